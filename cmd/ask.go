@@ -2,14 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ctrl-vfr/persona/internal/ffmpeg"
 	"github.com/ctrl-vfr/persona/internal/openai"
 	"github.com/ctrl-vfr/persona/internal/persona"
-	"github.com/ctrl-vfr/persona/internal/speak"
 	"github.com/ctrl-vfr/persona/internal/ui"
 
 	"github.com/spf13/cobra"
@@ -22,10 +21,17 @@ var (
 var askCmd = &cobra.Command{
 	Use:   "ask [nom]",
 	Short: "Simple discussion with a persona (non-interactive)",
-	Long:  "Simple discussion mode, one question-answer at a time. Use 'persona chat' for interactive interface.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Simple discussion mode, one question-answer at a time. Use 'persona chat' for interactive interface.
+Without a name, the persona of the .persona.yaml project file is used, or the default persona.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		personaName := args[0]
+		personaName := projectPersona()
+		if len(args) == 1 {
+			personaName = args[0]
+		}
+		if personaName == "" {
+			personaName = storageManager.DefaultPersonaName()
+		}
 
 		if askOutputFormat == "default" {
 			terminalWidth := ui.GetTerminalWidth()
@@ -46,7 +52,14 @@ var askCmd = &cobra.Command{
 			log.Fatal("Audio input device not configured. Use 'persona config set-input-device <device>'.")
 		}
 
-		aiClient := openai.New(os.Getenv("OPENAI_API_KEY"), appConfig.Models.Transcription, appConfig.Models.Speech, appConfig.Models.Chat, currentPersona.Voice.Name)
+		key, err := apiKey(appConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !checkBudget(appConfig) {
+			return
+		}
+		aiClient := personaClient(appConfig, key, currentPersona.Name, currentPersona)
 
 		// Start recording
 		if askOutputFormat == "default" {
@@ -78,10 +91,9 @@ var askCmd = &cobra.Command{
 			fmt.Println(ui.RenderUserMessage(transcription, terminalWidth, 0, true))
 		}
 
-		currentPersona.History = append(currentPersona.History, persona.Message{
-			Role:    "user",
-			Content: transcription,
-		})
+		question := persona.NewMessage("user", transcription)
+		question.Modality = persona.ModalityVoice
+		currentPersona.History = append(currentPersona.History, question)
 
 		conversationMessages := currentPersona.GetMessages()
 		aiMessages := []openai.Message{}
@@ -95,22 +107,23 @@ var askCmd = &cobra.Command{
 		if askOutputFormat == "default" {
 			fmt.Println(ui.RenderInfo("💭 Thinking..."))
 		}
-		aiResponse, err := aiClient.Chat(aiMessages)
+		completion, err := aiClient.Complete(aiMessages)
 		if err != nil {
 			log.Fatal("AI chat error:", err)
 		}
+		aiResponse := completion.Content
 
 		if askOutputFormat == "default" {
 			terminalWidth := ui.GetTerminalWidth()
 			fmt.Println(ui.RenderAssistantMessage(currentPersona.Name, aiResponse, terminalWidth, 0, true))
 		}
 
-		currentPersona.History = append(currentPersona.History, persona.Message{
-			Role:    "assistant",
-			Content: aiResponse,
-		})
-		_, historyPath := storageManager.GetPersonaPath(personaName)
-		err = currentPersona.SaveHistory(historyPath)
+		reply := persona.NewMessage("assistant", aiResponse)
+		reply.Model = completion.Model
+		reply.Usage = persona.Usage(completion.Usage)
+		reply.Latency = completion.Latency.Round(time.Millisecond)
+		currentPersona.History = append(currentPersona.History, reply)
+		err = storageManager.SaveHistory(personaName, currentPersona.History)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -118,31 +131,15 @@ var askCmd = &cobra.Command{
 		if askOutputFormat == "default" {
 			fmt.Println(ui.RenderInfo("🔊 Generating audio..."))
 		}
-		audioResponseData, err := aiClient.GenerateAudio(aiResponse, currentPersona.Voice.Instructions)
+		audio, err := aiClient.GenerateAudio(aiResponse, currentPersona.Voice.Instructions)
 		if err != nil {
 			log.Fatal("Audio generation error:", err)
 		}
 
-		audioBytes, err := io.ReadAll(audioResponseData)
-		if err != nil {
-			log.Fatal("Audio data read error:", err)
-		}
-
-		tempAudioResponseFile, err := os.CreateTemp("", "persona-response-*.mp3")
-		if err != nil {
-			log.Fatal("Temporary audio file creation error:", err)
-		}
-		defer os.Remove(tempAudioResponseFile.Name())
-
-		err = os.WriteFile(tempAudioResponseFile.Name(), audioBytes, 0o644)
-		if err != nil {
-			log.Fatal("Audio file write error:", err)
-		}
-
 		if askOutputFormat == "default" {
 			fmt.Println(ui.RenderInfo("🔈 Playing response..."))
 		}
-		err = speak.Play(tempAudioResponseFile.Name())
+		err = audioPlayer().Play(audio)
 		if err != nil {
 			log.Fatal("Response playback error:", err)
 		}
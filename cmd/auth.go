@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/auth"
+	"github.com/ctrl-vfr/persona/internal/config"
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	authProvider   string
+	authUseFile    bool
+	authNoValidate bool
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "API key management",
+	Long: `Store the API keys of the providers, in the OS keyring when available
+(secret-tool on Linux, the keychain on macOS) or in a file encrypted with a
+passphrase. The provider defaults to provider.name in the configuration.
+
+Environment variables such as OPENAI_API_KEY take precedence over stored keys.
+PERSONA_PASSPHRASE gives the passphrase of the encrypted file to scripts.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store the API key of a provider",
+	Long: `Ask the API key of a provider and store it. The key is read without echo
+from the terminal, or from stdin when it is piped:
+
+  pass show openai | persona auth login
+
+The key is checked against the provider's model list before being stored,
+unless --no-validate is given.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+			return
+		}
+		provider := authProviderName(appConfig)
+
+		key, err := readSecret(fmt.Sprintf("API key for %s: ", provider))
+		if err != nil {
+			fmt.Printf("Error reading API key: %v\n", err)
+			return
+		}
+		if key == "" {
+			fmt.Println("Error: the API key is empty")
+			return
+		}
+
+		if !authNoValidate {
+			if err := appConfig.NewClient(key, "").ValidateKey(); err != nil {
+				if errors.Is(err, openai.ErrInvalidKey) {
+					fmt.Printf("Error: the API key %s was rejected by %s\n", auth.Redact(key), provider)
+				} else {
+					fmt.Printf("Error validating API key: %v\n", err)
+				}
+				return
+			}
+		}
+
+		source, err := newAuthStore().Set(provider, key, authUseFile)
+		if err != nil {
+			fmt.Printf("Error storing API key: %v\n", err)
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("API key of %s stored in the %s.", provider, sourceLabel(source))))
+		if os.Getenv(auth.EnvName(provider)) != "" {
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("%s is set and takes precedence over the stored key.", auth.EnvName(provider))))
+		}
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Delete the stored API key of a provider",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+			return
+		}
+		provider := authProviderName(appConfig)
+
+		if err := newAuthStore().Delete(provider); err != nil {
+			fmt.Printf("Error deleting API key: %v\n", err)
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("API key of %s deleted.", provider)))
+		if os.Getenv(auth.EnvName(provider)) != "" {
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("%s is still set in the environment.", auth.EnvName(provider))))
+		}
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where the API keys come from",
+	Long:  "List the providers with an API key, redacted, and where it comes from: env, keyring or file.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+			return
+		}
+
+		store := newAuthStore()
+		statuses, err := store.Status(appConfig.ProviderName())
+		if err != nil {
+			fmt.Printf("Error reading credentials: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			result := map[string]any{
+				"provider":  appConfig.ProviderName(),
+				"keyring":   store.KeyringAvailable(),
+				"providers": statuses,
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			for _, status := range statuses {
+				fmt.Printf("%s\t%s\t%s\n", status.Provider, status.Source, status.Key)
+			}
+			return
+		}
+
+		if len(statuses) == 0 {
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("No API key, run 'persona auth login' or set %s.", auth.EnvName(appConfig.ProviderName()))))
+			return
+		}
+		fmt.Println(ui.RenderInfo("API keys:"))
+		fmt.Println()
+		for _, status := range statuses {
+			line := fmt.Sprintf("  - %s: %s", status.Provider, sourceLabel(status.Source))
+			if status.Key != "" {
+				line += " " + ui.RenderMuted(status.Key)
+			}
+			if status.Provider == appConfig.ProviderName() {
+				line += " " + ui.RenderMuted("(active)")
+			}
+			fmt.Println(line)
+		}
+		if !store.KeyringAvailable() {
+			fmt.Println()
+			fmt.Println(ui.RenderMuted("No OS keyring found, keys are stored in the encrypted file."))
+		}
+	},
+}
+
+// apiKey returns the API key of the configured provider
+func apiKey(appConfig *config.Config) (string, error) {
+	key, _, err := newAuthStore().Get(appConfig.ProviderName())
+	return key, err
+}
+
+func newAuthStore() *auth.Store {
+	return auth.NewStore(storageManager.BasePath, askPassphrase)
+}
+
+// authProviderName returns the provider given with --provider or the configured one
+func authProviderName(appConfig *config.Config) string {
+	if authProvider != "" {
+		return authProvider
+	}
+	return appConfig.ProviderName()
+}
+
+// askPassphrase reads the passphrase of the encrypted file from the terminal
+func askPassphrase(confirm bool) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no terminal to ask the passphrase, set %s", auth.PassphraseEnv)
+	}
+
+	passphrase, err := readSecret("Passphrase: ")
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+	again, err := readSecret("Passphrase again: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// readSecret reads a line without echo from the terminal, or from stdin when
+// it is not a terminal
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+func sourceLabel(source string) string {
+	switch source {
+	case auth.SourceEnv:
+		return "environment"
+	case auth.SourceKeyring:
+		return "OS keyring"
+	case auth.SourceFile:
+		return "encrypted file"
+	}
+	return source
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authStatusCmd)
+
+	authCmd.PersistentFlags().StringVar(&authProvider, "provider", "", "Provider of the key (default: provider.name)")
+
+	authLoginCmd.Flags().BoolVar(&authUseFile, "file", false, "Store the key in the encrypted file instead of the OS keyring")
+	authLoginCmd.Flags().BoolVar(&authNoValidate, "no-validate", false, "Store the key without checking it")
+
+	authStatusCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	authStatusCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+}
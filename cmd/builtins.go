@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/storage"
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	builtinsUpstream bool
+	builtinsForce    bool
+)
+
+var builtinsCmd = &cobra.Command{
+	Use:   "builtins",
+	Short: "Manage the built-in personas",
+	Long: `Compare the built-in personas installed in ~/.persona with the versions shipped
+in this release, and upgrade them while keeping your own edits.`,
+}
+
+var builtinsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the built-in personas are up to date",
+	Run: func(cmd *cobra.Command, args []string) {
+		statuses, err := storageManager.ListBuiltinStatuses()
+		if err != nil {
+			fmt.Printf("Error reading built-in personas: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, err := json.MarshalIndent(statuses, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			for _, status := range statuses {
+				fmt.Printf("%s\t%s\n", status.Name, status.Status)
+			}
+			return
+		}
+
+		fmt.Println(ui.TitleStyle.Render("Built-in personas:"))
+		for _, status := range statuses {
+			fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("%-12s %s", status.Name, describeBuiltinStatus(status.Status))))
+		}
+		fmt.Println()
+	},
+}
+
+var builtinsDiffCmd = &cobra.Command{
+	Use:   "diff [nom]",
+	Short: "Show the differences with the shipped version of a built-in persona",
+	Long: `Show the unified diff from your version of a built-in persona to the version
+shipped in this release. With --upstream, show what changed in the shipped
+version since you installed or last upgraded it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		d, err := storageManager.DiffBuiltin(args[0], builtinsUpstream)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if d == "" {
+			fmt.Println("No differences.")
+			return
+		}
+		fmt.Print(d)
+	},
+}
+
+var builtinsUpgradeCmd = &cobra.Command{
+	Use:   "upgrade [nom...]",
+	Short: "Upgrade the built-in personas to the shipped versions",
+	Long: `Upgrade built-in personas with a three-way merge: fields you edited are kept,
+fields that changed in the release are updated, and fields changed on both
+sides keep your value unless --force is given. Without names, every installed
+built-in is upgraded; name a removed built-in to reinstall it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		names := args
+		if len(names) == 0 {
+			statuses, err := storageManager.ListBuiltinStatuses()
+			if err != nil {
+				fmt.Printf("Error reading built-in personas: %v\n", err)
+				return
+			}
+			for _, status := range statuses {
+				if status.Status != storage.BuiltinRemoved {
+					names = append(names, status.Name)
+				}
+			}
+		}
+
+		results := make([]*storage.BuiltinUpgrade, 0, len(names))
+		for _, name := range names {
+			result, err := storageManager.UpgradeBuiltin(name, builtinsForce)
+			if err != nil {
+				fmt.Printf("Error upgrading %s: %v\n", name, err)
+				return
+			}
+			results = append(results, result)
+		}
+
+		if outputJSON {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		for _, result := range results {
+			line := describeBuiltinUpgrade(result)
+			if outputPlain {
+				fmt.Printf("%s: %s\n", result.Name, line)
+				continue
+			}
+			if len(result.Conflicts) > 0 && !builtinsForce {
+				fmt.Println(ui.RenderWarning(fmt.Sprintf("%s: %s", result.Name, line)))
+			} else {
+				fmt.Println(ui.RenderSuccess(fmt.Sprintf("%s: %s", result.Name, line)))
+			}
+		}
+	},
+}
+
+func describeBuiltinStatus(status string) string {
+	switch status {
+	case storage.BuiltinUpToDate:
+		return "up to date"
+	case storage.BuiltinModified:
+		return "modified locally"
+	case storage.BuiltinOutdated:
+		return "update available"
+	case storage.BuiltinDiverged:
+		return "modified locally, update available"
+	case storage.BuiltinRemoved:
+		return "removed"
+	default:
+		return "not installed"
+	}
+}
+
+func describeBuiltinUpgrade(result *storage.BuiltinUpgrade) string {
+	if result.Installed {
+		return "installed"
+	}
+
+	var parts []string
+	if len(result.Updated) > 0 {
+		parts = append(parts, "updated "+strings.Join(result.Updated, ", "))
+	}
+	if len(result.Conflicts) > 0 && !builtinsForce {
+		parts = append(parts, "kept your "+strings.Join(result.Conflicts, ", ")+" (use --force to replace)")
+	}
+	if len(parts) == 0 {
+		if result.Status == storage.BuiltinModified {
+			return "no update available, your edits are kept"
+		}
+		return "already up to date"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func init() {
+	rootCmd.AddCommand(builtinsCmd)
+	builtinsCmd.AddCommand(builtinsStatusCmd)
+	builtinsCmd.AddCommand(builtinsDiffCmd)
+	builtinsCmd.AddCommand(builtinsUpgradeCmd)
+
+	builtinsStatusCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	builtinsStatusCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+
+	builtinsDiffCmd.Flags().BoolVar(&builtinsUpstream, "upstream", false, "Show the changes of the shipped version since it was installed")
+
+	builtinsUpgradeCmd.Flags().BoolVar(&builtinsForce, "force", false, "Replace the fields you edited when they also changed upstream")
+	builtinsUpgradeCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	builtinsUpgradeCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+}
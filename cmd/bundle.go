@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/bundle"
+	"github.com/ctrl-vfr/persona/internal/storage"
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutput      string
+	exportWithHistory bool
+	exportAuthor      string
+	exportDescription string
+	exportTags        []string
+
+	importName      string
+	importRename    bool
+	importOverwrite bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [nom]",
+	Short: "Export a persona as a shareable bundle",
+	Long: `Export a persona as a .persona.tgz bundle holding its persona.yaml, a manifest
+(format version, author, description, tags and checksum) and, with
+--with-history, its conversation history. The author defaults to the
+configured user name.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		personaName := args[0]
+
+		if !storageManager.PersonaExists(personaName) {
+			fmt.Printf("Persona '%s' does not exist.\n", personaName)
+			return
+		}
+
+		author := exportAuthor
+		if author == "" {
+			if appConfig, err := storageManager.GetConfig(); err == nil {
+				author = appConfig.User.Name
+			}
+		}
+
+		b, err := storageManager.ExportPersona(personaName, bundle.Manifest{
+			Author:      author,
+			Description: exportDescription,
+			Tags:        exportTags,
+		}, exportWithHistory)
+		if err != nil {
+			fmt.Printf("Error exporting persona: %v\n", err)
+			return
+		}
+
+		output := exportOutput
+		if output == "" {
+			output = personaName + bundle.Extension
+		}
+
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Printf("Error creating bundle: %v\n", err)
+			return
+		}
+		if err := bundle.Write(file, b); err != nil {
+			file.Close()
+			os.Remove(output)
+			fmt.Printf("Error writing bundle: %v\n", err)
+			return
+		}
+		if err := file.Close(); err != nil {
+			fmt.Printf("Error writing bundle: %v\n", err)
+			return
+		}
+
+		if definition, err := storageManager.GetPersonaDefinition(personaName); err == nil {
+			if definition.Extends != "" || len(definition.Includes) > 0 {
+				fmt.Fprintln(os.Stderr, ui.RenderWarning("The persona extends other personas or includes fragments, which are not part of the bundle."))
+			}
+		}
+
+		if outputJSON {
+			result := map[string]any{
+				"persona":  personaName,
+				"file":     output,
+				"manifest": b.Manifest,
+				"status":   "exported",
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			fmt.Printf("Persona '%s' exported to %s\n", personaName, output)
+			return
+		}
+
+		fmt.Println(ui.TitleStyle.Render("Persona exported:"))
+		fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("%s -> %s", personaName, output)))
+		fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("Checksum: %s", b.Manifest.Checksum)))
+		fmt.Println()
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [fichier]",
+	Short: "Import a persona bundle",
+	Long: `Import a persona from a .persona.tgz bundle created by 'persona export'.
+The bundle checksum and the persona file are checked before anything is
+written. When a persona with the same name exists, use --rename to import it
+under a free name, --name to choose one, or --overwrite to replace it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if importRename && importOverwrite {
+			fmt.Println("Error: --rename and --overwrite cannot be used together")
+			return
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			fmt.Printf("Error opening bundle: %v\n", err)
+			return
+		}
+		b, err := bundle.Read(file)
+		file.Close()
+		if err != nil {
+			fmt.Printf("Error reading bundle: %v\n", err)
+			return
+		}
+
+		personaName := b.Manifest.Name
+		if importName != "" {
+			personaName = importName
+		}
+		if importRename {
+			personaName = storageManager.AvailablePersonaName(personaName)
+		}
+
+		if err := storageManager.ImportPersona(b, personaName, importOverwrite); err != nil {
+			if errors.Is(err, storage.ErrPersonaExists) {
+				fmt.Printf("Persona '%s' already exists: use --rename, --name or --overwrite.\n", personaName)
+				return
+			}
+			fmt.Println(ui.RenderLoadError("Error importing persona", err))
+			return
+		}
+
+		if outputJSON {
+			result := map[string]any{
+				"persona":  personaName,
+				"manifest": b.Manifest,
+				"status":   "imported",
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			fmt.Printf("Persona '%s' imported successfully\n", personaName)
+			return
+		}
+
+		fmt.Println(ui.TitleStyle.Render("Persona imported:"))
+		fmt.Println(ui.ContentStyle.Render(personaName))
+		if b.Manifest.Description != "" {
+			fmt.Println(ui.ContentStyle.Render(b.Manifest.Description))
+		}
+		if b.Manifest.Author != "" {
+			fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("Author: %s", b.Manifest.Author)))
+		}
+		if len(b.Manifest.Tags) > 0 {
+			fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("Tags: %s", strings.Join(b.Manifest.Tags, ", "))))
+		}
+		fmt.Println()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Bundle file (default: <nom>.persona.tgz)")
+	exportCmd.Flags().BoolVar(&exportWithHistory, "with-history", false, "Include the conversation history")
+	exportCmd.Flags().StringVar(&exportAuthor, "author", "", "Author written in the manifest")
+	exportCmd.Flags().StringVar(&exportDescription, "description", "", "Description written in the manifest")
+	exportCmd.Flags().StringSliceVar(&exportTags, "tag", nil, "Tag written in the manifest (repeatable)")
+	exportCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	exportCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+
+	importCmd.Flags().StringVar(&importName, "name", "", "Import the persona under another name")
+	importCmd.Flags().BoolVar(&importRename, "rename", false, "Pick a free name when the persona already exists")
+	importCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "Replace an existing persona")
+	importCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	importCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+}
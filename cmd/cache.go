@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Speech cache management",
+	Long: `Synthesized speech is cached by speech model, voice, instructions and text,
+so that reading a file again or replaying a reply (Ctrl+O in the chat) costs
+nothing. The least recently used entries are evicted past cache.max_size
+megabytes.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the size of the caches",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+			return
+		}
+
+		stats, err := storageManager.SpeechCache(appConfig).Stats()
+		if err != nil {
+			fmt.Printf("Error reading cache: %v\n", err)
+			return
+		}
+		var searchSize int64
+		if info, err := os.Stat(storageManager.GetSearchIndexPath()); err == nil {
+			searchSize = info.Size()
+		}
+
+		if outputJSON {
+			result := map[string]any{
+				"speech": stats,
+				"search": map[string]any{"path": storageManager.GetSearchIndexPath(), "size": searchSize},
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			fmt.Printf("speech\t%d\t%d\t%d\n", stats.Entries, stats.Size, stats.Limit)
+			fmt.Printf("search\t\t%d\t\n", searchSize)
+			return
+		}
+
+		fmt.Println(ui.RenderInfo("Caches:"))
+		fmt.Println()
+		fmt.Printf("  - speech: %d entries, %s of %s (%s)\n", stats.Entries, formatSize(stats.Size), formatSize(stats.Limit), ui.RenderMuted(stats.Dir))
+		fmt.Printf("  - search index: %s (%s)\n", formatSize(searchSize), ui.RenderMuted(storageManager.GetSearchIndexPath()))
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Empty the caches",
+	Long:  "Remove the cached speech and the search index, which is rebuilt by the next search.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+			return
+		}
+
+		removed, err := storageManager.SpeechCache(appConfig).Clear()
+		if err != nil {
+			fmt.Printf("Error clearing cache: %v\n", err)
+			return
+		}
+		if err := os.Remove(storageManager.GetSearchIndexPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error clearing search index: %v\n", err)
+			return
+		}
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Cache cleared: %d speech entries removed.", removed)))
+	},
+}
+
+// formatSize formats a number of bytes for humans
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	cacheStatsCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	cacheStatsCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+}
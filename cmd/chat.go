@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/ffmpeg"
+	"github.com/ctrl-vfr/persona/internal/group"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/storage"
 	"github.com/ctrl-vfr/persona/internal/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,10 +18,17 @@ import (
 )
 
 var chatCmd = &cobra.Command{
-	Use:   "chat [nom-persona]",
+	Use:   "chat [nom-persona...]",
 	Short: "Interactive chat interface with a persona",
 	Long: `Launch an interactive real-time chat interface with a persona.
 If no persona is specified, a selection interface will be displayed.
+With several personas, a group conversation starts where each persona
+answers in turn with its own voice, or where a moderator picks who speaks
+(--moderator). The shared transcript is stored as a group session.
+
+Without a persona, the one chosen by the .persona.yaml project file is
+opened if there is one. With a single persona, --session picks the
+conversation to resume, overriding the project's session.
 
 Features:
 • Interactive persona selection
@@ -28,11 +39,26 @@ Features:
 • Modern colorful interface that adapts to terminal
 • Multi-instance support with file watching
 • Automatic resizing`,
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			runGroupChat(args)
+			return
+		}
+
 		var personaName string
 		if len(args) > 0 {
 			personaName = args[0]
+		} else {
+			personaName = projectPersona()
+		}
+
+		if chatSession != "" {
+			if err := storage.ValidatePersonaName(chatSession); err != nil {
+				fmt.Println(ui.RenderError(fmt.Sprintf("Invalid session: %v", err)))
+				return
+			}
+			storageManager.Session = chatSession
 		}
 
 		// If no persona specified, start with selection interface
@@ -40,7 +66,7 @@ Features:
 			// Load configuration first to check requirements
 			appConfig, err := storageManager.GetConfig()
 			if err != nil {
-				fmt.Println(ui.RenderError(fmt.Sprintf("Error loading configuration: %v", err)))
+				fmt.Println(ui.RenderLoadError("Error loading configuration", err))
 				return
 			}
 
@@ -53,9 +79,12 @@ Features:
 			}
 
 			// Initialize OpenAI client
-			openaiAPIKey := os.Getenv("OPENAI_API_KEY")
-			if openaiAPIKey == "" {
-				fmt.Println(ui.RenderError("OPENAI_API_KEY is not set in environment variables."))
+			openaiAPIKey, err := apiKey(appConfig)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			if !checkBudget(appConfig) {
 				return
 			}
 
@@ -64,6 +93,7 @@ Features:
 				storageManager,
 				appConfig,
 				openaiAPIKey,
+				audioPlayer(),
 			)
 
 			// Set up cleanup on interrupt
@@ -106,14 +136,14 @@ Features:
 		// Load persona
 		currentPersona, err := storageManager.GetPersona(personaName)
 		if err != nil {
-			fmt.Println(ui.RenderError(fmt.Sprintf("Error loading persona: %v", err)))
+			fmt.Println(ui.RenderLoadError("Error loading persona", err))
 			return
 		}
 
 		// Load configuration
 		appConfig, err := storageManager.GetConfig()
 		if err != nil {
-			fmt.Println(ui.RenderError(fmt.Sprintf("Error loading configuration: %v", err)))
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
 			return
 		}
 
@@ -126,25 +156,26 @@ Features:
 		}
 
 		// Initialize OpenAI client
-		openaiAPIKey := os.Getenv("OPENAI_API_KEY")
-		if openaiAPIKey == "" {
-			fmt.Println(ui.RenderError("OPENAI_API_KEY is not set in environment variables."))
+		openaiAPIKey, err := apiKey(appConfig)
+		if err != nil {
+			fmt.Println(ui.RenderError(err.Error()))
+			return
+		}
+		if !checkBudget(appConfig) {
 			return
 		}
 
-		aiClient := openai.New(
-			openaiAPIKey,
-			appConfig.Models.Transcription,
-			appConfig.Models.Speech,
-			appConfig.Models.Chat,
-			currentPersona.Voice.Name,
-		)
+		aiClient := personaClient(appConfig, openaiAPIKey, currentPersona.Name, currentPersona)
 
 		// Create chat model
 		chatModel := ui.NewChatModel(
 			currentPersona,
 			aiClient,
+			func(p *persona.Persona) ui.AIClient {
+				return personaClient(appConfig, openaiAPIKey, p.Name, p)
+			},
 			storageManager,
+			audioPlayer(),
 			appConfig.Audio.InputDevice,
 			appConfig.Audio.SilenceThreshold,
 			appConfig.Audio.SilenceDuration,
@@ -180,6 +211,111 @@ Features:
 	},
 }
 
+var (
+	chatModerator bool
+	chatSession   string
+)
+
+// runGroupChat starts a conversation between the user and several personas
+func runGroupChat(names []string) {
+	appConfig, err := storageManager.GetConfig()
+	if err != nil {
+		fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+		return
+	}
+
+	if appConfig.Audio.InputDevice == "" {
+		fmt.Println(ui.RenderError("Audio input device not configured."))
+		fmt.Println(ui.RenderMuted("Use 'persona config set-input-device <device>' to configure it."))
+		fmt.Println(ui.RenderMuted("List devices with 'persona ffmpeg list input'."))
+		return
+	}
+
+	openaiAPIKey, err := apiKey(appConfig)
+	if err != nil {
+		fmt.Println(ui.RenderError(err.Error()))
+		return
+	}
+	if !checkBudget(appConfig) {
+		return
+	}
+
+	// Load every participant with an AI client speaking in its voice
+	personas := make(map[string]*persona.Persona, len(names))
+	clients := make(map[string]ui.AIClient, len(names))
+	for _, name := range names {
+		if _, exists := personas[name]; exists {
+			fmt.Println(ui.RenderError(fmt.Sprintf("Persona '%s' is listed twice.", name)))
+			return
+		}
+		if !storageManager.PersonaExists(name) {
+			fmt.Println(ui.RenderError(fmt.Sprintf("Persona '%s' does not exist.", name)))
+			fmt.Println(ui.RenderMuted("Use 'persona list' to see available personas."))
+			return
+		}
+
+		p, err := storageManager.GetPersona(name)
+		if err != nil {
+			fmt.Println(ui.RenderLoadError(fmt.Sprintf("Error loading persona %s", name), err))
+			return
+		}
+		// Participants are addressed by the name used on the command line
+		p.Name = name
+		personas[name] = p
+		clients[name] = personaClient(appConfig, openaiAPIKey, name, p)
+	}
+
+	mode := group.ModeRoundtable
+	if chatModerator {
+		mode = group.ModeModerator
+	}
+
+	// Resume the session for these participants if it exists
+	sessionName := chatSession
+	if sessionName == "" {
+		sessionName = group.DefaultName(names)
+	}
+	if err := storage.ValidateGroupName(sessionName); err != nil {
+		fmt.Println(ui.RenderError(fmt.Sprintf("Invalid session: %v", err)))
+		return
+	}
+	session, err := storageManager.GetGroupSession(sessionName)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Println(ui.RenderError(fmt.Sprintf("Error loading group session: %v", err)))
+			return
+		}
+		session = group.New(names, mode)
+		session.Name = sessionName
+	}
+	session.Participants = names
+	session.Mode = mode
+
+	chatModel := ui.NewGroupChatModel(
+		session,
+		personas,
+		clients,
+		storageManager,
+		ffmpeg.New(appConfig.Audio.InputDevice, appConfig.Audio.SilenceThreshold, appConfig.Audio.SilenceDuration),
+		audioPlayer(),
+	)
+
+	program := tea.NewProgram(
+		chatModel,
+		tea.WithAltScreen(),       // Use alternate screen
+		tea.WithMouseCellMotion(), // Enable mouse support
+	)
+
+	if _, err := program.Run(); err != nil {
+		fmt.Printf("❌ Chat execution error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(ui.RenderSuccess("Chat completed! Goodbye! 👋"))
+}
+
 func init() {
+	chatCmd.Flags().BoolVar(&chatModerator, "moderator", false, "Let a moderator choose which persona answers (group chat)")
+	chatCmd.Flags().StringVar(&chatSession, "session", "", "Conversation session to resume (group chats default to the participants' names)")
 	rootCmd.AddCommand(chatCmd)
 }
@@ -1,15 +1,27 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
+	"github.com/ctrl-vfr/persona/internal/config"
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/schema"
 	"github.com/ctrl-vfr/persona/internal/ui"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	configForce   bool
+	configUseNone bool
+)
+
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Configuration management",
@@ -61,6 +73,264 @@ var showConfigCmd = &cobra.Command{
 				fmt.Printf("  %s\n", line)
 			}
 		}
+
+		if overrides := envOverrides(); len(overrides) > 0 {
+			fmt.Println()
+			fmt.Println(ui.RenderMuted("Overridden by the environment: " + strings.Join(overrides, ", ")))
+		}
+	},
+}
+
+var getConfigCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Display a configuration value",
+	Long: `Display the value of a configuration key, such as audio.silence_duration.
+Without a key, list every key with its value.
+
+Values include the overrides of the PERSONA_* environment variables, named
+after the keys (PERSONA_AUDIO_SILENCE_DURATION for audio.silence_duration).`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			return
+		}
+
+		keys := config.Keys()
+		if len(args) == 1 {
+			key, err := config.LookupKey(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			keys = []config.Key{key}
+		}
+
+		type keyValue struct {
+			Key     string   `json:"key"`
+			Value   any      `json:"value"`
+			Kind    string   `json:"kind"`
+			Env     string   `json:"env"`
+			FromEnv bool     `json:"from_env"`
+			Allowed []string `json:"allowed,omitempty"`
+		}
+		values := make([]keyValue, 0, len(keys))
+		for _, key := range keys {
+			value, _ := appConfig.Get(key.Name)
+			_, fromEnv := os.LookupEnv(key.EnvName())
+			values = append(values, keyValue{
+				Key:     key.Name,
+				Value:   value,
+				Kind:    key.Kind,
+				Env:     key.EnvName(),
+				FromEnv: fromEnv,
+				Allowed: key.Allowed,
+			})
+		}
+
+		if outputJSON {
+			var result any = values
+			if len(args) == 1 {
+				result = values[0]
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if len(args) == 1 {
+			fmt.Println(config.FormatValue(values[0].Value))
+			return
+		}
+
+		if outputPlain {
+			for _, value := range values {
+				fmt.Printf("%s=%s\n", value.Key, config.FormatValue(value.Value))
+			}
+			return
+		}
+
+		// Default: full formatted output
+		fmt.Println(ui.RenderInfo("Configuration keys:"))
+		fmt.Println()
+		for i, value := range values {
+			line := fmt.Sprintf("  - %s = %q", value.Key, config.FormatValue(value.Value))
+			if value.FromEnv {
+				line += ui.RenderMuted(fmt.Sprintf(" (from %s)", value.Env))
+			}
+			fmt.Println(line)
+			fmt.Println(ui.RenderMuted("    " + keys[i].Description))
+		}
+	},
+}
+
+var setConfigCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Set a configuration value",
+	Long: `Set a configuration key, such as 'persona config set audio.silence_duration 1.5'.
+The value is checked against the type of the key and, for models, against the
+known models; use --force for a model missing from the list.
+List values, such as persona_paths, are separated by commas.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, value := args[0], args[1]
+
+		appConfig, err := storageManager.GetConfigFile()
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			return
+		}
+
+		if err := appConfig.Set(name, value, configForce); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			if errors.Is(err, config.ErrNotAllowed) {
+				fmt.Println("Use --force to set it anyway.")
+			}
+			return
+		}
+		if name == "default_persona" && value != "" && !storageManager.PersonaExists(value) {
+			fmt.Printf("Error: persona %s does not exist\n", value)
+			return
+		}
+		if _, ok := appConfig.Profiles[value]; name == "profile" && value != "" && !ok {
+			fmt.Printf("Error: profile %s is not defined in profiles\n", value)
+			return
+		}
+
+		if err := storageManager.SaveConfig(appConfig); err != nil {
+			fmt.Printf("Configuration save error: %v\n", err)
+			return
+		}
+
+		printConfigValue(appConfig, name, "set")
+		if name == "models.speech" {
+			warnUnsupportedVoices(appConfig.Models.Speech)
+		}
+	},
+}
+
+var unsetConfigCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "Restore the default value of a configuration key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		appConfig, err := storageManager.GetConfigFile()
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			return
+		}
+
+		if err := appConfig.Unset(name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if err := storageManager.SaveConfig(appConfig); err != nil {
+			fmt.Printf("Configuration save error: %v\n", err)
+			return
+		}
+
+		printConfigValue(appConfig, name, "unset")
+	},
+}
+
+var useConfigCmd = &cobra.Command{
+	Use:   "use [profile]",
+	Short: "Select the active configuration profile",
+	Long: `Select the active profile, whose settings replace the base ones. Profiles are
+defined under 'profiles' in config.yaml, e.g. a streaming and a desk setup:
+
+  profiles:
+    streaming:
+      models:
+        chat: gpt-4.1-nano
+      audio:
+        input_device: "CABLE Output (VB-Audio Virtual Cable)"
+        silence_duration: 0.8
+
+Without a profile, list the profiles. Use --none to go back to the base settings.
+--profile selects a profile for a single command instead.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfigFile()
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			return
+		}
+
+		if len(args) == 0 && !configUseNone {
+			listProfiles(appConfig)
+			return
+		}
+
+		profile := ""
+		if len(args) == 1 {
+			profile = args[0]
+			if _, ok := appConfig.Profiles[profile]; !ok {
+				fmt.Printf("Error: profile %s is not defined in profiles\n", profile)
+				return
+			}
+		}
+		appConfig.Profile = profile
+
+		if err := storageManager.SaveConfig(appConfig); err != nil {
+			fmt.Printf("Configuration save error: %v\n", err)
+			return
+		}
+
+		printConfigValue(appConfig, "profile", "configured")
+	},
+}
+
+var editConfigCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the configuration file in $EDITOR",
+	Long:  "Open the configuration file in $EDITOR and validate it before saving. Invalid changes can be edited again or discarded.",
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath := storageManager.GetConfigPath()
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			fmt.Printf("Error reading configuration: %v\n", err)
+			return
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			edited, err := editInEditor(data)
+			if err != nil {
+				fmt.Println(ui.RenderLoadError("Error running the editor", err))
+				return
+			}
+			data = edited
+
+			diagnostics := schema.ValidateConfig(configPath, data)
+			if len(diagnostics) > 0 {
+				fmt.Println(ui.RenderDiagnostics(diagnostics))
+			}
+			if schema.Check(diagnostics) == nil {
+				break
+			}
+
+			fmt.Print("The configuration is invalid: [e]dit again or [q]uit without saving? ")
+			answer, _ := reader.ReadString('\n')
+			if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "e") {
+				fmt.Println(ui.RenderMuted("Configuration unchanged."))
+				return
+			}
+		}
+
+		if err := os.WriteFile(configPath, data, 0644); err != nil {
+			fmt.Printf("Configuration save error: %v\n", err)
+			return
+		}
+		fmt.Println(ui.RenderSuccess("Configuration saved"))
 	},
 }
 
@@ -68,11 +338,16 @@ var pathConfigCmd = &cobra.Command{
 	Use:   "path",
 	Short: "Display configuration paths",
 	Run: func(cmd *cobra.Command, args []string) {
+		// The first directory is the user's own, the others are read-only
+		searchPaths := storageManager.PersonaDirs()[1:]
+
 		if outputJSON {
 			pathData := map[string]interface{}{
-				"config_dir":   storageManager.BasePath,
-				"config_file":  storageManager.BasePath + "/config.yaml",
-				"personas_dir": storageManager.BasePath + "/personas/",
+				"config_dir":    storageManager.BasePath,
+				"config_file":   storageManager.BasePath + "/config.yaml",
+				"personas_dir":  storageManager.BasePath + "/personas/",
+				"fragments_dir": storageManager.BasePath + "/fragments/",
+				"persona_paths": searchPaths,
 			}
 			data, err := json.MarshalIndent(pathData, "", "  ")
 			if err != nil {
@@ -87,6 +362,10 @@ var pathConfigCmd = &cobra.Command{
 			fmt.Printf("Config directory: %s\n", storageManager.BasePath)
 			fmt.Printf("Config file: %s/config.yaml\n", storageManager.BasePath)
 			fmt.Printf("Personas directory: %s/personas/\n", storageManager.BasePath)
+			fmt.Printf("Fragments directory: %s/fragments/\n", storageManager.BasePath)
+			for _, path := range searchPaths {
+				fmt.Printf("Persona path (read-only): %s\n", path)
+			}
 			return
 		}
 
@@ -96,6 +375,10 @@ var pathConfigCmd = &cobra.Command{
 		fmt.Printf("  - Config directory: %s\n", storageManager.BasePath)
 		fmt.Printf("  - Config file: %s/config.yaml\n", storageManager.BasePath)
 		fmt.Printf("  - Personas directory: %s/personas/\n", storageManager.BasePath)
+		fmt.Printf("  - Fragments directory: %s/fragments/\n", storageManager.BasePath)
+		for _, path := range searchPaths {
+			fmt.Printf("  - Persona path (read-only): %s\n", path)
+		}
 	},
 }
 
@@ -107,7 +390,7 @@ var setInputDeviceCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		deviceName := args[0]
 
-		appConfig, err := storageManager.GetConfig()
+		appConfig, err := storageManager.GetConfigFile()
 		if err != nil {
 			fmt.Printf("Error loading configuration: %v\n", err)
 			return
@@ -147,11 +430,168 @@ var setInputDeviceCmd = &cobra.Command{
 	},
 }
 
+var setUserNameCmd = &cobra.Command{
+	Use:   "set-user-name [name]",
+	Short: "Set the user name",
+	Long:  "Set the user name substituted for {{user.name}} in persona prompts",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userName := args[0]
+
+		appConfig, err := storageManager.GetConfigFile()
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			return
+		}
+
+		appConfig.User.Name = userName
+
+		err = storageManager.SaveConfig(appConfig)
+		if err != nil {
+			fmt.Printf("Configuration save error: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			result := map[string]interface{}{
+				"user_name": userName,
+				"status":    "configured",
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			fmt.Printf("User name configured: %s\n", userName)
+			return
+		}
+
+		// Default: full formatted output
+		fmt.Println(ui.RenderSuccess("User name configured:"))
+		fmt.Println()
+		fmt.Printf("  - %s\n", userName)
+	},
+}
+
+// printConfigValue reports the new value of a configuration key
+func printConfigValue(appConfig *config.Config, name, status string) {
+	value, _ := appConfig.Get(name)
+
+	if outputJSON {
+		result := map[string]any{
+			"key":    name,
+			"value":  value,
+			"status": status,
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if outputPlain {
+		fmt.Printf("%s=%s\n", name, config.FormatValue(value))
+		return
+	}
+
+	fmt.Println(ui.RenderSuccess(fmt.Sprintf("%s = %q", name, config.FormatValue(value))))
+	if key, err := config.LookupKey(name); err == nil {
+		if _, ok := os.LookupEnv(key.EnvName()); ok {
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("%s is set and overrides this value.", key.EnvName())))
+		}
+	}
+}
+
+// listProfiles prints the profiles defined in the configuration file
+func listProfiles(appConfig *config.Config) {
+	names := appConfig.ProfileNames()
+	active := storageManager.ActiveProfile()
+
+	if outputJSON {
+		result := map[string]any{
+			"profiles": names,
+			"active":   active,
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if outputPlain {
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	if len(names) == 0 {
+		fmt.Println(ui.RenderMuted("No profile defined, add them under 'profiles' in config.yaml."))
+		return
+	}
+	fmt.Println(ui.RenderInfo("Profiles:"))
+	fmt.Println()
+	for _, name := range names {
+		if name == active {
+			fmt.Printf("  - %s %s\n", name, ui.RenderMuted("(active)"))
+		} else {
+			fmt.Printf("  - %s\n", name)
+		}
+	}
+}
+
+// warnUnsupportedVoices lists the personas whose voice the speech model does not have
+func warnUnsupportedVoices(speechModel string) {
+	voices := openai.Voices(speechModel)
+	if voices == nil {
+		return
+	}
+
+	names, err := storageManager.ListPersonas()
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		p, err := storageManager.GetPersona(name)
+		if err == nil && p.Voice.Name != "" && !slices.Contains(voices, p.Voice.Name) {
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("Warning: %s does not have the voice %q of persona %s", speechModel, p.Voice.Name, name)))
+		}
+	}
+}
+
+// envOverrides returns the environment variables overriding configuration keys
+func envOverrides() []string {
+	var overrides []string
+	for _, key := range config.Keys() {
+		if _, ok := os.LookupEnv(key.EnvName()); ok {
+			overrides = append(overrides, key.EnvName())
+		}
+	}
+	return overrides
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(showConfigCmd)
 	configCmd.AddCommand(pathConfigCmd)
+	configCmd.AddCommand(getConfigCmd)
+	configCmd.AddCommand(setConfigCmd)
+	configCmd.AddCommand(unsetConfigCmd)
+	configCmd.AddCommand(editConfigCmd)
+	configCmd.AddCommand(useConfigCmd)
 	configCmd.AddCommand(setInputDeviceCmd)
+	configCmd.AddCommand(setUserNameCmd)
 
 	// Add output format flags
 	showConfigCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
@@ -162,4 +602,21 @@ func init() {
 
 	setInputDeviceCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
 	setInputDeviceCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+
+	getConfigCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	getConfigCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+
+	setConfigCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	setConfigCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+	setConfigCmd.Flags().BoolVar(&configForce, "force", false, "Accept a value missing from the allowed values")
+
+	useConfigCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	useConfigCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+	useConfigCmd.Flags().BoolVar(&configUseNone, "none", false, "Go back to the base settings, without profile")
+
+	unsetConfigCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	unsetConfigCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+
+	setUserNameCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	setUserNameCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
 }
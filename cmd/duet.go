@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ctrl-vfr/persona/internal/ffmpeg"
+	"github.com/ctrl-vfr/persona/internal/group"
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	duetTopic  string
+	duetTurns  int
+	duetFormat string
+	duetOutput string
+	duetAudio  string
+	duetPlay   bool
+)
+
+var duetCmd = &cobra.Command{
+	Use:   "duet [persona-a] [persona-b]",
+	Short: "Let two personas converse on a topic",
+	Long: `Let two personas converse autonomously on a topic, each with its own prompt and voice.
+The script is written as Markdown or JSON. With --audio, every reply is voiced and
+stitched into a single audio file with ffmpeg; --play reads the replies live.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if duetTopic == "" {
+			log.Fatal("A topic is required: use --topic \"...\"")
+		}
+		if duetTurns < 1 {
+			log.Fatal("--turns must be at least 1")
+		}
+		if duetFormat != "markdown" && duetFormat != "json" {
+			log.Fatal("Unknown format: use markdown or json")
+		}
+		if args[0] == args[1] {
+			log.Fatal("A duet needs two different personas")
+		}
+
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			log.Fatal("Error loading configuration:", err)
+		}
+		key, err := apiKey(appConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !checkBudget(appConfig) {
+			return
+		}
+
+		// Load both personas with a client speaking in their voice
+		personas := make([]*persona.Persona, 0, 2)
+		clients := make(map[string]*openai.OpenAI, 2)
+		instructions := make(map[string]string, 2)
+		for _, name := range args {
+			p, err := storageManager.GetPersona(name)
+			if err != nil {
+				log.Fatalf("Error loading persona %s: %v", name, err)
+			}
+			// Participants are addressed by the name used on the command line
+			p.Name = name
+			personas = append(personas, p)
+			instructions[name] = p.Voice.Instructions
+			clients[name] = personaClient(appConfig, key, name, p)
+		}
+
+		voiced := duetAudio != "" || duetPlay
+		audioDir := ""
+		if duetAudio != "" {
+			audioDir, err = os.MkdirTemp("", "persona-duet-*")
+			if err != nil {
+				log.Fatal("Temporary audio directory creation error:", err)
+			}
+			defer os.RemoveAll(audioDir)
+		}
+
+		terminalWidth := ui.GetTerminalWidth()
+		fmt.Fprintln(os.Stderr, ui.RenderChatBoxTitle(fmt.Sprintf("🎙️ %s × %s : %s", args[0], args[1], duetTopic), terminalWidth))
+
+		duet := group.NewDuet(personas[0], personas[1], duetTopic)
+		chat := func(speaker string, messages []persona.Message) (string, error) {
+			aiMessages := make([]openai.Message, 0, len(messages))
+			for _, message := range messages {
+				aiMessages = append(aiMessages, openai.Message{Role: message.Role, Content: message.Content})
+			}
+			return clients[speaker].Chat(aiMessages)
+		}
+
+		var audioFiles []string
+		player := audioPlayer()
+		for turn := 0; turn < duetTurns; turn++ {
+			line, err := duet.Next(chat)
+			if err != nil {
+				log.Fatal("Chat error:", err)
+			}
+			fmt.Fprintln(os.Stderr, ui.RenderGroupMessage(line.Speaker, line.Content, terminalWidth))
+
+			if !voiced {
+				continue
+			}
+
+			audio, err := clients[line.Speaker].GenerateAudio(line.Content, instructions[line.Speaker])
+			if err != nil {
+				log.Fatal("Audio generation error:", err)
+			}
+			if duetAudio != "" {
+				audioFile := filepath.Join(audioDir, fmt.Sprintf("%03d-%s.mp3", turn, line.Speaker))
+				if err := writeAudio(audioFile, audio); err != nil {
+					log.Fatal("Audio file write error:", err)
+				}
+				audioFiles = append(audioFiles, audioFile)
+				if !duetPlay {
+					continue
+				}
+				if audio, err = os.Open(audioFile); err != nil {
+					log.Fatal("Audio file read error:", err)
+				}
+			}
+
+			// The reply plays while the next one is written
+			if err := player.Enqueue(audio); err != nil {
+				log.Fatal("Audio playback error:", err)
+			}
+		}
+		if duetPlay {
+			if err := player.Wait(); err != nil {
+				log.Fatal("Audio playback error:", err)
+			}
+		}
+
+		if duetAudio != "" {
+			fmt.Fprintln(os.Stderr, ui.RenderInfo("🎚️ Mixing audio..."))
+			if err := ffmpeg.Concat(audioFiles, duetAudio); err != nil {
+				log.Fatal("Audio mixing error:", err)
+			}
+			fmt.Fprintln(os.Stderr, ui.RenderSuccess(fmt.Sprintf("Audio saved to %s", duetAudio)))
+		}
+
+		script := duet.Script()
+		var content string
+		if duetFormat == "json" {
+			data, err := json.MarshalIndent(script, "", "  ")
+			if err != nil {
+				log.Fatal("Script encoding error:", err)
+			}
+			content = string(data) + "\n"
+		} else {
+			content = script.Markdown()
+		}
+
+		if duetOutput == "" {
+			fmt.Print(content)
+			return
+		}
+		if err := os.WriteFile(duetOutput, []byte(content), 0644); err != nil {
+			log.Fatal("Script write error:", err)
+		}
+		fmt.Fprintln(os.Stderr, ui.RenderSuccess(fmt.Sprintf("Script saved to %s", duetOutput)))
+	},
+}
+
+func writeAudio(path string, audioData io.Reader) error {
+	data, err := io.ReadAll(audioData)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func init() {
+	rootCmd.AddCommand(duetCmd)
+	duetCmd.Flags().StringVar(&duetTopic, "topic", "", "Topic of the conversation")
+	duetCmd.Flags().IntVar(&duetTurns, "turns", 10, "Number of replies")
+	duetCmd.Flags().StringVar(&duetFormat, "format", "markdown", "Script format (markdown, json)")
+	duetCmd.Flags().StringVarP(&duetOutput, "output", "o", "", "Write the script to a file instead of stdout")
+	duetCmd.Flags().StringVar(&duetAudio, "audio", "", "Write the voiced conversation to a single audio file")
+	duetCmd.Flags().BoolVar(&duetPlay, "play", false, "Play each reply as it is generated")
+}
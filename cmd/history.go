@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/export"
+	"github.com/ctrl-vfr/persona/internal/ffmpeg"
+	"github.com/ctrl-vfr/persona/internal/speak"
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyFormat    string
+	historyOutput    string
+	historyWithAudio bool
+	historyCaptions  string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Conversation history management",
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export [nom]",
+	Short: "Export a conversation to share it",
+	Long: `Export the conversation with a persona as Markdown, HTML, JSON or plain text,
+with the persona's voice, model and the dates of the conversation. Without a
+name, the persona of the .persona.yaml project file is used, or the default
+persona.
+
+--with-audio voices the replies, reusing the ones voiced by a previous export:
+  - with --format html, the page embeds an audio player for each reply;
+  - with the other formats, the replies are concatenated in a single audio
+    file next to --output, with a caption track (--captions srt or vtt).
+
+  persona history export sherlock --format html --with-audio -o enquete.html
+  persona history export sherlock --with-audio -o enquete.md  # enquete.mp3, enquete.srt`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		personaName := projectPersona()
+		if len(args) == 1 {
+			personaName = args[0]
+		}
+		if personaName == "" {
+			personaName = storageManager.DefaultPersonaName()
+		}
+
+		if !slices.Contains(export.Formats, historyFormat) {
+			fmt.Printf("Error: unknown format %q (formats: %s)\n", historyFormat, strings.Join(export.Formats, ", "))
+			return
+		}
+		if !slices.Contains(export.CaptionFormats, historyCaptions) {
+			fmt.Printf("Error: unknown caption format %q (formats: %s)\n", historyCaptions, strings.Join(export.CaptionFormats, ", "))
+			return
+		}
+		// Only the HTML page embeds the audio, the other formats need files next to it
+		if historyWithAudio && historyFormat != "html" && historyOutput == "" {
+			fmt.Println("Error: --with-audio needs --output to name the audio and caption files")
+			return
+		}
+
+		currentPersona, err := storageManager.GetPersona(personaName)
+		if err != nil {
+			fmt.Printf("Error loading persona: %v\n", err)
+			return
+		}
+		if len(currentPersona.History) == 0 {
+			fmt.Printf("Error: no conversation with %s\n", personaName)
+			return
+		}
+
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+			return
+		}
+
+		models := personaModels(currentPersona)
+		transcript := export.New(currentPersona, models, appConfig.User.Name)
+		transcript.Session = storageManager.Session
+		_, historyPath := storageManager.GetPersonaPath(personaName)
+		if info, err := os.Stat(historyPath); err == nil {
+			transcript.UpdatedAt = info.ModTime()
+		}
+
+		if historyWithAudio {
+			key, err := apiKey(appConfig)
+			if err != nil {
+				fmt.Println(ui.RenderError(err.Error()))
+				return
+			}
+			if !checkBudget(appConfig) {
+				return
+			}
+			aiClient := personaClient(appConfig, key, currentPersona.Name, currentPersona)
+			synth := func(text string) (io.Reader, error) {
+				fmt.Fprintln(os.Stderr, ui.RenderInfo("🔊 Generating audio..."))
+				return aiClient.GenerateAudio(text, currentPersona.Voice.Instructions)
+			}
+
+			// Replies voiced with other settings are generated again
+			voiceKey := strings.Join([]string{
+				models.Speech, currentPersona.Voice.Name, currentPersona.Voice.Instructions,
+				fmt.Sprint(currentPersona.Voice.Speed),
+			}, "\x00")
+			if err := transcript.Synthesize(storageManager.GetAudioPath(personaName), voiceKey, synth, speak.Duration); err != nil {
+				fmt.Printf("Error voicing conversation: %v\n", err)
+				return
+			}
+		}
+
+		content, err := export.Render(transcript, historyFormat)
+		if err != nil {
+			fmt.Printf("Error exporting conversation: %v\n", err)
+			return
+		}
+
+		if historyOutput == "" {
+			os.Stdout.Write(content)
+			return
+		}
+		if err := os.WriteFile(historyOutput, content, 0644); err != nil {
+			fmt.Printf("Error writing export: %v\n", err)
+			return
+		}
+		fmt.Fprintln(os.Stderr, ui.RenderSuccess(fmt.Sprintf("Conversation exported to %s", historyOutput)))
+
+		if historyWithAudio && historyFormat != "html" {
+			if err := writeExportAudio(transcript); err != nil {
+				fmt.Printf("Error exporting audio: %v\n", err)
+			}
+		}
+	},
+}
+
+// writeExportAudio concatenates the voiced replies and writes their captions
+// next to the exported document
+func writeExportAudio(transcript *export.Transcript) error {
+	base := strings.TrimSuffix(historyOutput, filepath.Ext(historyOutput))
+
+	fmt.Fprintln(os.Stderr, ui.RenderInfo("🎚️ Mixing audio..."))
+	audioPath := base + ".mp3"
+	if err := ffmpeg.Concat(transcript.AudioFiles(), audioPath); err != nil {
+		return err
+	}
+
+	captions, err := export.Captions(transcript, historyCaptions)
+	if err != nil {
+		return err
+	}
+	captionsPath := base + "." + historyCaptions
+	if err := os.WriteFile(captionsPath, captions, 0644); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, ui.RenderSuccess(fmt.Sprintf("Audio saved to %s, captions to %s", audioPath, captionsPath)))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyExportCmd)
+
+	historyExportCmd.Flags().StringVar(&historyFormat, "format", "md", "Document format (md, html, json, txt)")
+	historyExportCmd.Flags().StringVarP(&historyOutput, "output", "o", "", "Write the export to a file instead of stdout")
+	historyExportCmd.Flags().BoolVar(&historyWithAudio, "with-audio", false, "Voice the replies")
+	historyExportCmd.Flags().StringVar(&historyCaptions, "captions", "srt", "Caption format of the audio (srt, vtt)")
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ctrl-vfr/persona/internal/ui"
+	"github.com/ctrl-vfr/persona/internal/watcher"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	copyNoHistory bool
+	renameForce   bool
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename [ancien] [nouveau]",
+	Short: "Rename a persona",
+	Long: `Rename a persona: its directory, the name field of its persona.yaml, the
+personas extending it, the group sessions it takes part in and the running
+instances registered for it. A persona open in a running chat is not renamed
+unless --force is given, as the chat would keep saving under the old name.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		oldName, newName := args[0], args[1]
+
+		if active, err := watcher.CountActiveInstances(storageManager, oldName); err != nil {
+			fmt.Printf("Error checking running instances: %v\n", err)
+			return
+		} else if active > 0 && !renameForce {
+			fmt.Printf("Error renaming persona: %d running chat(s) use '%s', close them or use --force\n", active, oldName)
+			return
+		}
+
+		if err := storageManager.RenamePersona(oldName, newName); err != nil {
+			fmt.Printf("Error renaming persona: %v\n", err)
+			return
+		}
+
+		active, err := watcher.RenamePersona(storageManager, oldName, newName)
+		if err != nil {
+			fmt.Printf("Error updating running instances: %v\n", err)
+		} else if active > 0 && !outputJSON {
+			fmt.Println(ui.RenderWarning(fmt.Sprintf("%d running chat(s) still use '%s', restart them to pick up the new name.", active, oldName)))
+		}
+
+		printPersonaResult(newName, "renamed", fmt.Sprintf("Persona '%s' renamed to '%s'", oldName, newName), "Persona renamed:", fmt.Sprintf("%s -> %s", oldName, newName))
+	},
+}
+
+var copyCmd = &cobra.Command{
+	Use:   "copy [source] [destination]",
+	Short: "Duplicate a persona",
+	Long: `Duplicate a persona, including read-only ones from team catalogs. The history
+and conversation branches are copied too, unless --no-history is given.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		src, dst := args[0], args[1]
+
+		if err := storageManager.CopyPersona(src, dst, !copyNoHistory); err != nil {
+			fmt.Printf("Error copying persona: %v\n", err)
+			return
+		}
+
+		printPersonaResult(dst, "copied", fmt.Sprintf("Persona '%s' copied to '%s'", src, dst), "Persona copied:", fmt.Sprintf("%s -> %s", src, dst))
+	},
+}
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive [nom]",
+	Short: "Archive a persona",
+	Long: `Move a persona and its history to ~/.persona/archive, out of the persona list
+and the selector. Nothing is deleted: 'persona unarchive' brings it back.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := storageManager.ArchivePersona(args[0]); err != nil {
+			fmt.Printf("Error archiving persona: %v\n", err)
+			return
+		}
+
+		printPersonaResult(args[0], "archived", fmt.Sprintf("Persona '%s' archived successfully", args[0]), "Persona archived:", args[0])
+	},
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive [nom]",
+	Short: "Bring an archived persona back",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := storageManager.UnarchivePersona(args[0]); err != nil {
+			fmt.Printf("Error unarchiving persona: %v\n", err)
+			return
+		}
+
+		printPersonaResult(args[0], "unarchived", fmt.Sprintf("Persona '%s' unarchived successfully", args[0]), "Persona unarchived:", args[0])
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [nom]",
+	Short: "Restore a deleted persona",
+	Long: `Restore the most recently deleted persona with that name from the trash
+(~/.persona/trash). Without a name, list the deleted personas.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			if err := storageManager.RestorePersona(args[0]); err != nil {
+				fmt.Printf("Error restoring persona: %v\n", err)
+				return
+			}
+			printPersonaResult(args[0], "restored", fmt.Sprintf("Persona '%s' restored successfully", args[0]), "Persona restored:", args[0])
+			return
+		}
+
+		trashed, err := storageManager.ListTrash()
+		if err != nil {
+			fmt.Printf("Error listing deleted personas: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			data, err := json.MarshalIndent(trashed, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			for _, t := range trashed {
+				fmt.Printf("%s\t%s\n", t.Name, t.DeletedAt.Format("2006-01-02 15:04:05"))
+			}
+			return
+		}
+
+		if len(trashed) == 0 {
+			fmt.Println(ui.TitleStyle.Render("The trash is empty."))
+			return
+		}
+		fmt.Println(ui.TitleStyle.Render("Deleted personas:"))
+		for _, t := range trashed {
+			fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("%s %s", t.Name, ui.RenderMuted(t.DeletedAt.Format("(2006-01-02 15:04)")))))
+		}
+		fmt.Println()
+	},
+}
+
+// printPersonaResult prints the outcome of a command acting on a persona in
+// the selected output format
+func printPersonaResult(personaName, status, plain, title, content string) {
+	if outputJSON {
+		result := map[string]any{
+			"persona": personaName,
+			"status":  status,
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if outputPlain {
+		fmt.Println(plain)
+		return
+	}
+
+	// Default: full formatted output
+	fmt.Println(ui.TitleStyle.Render(title))
+	fmt.Println(ui.ContentStyle.Render(content))
+	fmt.Println()
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+	rootCmd.AddCommand(restoreCmd)
+
+	copyCmd.Flags().BoolVar(&copyNoHistory, "no-history", false, "Do not copy the history")
+	renameCmd.Flags().BoolVar(&renameForce, "force", false, "Rename even while chats use the persona")
+
+	for _, cmd := range []*cobra.Command{renameCmd, copyCmd, archiveCmd, unarchiveCmd, restoreCmd} {
+		cmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+		cmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ctrl-vfr/persona/internal/schema"
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var lintAll bool
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [nom]",
+	Short: "Validate persona files",
+	Long: `Check a persona file against the schema: unknown or misspelled fields,
+unknown voices for the configured speech model, empty prompts and names that
+do not match the persona directory. With --all, every persona and the
+configuration file are checked. Exits with status 1 when errors are found.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if lintAll == (len(args) == 1) {
+			fmt.Println("Error: specify a persona name or --all")
+			return
+		}
+
+		names := args
+		if lintAll {
+			var err error
+			names, err = storageManager.ListPersonas()
+			if err != nil {
+				fmt.Printf("Error listing personas: %v\n", err)
+				return
+			}
+		}
+
+		// Diagnostics grouped by file, in the order they were checked
+		var files []string
+		results := map[string][]schema.Diagnostic{}
+		if lintAll {
+			files = append(files, storageManager.GetConfigPath())
+			results[storageManager.GetConfigPath()] = storageManager.LintConfig()
+		}
+		for _, name := range names {
+			personaPath, _ := storageManager.GetPersonaPath(name)
+			diagnostics, err := storageManager.LintPersona(name)
+			if err != nil {
+				diagnostics = []schema.Diagnostic{{File: personaPath, Line: 1, Column: 1, Severity: schema.SeverityError, Message: err.Error()}}
+			}
+			files = append(files, personaPath)
+			results[personaPath] = diagnostics
+		}
+
+		failed := false
+		all := []schema.Diagnostic{}
+		for _, file := range files {
+			all = append(all, results[file]...)
+			if schema.Check(results[file]) != nil {
+				failed = true
+			}
+		}
+
+		switch {
+		case outputJSON:
+			data, err := json.MarshalIndent(all, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+		case outputPlain:
+			for _, diagnostic := range all {
+				fmt.Println(diagnostic)
+			}
+		default:
+			for _, file := range files {
+				if len(results[file]) == 0 {
+					fmt.Println(ui.RenderSuccess(file))
+					continue
+				}
+				fmt.Println(ui.RenderSubtitle(file))
+				fmt.Println(ui.RenderDiagnostics(results[file]))
+			}
+			fmt.Println()
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().BoolVar(&lintAll, "all", false, "Check every persona and the configuration file")
+	lintCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	lintCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+}
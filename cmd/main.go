@@ -2,15 +2,25 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
+	"os"
 
+	"github.com/ctrl-vfr/persona/internal/project"
 	"github.com/ctrl-vfr/persona/internal/storage"
+	"github.com/ctrl-vfr/persona/internal/ui"
 
 	"github.com/spf13/cobra"
 )
 
 var storageManager *storage.Manager
 
+// currentProject is the project file found from the working directory, nil if none
+var currentProject *project.Project
+
+// profileName is the configuration profile selected with --profile
+var profileName string
+
 func init() {
 	// Initialize storage manager
 	manager, err := storage.NewManager()
@@ -38,7 +48,18 @@ var rootCmd = &cobra.Command{
 • Record and transcribe your voice messages
 • Chat with different AI personas
 • Manage conversation history
-• Provide a colorful and interactive interface`,
+• Provide a colorful and interactive interface
+
+A .persona.yaml file in the current directory or one of its parents picks the
+persona opened by 'persona chat', a session keeping the project's
+conversations apart, and context added to the personas' prompts.
+
+--profile selects a configuration profile for one command, see
+'persona config use' to change the active one.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		storageManager.Profile = profileName
+		loadProject()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		err := cmd.Help()
 		if err != nil {
@@ -47,6 +68,10 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Configuration profile to use (default: the active one)")
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -54,3 +79,33 @@ func Execute() error {
 func GetRootCmd() *cobra.Command {
 	return rootCmd
 }
+
+// loadProject applies the project file found from the working directory
+func loadProject() {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+
+	p, err := project.Find(cwd)
+	if err != nil {
+		// A broken project file should not prevent using the other commands
+		fmt.Fprintln(os.Stderr, ui.RenderLoadError("Ignoring project file", err))
+		return
+	}
+	if p == nil {
+		return
+	}
+
+	currentProject = p
+	storageManager.Session = p.Session
+	storageManager.Context = p.Context
+}
+
+// projectPersona returns the persona chosen by the project file, if any
+func projectPersona() string {
+	if currentProject == nil {
+		return ""
+	}
+	return currentProject.Persona
+}
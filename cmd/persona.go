@@ -1,19 +1,45 @@
 package cmd
 
 import (
+	"bufio"
+	"cmp"
 	"encoding/json"
 	"fmt"
-
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ctrl-vfr/persona/internal/config"
+	"github.com/ctrl-vfr/persona/internal/generator"
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/schema"
+	"github.com/ctrl-vfr/persona/internal/speak"
+	"github.com/ctrl-vfr/persona/internal/storage"
 	"github.com/ctrl-vfr/persona/internal/ui"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
 
+var listArchived bool
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all available personas",
+	Long: `List the personas of the user's directory and of the read-only persona
+paths (persona_paths in config.yaml, $XDG_CONFIG_HOME/persona/personas),
+with the directory each one is loaded from. With --archived, list the
+archived personas instead.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		personas, err := storageManager.ListPersonas()
+		if listArchived {
+			listArchivedPersonas()
+			return
+		}
+
+		personas, err := storageManager.ListPersonaSources()
 		if err != nil {
 			fmt.Printf("Error listing personas: %v\n", err)
 			return
@@ -35,7 +61,7 @@ var listCmd = &cobra.Command{
 				return
 			}
 			for _, persona := range personas {
-				fmt.Println(persona)
+				fmt.Println(persona.Name)
 			}
 			return
 		}
@@ -49,27 +75,100 @@ var listCmd = &cobra.Command{
 
 		fmt.Println(ui.TitleStyle.Render("Available Personas:"))
 		for _, persona := range personas {
-			fmt.Println(ui.ContentStyle.Render(fmt.Sprint(persona)))
+			line := persona.Name
+			if persona.ReadOnly {
+				line = fmt.Sprintf("%s %s", persona.Name, ui.RenderMuted(fmt.Sprintf("(read-only, %s)", persona.Dir)))
+			}
+			fmt.Println(ui.ContentStyle.Render(line))
 		}
 		fmt.Println()
 	},
 }
 
+func listArchivedPersonas() {
+	personas, err := storageManager.ListArchivedPersonas()
+	if err != nil {
+		fmt.Printf("Error listing archived personas: %v\n", err)
+		return
+	}
+
+	if outputJSON {
+		data, err := json.MarshalIndent(personas, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if outputPlain {
+		for _, persona := range personas {
+			fmt.Println(persona)
+		}
+		return
+	}
+
+	if len(personas) == 0 {
+		fmt.Println(ui.TitleStyle.Render("No archived personas."))
+		return
+	}
+	fmt.Println(ui.TitleStyle.Render("Archived Personas:"))
+	for _, persona := range personas {
+		fmt.Println(ui.ContentStyle.Render(persona))
+	}
+	fmt.Println()
+}
+
+var (
+	createInteractive bool
+	createFrom        string
+	createYes         bool
+)
+
 var createCmd = &cobra.Command{
 	Use:   "create [nom]",
 	Short: "Create a new persona",
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new persona from the default template, to be edited in
+~/.persona/personas/<nom>/persona.yaml. With --interactive, a form lets you
+choose the voice (with a preview), the voice instructions and the prompt.
+With --from, the chat model drafts the persona from a short description; the
+draft is shown for approval or editing before being written.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if createInteractive && createFrom != "" {
+			return fmt.Errorf("--interactive and --from cannot be used together")
+		}
+		if createInteractive {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		personaName := args[0]
+		var personaName string
+		if createInteractive {
+			// The editor validates and saves the persona itself
+			name, ok := runPersonaEditor(args)
+			if !ok {
+				return
+			}
+			personaName = name
+		} else if createFrom != "" {
+			personaName = args[0]
+			if !runPersonaGenerator(personaName, createFrom) {
+				return
+			}
+		} else {
+			personaName = args[0]
 
-		if storageManager.PersonaExists(personaName) {
-			fmt.Printf("Persona '%s' already exists.\n", personaName)
-			return
-		}
+			if storageManager.PersonaExists(personaName) {
+				fmt.Printf("Persona '%s' already exists.\n", personaName)
+				return
+			}
 
-		if err := storageManager.CreatePersona(personaName); err != nil {
-			fmt.Printf("Error creating persona: %v\n", err)
-			return
+			if err := storageManager.CreatePersona(personaName); err != nil {
+				fmt.Printf("Error creating persona: %v\n", err)
+				return
+			}
 		}
 
 		if outputJSON {
@@ -101,7 +200,9 @@ var createCmd = &cobra.Command{
 var deleteCmd = &cobra.Command{
 	Use:   "delete [nom]",
 	Short: "Delete a persona",
-	Args:  cobra.ExactArgs(1),
+	Long: `Move a persona and its history to the trash (~/.persona/trash), from where
+'persona restore' can bring it back.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		personaName := args[0]
 
@@ -130,13 +231,14 @@ var deleteCmd = &cobra.Command{
 		}
 
 		if outputPlain {
-			fmt.Printf("Persona '%s' deleted successfully\n", personaName)
+			fmt.Printf("Persona '%s' moved to the trash\n", personaName)
 			return
 		}
 
 		// Default: full formatted output
 		fmt.Println(ui.TitleStyle.Render("Persona deleted:"))
 		fmt.Println(ui.ContentStyle.Render(personaName))
+		fmt.Println(ui.ContentStyle.Render(ui.RenderMuted(fmt.Sprintf("Restore it with 'persona restore %s'.", personaName))))
 		fmt.Println()
 	},
 }
@@ -153,14 +255,19 @@ var showCmd = &cobra.Command{
 			fmt.Printf("Error loading persona: %v\n", err)
 			return
 		}
+		models := personaModels(currentPersona)
 
 		if outputJSON {
 			personaData := map[string]any{
 				"name":               currentPersona.Name,
 				"voice_name":         currentPersona.Voice.Name,
 				"voice_instructions": currentPersona.Voice.Instructions,
+				"voice_speed":        currentPersona.Voice.Speed,
 				"prompt":             currentPersona.Prompt,
 				"history_count":      len(currentPersona.History),
+				"models":             models,
+				"generation":         currentPersona.Generation,
+				"transcription":      currentPersona.Transcription,
 			}
 			data, err := json.MarshalIndent(personaData, "", "  ")
 			if err != nil {
@@ -174,6 +281,7 @@ var showCmd = &cobra.Command{
 		if outputPlain {
 			fmt.Printf("Name: %s\n", currentPersona.Name)
 			fmt.Printf("Voice: %s\n", currentPersona.Voice.Name)
+			fmt.Printf("Models: %s\n", formatModels(models))
 			fmt.Printf("History: %d messages\n", len(currentPersona.History))
 			fmt.Println()
 			fmt.Println("Instructions:")
@@ -187,6 +295,7 @@ var showCmd = &cobra.Command{
 		fmt.Println(ui.TitleStyle.Render(fmt.Sprintf("Persona: %s", currentPersona.Name)))
 		fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("Name: %s", currentPersona.Name)))
 		fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("Voice: %s", currentPersona.Voice.Name)))
+		fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("Models: %s", formatModels(models))))
 		fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("History: %d messages", len(currentPersona.History))))
 		fmt.Println()
 		fmt.Println(ui.TitleStyle.Render("Instructions:"))
@@ -200,6 +309,8 @@ var showCmd = &cobra.Command{
 var defaultCmd = &cobra.Command{
 	Use:   "default",
 	Short: "Show default persona",
+	Long: `Show the default persona, opened by 'persona ask' without a name.
+A .persona.yaml project file takes precedence over it in its directory.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		defaultPersona, err := storageManager.GetDefaultPersona()
 		if err != nil {
@@ -216,6 +327,11 @@ var defaultCmd = &cobra.Command{
 				"history_count":      len(defaultPersona.History),
 				"is_default":         true,
 			}
+			if currentProject != nil {
+				personaData["project_file"] = currentProject.Path
+				personaData["project_persona"] = currentProject.Persona
+				personaData["project_session"] = currentProject.Session
+			}
 			data, err := json.MarshalIndent(personaData, "", "  ")
 			if err != nil {
 				fmt.Printf("Error: %v\n", err)
@@ -227,28 +343,56 @@ var defaultCmd = &cobra.Command{
 
 		if outputPlain {
 			fmt.Printf("Default persona: %s\n", defaultPersona.Name)
+			if projectPersona() != "" {
+				fmt.Printf("Project persona: %s (%s)\n", currentProject.Persona, currentProject.Path)
+			}
 			return
 		}
 
 		fmt.Println(ui.TitleStyle.Render("Default persona:"))
 		fmt.Println(ui.ContentStyle.Render(defaultPersona.Name))
+		if projectPersona() != "" {
+			fmt.Println(ui.TitleStyle.Render("Project persona:"))
+			fmt.Println(ui.ContentStyle.Render(fmt.Sprintf("%s (%s)", currentProject.Persona, currentProject.Path)))
+		}
 		fmt.Println()
 	},
 }
 
+var defaultSetCmd = &cobra.Command{
+	Use:   "set [nom]",
+	Short: "Set the default persona",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		personaName := args[0]
+
+		if err := storageManager.SetDefaultPersona(personaName); err != nil {
+			fmt.Printf("Error setting default persona: %v\n", err)
+			return
+		}
+
+		fmt.Println(ui.RenderSuccess(fmt.Sprintf("Default persona set to '%s'", personaName)))
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(defaultCmd)
+	defaultCmd.AddCommand(defaultSetCmd)
 
 	// Add output format flags
 	listCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
 	listCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+	listCmd.Flags().BoolVar(&listArchived, "archived", false, "List the archived personas")
 
 	createCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
 	createCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+	createCmd.Flags().BoolVarP(&createInteractive, "interactive", "i", false, "Fill in the persona with an interactive form")
+	createCmd.Flags().StringVar(&createFrom, "from", "", "Generate the persona from a one-line description")
+	createCmd.Flags().BoolVarP(&createYes, "yes", "y", false, "Accept the generated persona without asking")
 
 	deleteCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
 	deleteCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
@@ -259,3 +403,227 @@ func init() {
 	defaultCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
 	defaultCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
 }
+
+// personaModels returns the models used by a persona: its own, or the configured ones
+func personaModels(p *persona.Persona) persona.Models {
+	models := p.Models
+	if appConfig, err := storageManager.GetConfig(); err == nil {
+		models.Chat = cmp.Or(models.Chat, appConfig.Models.Chat)
+		models.Speech = cmp.Or(models.Speech, appConfig.Models.Speech)
+		models.Transcription = cmp.Or(models.Transcription, appConfig.Models.Transcription)
+	}
+	return models
+}
+
+func formatModels(models persona.Models) string {
+	return fmt.Sprintf("chat %s, speech %s, transcription %s", models.Chat, models.Speech, models.Transcription)
+}
+
+// personaClient returns the API client of a persona, addressed by name: it
+// speaks in its voice with its models, records its usage and caches its speech
+func personaClient(appConfig *config.Config, key, name string, p *persona.Persona) *openai.OpenAI {
+	return appConfig.NewClient(key, p.Voice.Name).
+		WithOptions(p.ClientOptions()).
+		WithMeter(storageManager.UsageMeter(appConfig), name).
+		WithSpeechCache(storageManager.SpeechCache(appConfig))
+}
+
+// audioPlayer returns the player of the process, shared by every command and
+// model so that a single player stays on the speaker
+var audioPlayer = sync.OnceValue(speak.NewPlayer)
+
+// runPersonaEditor opens the persona form and returns the name of the saved persona
+func runPersonaEditor(args []string) (string, bool) {
+	appConfig, err := storageManager.GetConfig()
+	if err != nil {
+		fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+		return "", false
+	}
+
+	if len(args) == 1 && storageManager.PersonaExists(args[0]) {
+		fmt.Printf("Persona '%s' already exists.\n", args[0])
+		return "", false
+	}
+
+	// Voice previews need an API key
+	var newClient ui.ClientFactory
+	if key, err := apiKey(appConfig); err == nil {
+		newClient = func(voice string) ui.AIClient {
+			return appConfig.NewClient(key, voice).
+				WithMeter(storageManager.UsageMeter(appConfig), "").
+				WithSpeechCache(storageManager.SpeechCache(appConfig))
+		}
+	}
+
+	editor := ui.NewEditorModel(storageManager, nil, appConfig.Models.Speech, newClient, audioPlayer().Play)
+	if len(args) == 1 {
+		editor.SetName(args[0])
+	}
+
+	if _, err := tea.NewProgram(editor, tea.WithAltScreen()).Run(); err != nil {
+		fmt.Printf("Error running editor: %v\n", err)
+		return "", false
+	}
+	if !editor.Saved() {
+		fmt.Println("Persona creation cancelled.")
+		return "", false
+	}
+	return editor.Name(), true
+}
+
+// runPersonaGenerator drafts a persona from description and writes it once
+// the user accepts the draft
+func runPersonaGenerator(name, description string) bool {
+	if err := storage.ValidatePersonaName(name); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+	if storageManager.PersonaExists(name) {
+		fmt.Printf("Persona '%s' already exists.\n", name)
+		return false
+	}
+
+	appConfig, err := storageManager.GetConfig()
+	if err != nil {
+		fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+		return false
+	}
+
+	key, err := apiKey(appConfig)
+	if err != nil {
+		fmt.Println(ui.RenderError(err.Error()))
+		return false
+	}
+
+	voices := openai.Voices(appConfig.Models.Speech)
+	if voices == nil {
+		voices = openai.Voices("gpt-4o-mini-tts")
+	}
+	if !checkBudget(appConfig) {
+		return false
+	}
+	ai := appConfig.NewClient(key, "").WithMeter(storageManager.UsageMeter(appConfig), name)
+	options := schema.PersonaOptions{Directory: name, SpeechModel: appConfig.Models.Speech}
+
+	generate := func() ([]byte, bool) {
+		fmt.Fprintln(os.Stderr, ui.RenderMuted(fmt.Sprintf("Generating persona '%s'...", name)))
+		p, err := generator.Generate(ai, name, description, voices)
+		if err != nil {
+			fmt.Printf("Error generating persona: %v\n", err)
+			return nil, false
+		}
+		data, err := generator.YAML(p)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return nil, false
+		}
+		return data, true
+	}
+
+	draft, ok := generate()
+	if !ok {
+		return false
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Println(ui.RenderSubtitle(fmt.Sprintf("Draft for '%s':", name)))
+		fmt.Println(string(draft))
+
+		diagnostics := schema.ValidatePersona("persona.yaml", draft, options)
+		if len(diagnostics) > 0 {
+			fmt.Println(ui.RenderDiagnostics(diagnostics))
+		}
+		valid := schema.Check(diagnostics) == nil
+
+		if createYes {
+			if !valid {
+				fmt.Println(ui.RenderError("The generated persona is invalid."))
+				return false
+			}
+			break
+		}
+
+		choices := "[a]ccept, [e]dit, [r]egenerate, [q]uit"
+		if !valid {
+			choices = "[e]dit, [r]egenerate, [q]uit"
+		}
+		fmt.Printf("%s: ", choices)
+		answer, err := input.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Println()
+			fmt.Println("Persona creation cancelled.")
+			return false
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a", "accept":
+			if !valid {
+				fmt.Println(ui.RenderError("Fix the errors before accepting the persona."))
+				continue
+			}
+		case "e", "edit":
+			edited, err := editInEditor(draft)
+			if err != nil {
+				fmt.Printf("Error editing persona: %v\n", err)
+			} else {
+				draft = edited
+			}
+			continue
+		case "r", "regenerate":
+			if regenerated, ok := generate(); ok {
+				draft = regenerated
+			}
+			continue
+		case "q", "quit":
+			fmt.Println("Persona creation cancelled.")
+			return false
+		default:
+			continue
+		}
+		break
+	}
+
+	if err := storageManager.CreatePersonaFromYAMLTemplate(name, draft); err != nil {
+		fmt.Printf("Error creating persona: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// editInEditor opens data in $EDITOR and returns the edited content
+func editInEditor(data []byte) ([]byte, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	tempFile, err := os.CreateTemp("", "persona-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return nil, err
+	}
+	if err := tempFile.Close(); err != nil {
+		return nil, err
+	}
+
+	// $EDITOR may carry arguments, such as "code --wait"
+	fields := strings.Fields(editor)
+	command := exec.Command(fields[0], append(fields[1:], tempFile.Name())...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(tempFile.Name())
+}
@@ -2,11 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"log"
 	"os"
 
-	"github.com/ctrl-vfr/persona/internal/openai"
 	"github.com/ctrl-vfr/persona/internal/speak"
 	"github.com/ctrl-vfr/persona/internal/ui"
 
@@ -15,6 +13,7 @@ import (
 
 var (
 	readOutputFormat string
+	readSpeed        float64
 )
 
 var readCmd = &cobra.Command{
@@ -26,6 +25,10 @@ var readCmd = &cobra.Command{
 		personaName := args[0]
 		filePath := args[1]
 
+		if readSpeed < speak.MinSpeed || readSpeed > speak.MaxSpeed {
+			log.Fatalf("Invalid speed %g: expected between %g and %g", readSpeed, speak.MinSpeed, speak.MaxSpeed)
+		}
+
 		if readOutputFormat == "default" {
 			terminalWidth := ui.GetTerminalWidth()
 			fmt.Println(ui.RenderChatBoxTitle(fmt.Sprintf("📖 Reading by %s", personaName), terminalWidth))
@@ -56,35 +59,29 @@ var readCmd = &cobra.Command{
 		}
 
 		// Initialize OpenAI client
-		aiClient := openai.New(os.Getenv("OPENAI_API_KEY"), appConfig.Models.Transcription, appConfig.Models.Speech, appConfig.Models.Chat, currentPersona.Voice.Name)
-
-		if readOutputFormat == "default" {
-			fmt.Println(ui.RenderInfo("🔊 Generating audio..."))
-		}
-		audioResponseData, err := aiClient.GenerateAudio(textContent, currentPersona.Voice.Instructions)
+		key, err := apiKey(appConfig)
 		if err != nil {
-			log.Fatal("Audio generation error:", err)
+			log.Fatal(err)
 		}
-
-		audioBytes, err := io.ReadAll(audioResponseData)
-		if err != nil {
-			log.Fatal("Audio data read error:", err)
+		if !checkBudget(appConfig) {
+			return
 		}
+		aiClient := personaClient(appConfig, key, currentPersona.Name, currentPersona)
 
-		tempAudioResponseFile, err := os.CreateTemp("", "persona-read-*.mp3")
-		if err != nil {
-			log.Fatal("Temporary audio file creation error:", err)
+		if readOutputFormat == "default" {
+			fmt.Println(ui.RenderInfo("🔊 Generating audio..."))
 		}
-
-		err = os.WriteFile(tempAudioResponseFile.Name(), audioBytes, 0644)
+		audio, err := aiClient.GenerateAudio(textContent, currentPersona.Voice.Instructions)
 		if err != nil {
-			log.Fatal("Audio file write error:", err)
+			log.Fatal("Audio generation error:", err)
 		}
 
 		if readOutputFormat == "default" {
 			fmt.Println(ui.RenderInfo("🔈 Reading text..."))
 		}
-		err = speak.Play(tempAudioResponseFile.Name())
+		player := audioPlayer()
+		player.SetSpeed(readSpeed)
+		err = player.Play(audio)
 		if err != nil {
 			log.Fatal("Text reading error:", err)
 		}
@@ -92,14 +89,11 @@ var readCmd = &cobra.Command{
 		if readOutputFormat == "default" {
 			fmt.Println(ui.RenderSuccess("Reading completed!"))
 		}
-		err = os.Remove(tempAudioResponseFile.Name())
-		if err != nil {
-			log.Fatal("Temporary audio file removal error:", err)
-		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(readCmd)
 	readCmd.Flags().StringVarP(&readOutputFormat, "output", "o", "default", "Output format (default, json, plain)")
+	readCmd.Flags().Float64Var(&readSpeed, "speed", 1, "Playback speed, from 0.5 to 2")
 }
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/search"
+	"github.com/ctrl-vfr/persona/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchPersona string
+	searchSession string
+	searchSince   string
+	searchRole    string
+	searchLimit   int
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the conversations",
+	Long: `Search the messages of every persona and session, the most recent first.
+All the words of the query must appear in a message, case and accents ignored;
+"quoted words" are searched as a phrase.
+
+The histories are indexed in the cache directory, only the conversations that
+changed since the last search are read again.
+
+Examples:
+  persona search kubernetes
+  persona search "pod security" --persona devops --since 7d
+  persona search deploy --role assistant --json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		terms := search.ParseTerms(args[0])
+		if len(terms) == 0 {
+			fmt.Println("Error: empty search query")
+			return
+		}
+		if searchRole != "" && searchRole != "user" && searchRole != "assistant" {
+			fmt.Printf("Error: invalid --role %q (roles: user, assistant)\n", searchRole)
+			return
+		}
+
+		query := search.Query{
+			Terms:   terms,
+			Persona: searchPersona,
+			Session: searchSession,
+			Role:    searchRole,
+			Limit:   searchLimit,
+		}
+		if searchSince != "" {
+			since, err := search.ParseSince(searchSince, time.Now())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			query.Since = since
+		}
+
+		index, err := storageManager.SearchIndex()
+		if err != nil {
+			fmt.Printf("Error indexing conversations: %v\n", err)
+			return
+		}
+		results := index.Search(query)
+
+		if outputJSON {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			for _, result := range results {
+				fmt.Printf("%s\t%s\t%d\t%s\n", result.Persona, result.Session, result.Index, result.Snippet)
+			}
+			return
+		}
+
+		if len(results) == 0 {
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("No message matches %q.", args[0])))
+			return
+		}
+		now := time.Now()
+		for _, result := range results {
+			fmt.Println(ui.RenderInfo(resultLocation(result)) + " " +
+				ui.RenderMessageInfo([]string{ui.RelativeTime(result.Time, now), roleLabel(result.Entry)}))
+			fmt.Println("  " + search.Highlight(result.Snippet, result.Highlights, ui.RenderMatch))
+			fmt.Println()
+		}
+		fmt.Println(ui.RenderMuted(fmt.Sprintf("%d result(s)", len(results))))
+	},
+}
+
+// resultLocation names the conversation of a search result
+func resultLocation(result search.Result) string {
+	if result.Session == "" {
+		return result.Persona
+	}
+	return result.Persona + "/" + result.Session
+}
+
+// roleLabel names the author of a message found by a search
+func roleLabel(entry search.Entry) string {
+	if entry.Name != "" {
+		return strings.Join([]string{entry.Role, entry.Name}, ":")
+	}
+	return entry.Role
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringVarP(&searchPersona, "persona", "p", "", "Search the conversations of this persona only, "+search.GroupPersona+" for the group sessions")
+	searchCmd.Flags().StringVar(&searchSession, "session", "", "Search this session only")
+	searchCmd.Flags().StringVar(&searchSince, "since", "", "Search the messages newer than a duration (7d, 2w, 12h) or a date (2025-09-01)")
+	searchCmd.Flags().StringVar(&searchRole, "role", "", "Search the messages of a role only: user or assistant")
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "n", 20, "Maximum number of results, 0 for all")
+	searchCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	searchCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/config"
+	"github.com/ctrl-vfr/persona/internal/search"
+	"github.com/ctrl-vfr/persona/internal/ui"
+	"github.com/ctrl-vfr/persona/internal/usage"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	usageBy    string
+	usageSince string
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show the API usage and its estimated cost",
+	Long: `Summarize the API calls recorded in the usage ledger: chat tokens, spoken
+characters and transcribed minutes, with their cost estimated from the price
+table. Defaults to the current month.
+
+Prices are in US dollars and can be changed under usage.prices in the
+configuration. usage.monthly_budget caps the cost of a month, with
+usage.budget_action set to warn or block once it is spent.
+
+Examples:
+  persona usage
+  persona usage --by model --since 7d
+  persona usage --by day --since 2025-09-01 --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		appConfig, err := storageManager.GetConfig()
+		if err != nil {
+			fmt.Println(ui.RenderLoadError("Error loading configuration", err))
+			return
+		}
+
+		now := time.Now()
+		since := usage.MonthStart(now)
+		if usageSince != "" {
+			if since, err = search.ParseSince(usageSince, now); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		records, err := usage.NewLedger(storageManager.GetUsagePath()).Read(since)
+		if err != nil {
+			fmt.Printf("Error reading usage: %v\n", err)
+			return
+		}
+		prices := appConfig.Prices()
+		summaries, err := usage.Summarize(records, usageBy, prices)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		total := usage.Total(records, prices)
+		meter := storageManager.UsageMeter(appConfig)
+		budget := meter.Budget()
+		spent, err := meter.Spent()
+		if err != nil {
+			fmt.Printf("Error reading usage: %v\n", err)
+			return
+		}
+
+		if outputJSON {
+			result := map[string]any{
+				"since":   since,
+				"by":      usageBy,
+				"groups":  summaries,
+				"total":   total,
+				"month":   spent,
+				"budget":  budget.Monthly,
+				"action":  budget.Action,
+				"records": len(records),
+			}
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if outputPlain {
+			for _, s := range append(summaries, total) {
+				fmt.Printf("%s\t%d\t%d\t%d\t%d\t%.1f\t%.4f\n", s.Key, s.Calls, s.PromptTokens, s.CompletionTokens, s.Characters, s.AudioSeconds/60, s.Cost)
+			}
+			return
+		}
+
+		if len(records) == 0 {
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("No API call since %s.", since.Format("02/01/2006"))))
+			return
+		}
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("API usage since %s, by %s:", since.Format("02/01/2006"), usageBy)))
+		fmt.Println()
+		width := len(usageBy)
+		for _, s := range summaries {
+			width = max(width, len(s.Key))
+		}
+		fmt.Println(ui.RenderMuted(fmt.Sprintf("  %-*s %6s %11s %11s %10s %8s %10s", width, strings.ToUpper(usageBy), "CALLS", "TOKENS IN", "TOKENS OUT", "TTS CHARS", "STT MIN", "COST")))
+		for _, s := range summaries {
+			fmt.Println(usageLine(s, width))
+		}
+		fmt.Println(usageLine(total, width))
+
+		if total.Unpriced > 0 {
+			fmt.Println()
+			fmt.Println(ui.RenderMuted(fmt.Sprintf("%d call(s) to models without price are not counted, add them under usage.prices.", total.Unpriced)))
+		}
+		if budget.Monthly > 0 {
+			fmt.Println()
+			line := fmt.Sprintf("Budget: $%.2f of $%.2f this month (%.0f%%, %s once spent)", spent, budget.Monthly, spent/budget.Monthly*100, budget.Action)
+			if spent >= budget.Monthly {
+				fmt.Println(ui.RenderWarning(line))
+			} else {
+				fmt.Println(ui.RenderMuted(line))
+			}
+		}
+	},
+}
+
+// usageLine formats a summary as a row of the usage table
+func usageLine(s usage.Summary, width int) string {
+	return fmt.Sprintf("  %-*s %6d %11d %11d %10d %8.1f %10s", width, s.Key, s.Calls,
+		s.PromptTokens, s.CompletionTokens, s.Characters, s.AudioSeconds/60, fmt.Sprintf("$%.4f", s.Cost))
+}
+
+// checkBudget warns when the monthly budget is spent, and reports false when
+// the budget blocks the API calls
+func checkBudget(appConfig *config.Config) bool {
+	meter := storageManager.UsageMeter(appConfig)
+	if err := meter.Allow(); err != nil {
+		fmt.Println(ui.RenderError(err.Error()))
+		return false
+	}
+	if meter.OverBudget() {
+		spent, _ := meter.Spent()
+		fmt.Fprintln(os.Stderr, ui.RenderWarning(fmt.Sprintf("Monthly budget exceeded: $%.2f spent of $%.2f, see persona usage", spent, meter.Budget().Monthly)))
+	}
+	return true
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().StringVar(&usageBy, "by", "persona", "Group the calls by "+strings.Join(usage.Groupings, ", "))
+	usageCmd.Flags().StringVar(&usageSince, "since", "", "Count the calls newer than a duration (7d, 2w, 12h) or a date (2025-09-01), default: this month")
+	usageCmd.Flags().BoolVar(&outputJSON, "json", false, "Display information in JSON format")
+	usageCmd.Flags().BoolVar(&outputPlain, "plain", false, "Display simple plain text output")
+}
@@ -0,0 +1,264 @@
+// Package auth stores the API keys of the providers, in the OS keyring when
+// available or in a file encrypted with a passphrase.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where an API key comes from
+const (
+	SourceEnv     = "env"
+	SourceKeyring = "keyring"
+	SourceFile    = "file"
+)
+
+// DefaultProvider is the provider used when the configuration names none
+const DefaultProvider = "openai"
+
+// PassphraseEnv holds the passphrase of the encrypted file, for scripts
+const PassphraseEnv = "PERSONA_PASSPHRASE"
+
+// ErrNotFound is returned when no API key is available for a provider
+var ErrNotFound = errors.New("no API key")
+
+// PassphraseFunc asks the passphrase of the encrypted file. confirm is set
+// when the file is created, so that the passphrase can be typed twice.
+type PassphraseFunc func(confirm bool) (string, error)
+
+// Store finds and saves the API keys of the providers. Environment variables
+// named after the provider, such as OPENAI_API_KEY, take precedence.
+type Store struct {
+	dir        string
+	passphrase PassphraseFunc
+	keyring    Keyring
+}
+
+// Status describes the API key of a provider
+type Status struct {
+	Provider string `json:"provider"`
+	Source   string `json:"source"`
+	// Key is the redacted key, empty when it is in the encrypted file
+	Key string `json:"key,omitempty"`
+}
+
+// NewStore returns the store of the credentials kept in dir. passphrase is
+// only called when the encrypted file is used.
+func NewStore(dir string, passphrase PassphraseFunc) *Store {
+	return &Store{dir: dir, passphrase: passphrase, keyring: SystemKeyring()}
+}
+
+// EnvName returns the environment variable holding the key of a provider
+func EnvName(provider string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(provider)) + "_API_KEY"
+}
+
+// KeyringAvailable reports whether keys can be stored in the OS keyring
+func (s *Store) KeyringAvailable() bool {
+	return s.keyring != nil
+}
+
+// Get returns the API key of a provider and where it comes from
+func (s *Store) Get(provider string) (string, string, error) {
+	if key := os.Getenv(EnvName(provider)); key != "" {
+		return key, SourceEnv, nil
+	}
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return "", "", err
+	}
+	switch index[provider] {
+	case SourceKeyring:
+		if s.keyring == nil {
+			return "", "", fmt.Errorf("the key of %s is in the OS keyring, which is not available", provider)
+		}
+		key, err := s.keyring.Get(provider)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read the keyring: %w", err)
+		}
+		return key, SourceKeyring, nil
+	case SourceFile:
+		keys, err := s.readFile()
+		if err != nil {
+			return "", "", err
+		}
+		if key, ok := keys[provider]; ok {
+			return key, SourceFile, nil
+		}
+	}
+	return "", "", fmt.Errorf("%w for %s: run 'persona auth login' or set %s", ErrNotFound, provider, EnvName(provider))
+}
+
+// Set stores the API key of a provider, in the OS keyring unless useFile is
+// set or no keyring is available. It returns where the key was stored.
+func (s *Store) Set(provider, key string, useFile bool) (string, error) {
+	index, err := s.loadIndex()
+	if err != nil {
+		return "", err
+	}
+
+	source := SourceKeyring
+	if useFile || s.keyring == nil {
+		source = SourceFile
+	}
+
+	// Remove the key from the other backend so that only one copy remains
+	if err := s.deleteKey(provider, index[provider]); err != nil {
+		return "", err
+	}
+
+	if source == SourceKeyring {
+		if err := s.keyring.Set(provider, key); err != nil {
+			return "", fmt.Errorf("failed to write the keyring: %w", err)
+		}
+	} else {
+		keys, err := s.readFile()
+		if err != nil {
+			return "", err
+		}
+		keys[provider] = key
+		if err := s.writeFile(keys); err != nil {
+			return "", err
+		}
+	}
+
+	index[provider] = source
+	return source, s.saveIndex(index)
+}
+
+// Delete removes the stored API key of a provider. Environment variables are
+// left untouched.
+func (s *Store) Delete(provider string) error {
+	index, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+	source, ok := index[provider]
+	if !ok {
+		return fmt.Errorf("%w stored for %s", ErrNotFound, provider)
+	}
+
+	if err := s.deleteKey(provider, source); err != nil {
+		return err
+	}
+	delete(index, provider)
+	return s.saveIndex(index)
+}
+
+// Status lists the providers with a key, stored or in the environment.
+// Keys of the encrypted file are not decrypted.
+func (s *Store) Status(providers ...string) ([]Status, error) {
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	for provider := range index {
+		if !slices.Contains(providers, provider) {
+			providers = append(providers, provider)
+		}
+	}
+	slices.Sort(providers)
+
+	var statuses []Status
+	for _, provider := range providers {
+		status := Status{Provider: provider, Source: index[provider]}
+		switch {
+		case os.Getenv(EnvName(provider)) != "":
+			status.Source = SourceEnv
+			status.Key = Redact(os.Getenv(EnvName(provider)))
+		case status.Source == SourceKeyring && s.keyring != nil:
+			if key, err := s.keyring.Get(provider); err == nil {
+				status.Key = Redact(key)
+			}
+		case status.Source == "":
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Redact hides an API key but for its prefix and last characters
+func Redact(key string) string {
+	if len(key) <= 12 {
+		return strings.Repeat("*", len(key))
+	}
+	prefix := key[:3]
+	if i := strings.LastIndex(key[:min(len(key)-4, 12)], "-"); i > 0 {
+		prefix = key[:i+1]
+	}
+	return prefix + "…" + key[len(key)-4:]
+}
+
+func (s *Store) deleteKey(provider, source string) error {
+	switch source {
+	case SourceKeyring:
+		if s.keyring == nil {
+			return fmt.Errorf("the key of %s is in the OS keyring, which is not available", provider)
+		}
+		if err := s.keyring.Delete(provider); err != nil {
+			return fmt.Errorf("failed to delete from the keyring: %w", err)
+		}
+	case SourceFile:
+		keys, err := s.readFile()
+		if err != nil {
+			return err
+		}
+		delete(keys, provider)
+		if len(keys) == 0 {
+			if err := os.Remove(s.filePath()); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to delete credentials: %w", err)
+			}
+			return nil
+		}
+		return s.writeFile(keys)
+	}
+	return nil
+}
+
+// indexPath lists where the key of each provider is stored, without the keys
+func (s *Store) indexPath() string {
+	return filepath.Join(s.dir, "credentials.yaml")
+}
+
+func (s *Store) loadIndex() (map[string]string, error) {
+	index := map[string]string{}
+	data, err := os.ReadFile(s.indexPath())
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials index: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to read credentials index: %w", err)
+	}
+	return index, nil
+}
+
+func (s *Store) saveIndex(index map[string]string) error {
+	if len(index) == 0 {
+		if err := os.Remove(s.indexPath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to save credentials index: %w", err)
+		}
+		return nil
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to save credentials index: %w", err)
+	}
+	if err := os.WriteFile(s.indexPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save credentials index: %w", err)
+	}
+	return nil
+}
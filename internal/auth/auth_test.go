@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memoryKeyring replaces the OS keyring in tests
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(account string) (string, error) {
+	secret, ok := k[account]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return secret, nil
+}
+
+func (k memoryKeyring) Set(account, secret string) error {
+	k[account] = secret
+	return nil
+}
+
+func (k memoryKeyring) Delete(account string) error {
+	delete(k, account)
+	return nil
+}
+
+func newTestStore(t *testing.T, passphrase string, keyring Keyring) *Store {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv(PassphraseEnv, "")
+	store := NewStore(t.TempDir(), func(bool) (string, error) { return passphrase, nil })
+	store.keyring = keyring
+	return store
+}
+
+func TestStore_File(t *testing.T) {
+	store := newTestStore(t, "secret", nil)
+
+	source, err := store.Set("openai", "sk-proj-abcdef123456", false)
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if source != SourceFile {
+		t.Errorf("Expected source %s without keyring, got %s", SourceFile, source)
+	}
+
+	data, err := os.ReadFile(store.filePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-proj") {
+		t.Error("Expected the key to be encrypted")
+	}
+	if info, err := os.Stat(store.filePath()); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// A new store reads the file with the passphrase
+	reader := NewStore(store.dir, func(bool) (string, error) { return "secret", nil })
+	reader.keyring = nil
+	key, source, err := reader.Get("openai")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if key != "sk-proj-abcdef123456" || source != SourceFile {
+		t.Errorf("Expected the stored key from the file, got %q from %s", key, source)
+	}
+
+	if err := reader.Delete("openai"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(store.filePath()); !os.IsNotExist(err) {
+		t.Error("Expected the encrypted file to be removed with its last key")
+	}
+	if _, _, err := reader.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStore_WrongPassphrase(t *testing.T) {
+	store := newTestStore(t, "secret", nil)
+	if _, err := store.Set("openai", "sk-proj-abcdef123456", true); err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewStore(store.dir, func(bool) (string, error) { return "wrong", nil })
+	other.keyring = nil
+	if _, _, err := other.Get("openai"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+}
+
+func TestStore_Keyring(t *testing.T) {
+	keyring := memoryKeyring{}
+	store := newTestStore(t, "secret", keyring)
+
+	source, err := store.Set("openai", "sk-proj-abcdef123456", false)
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if source != SourceKeyring || keyring["openai"] != "sk-proj-abcdef123456" {
+		t.Errorf("Expected the key in the keyring, got %s", source)
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, "credentials.enc")); !os.IsNotExist(err) {
+		t.Error("Expected no encrypted file")
+	}
+
+	// Moving the key to the file removes it from the keyring
+	if _, err := store.Set("openai", "sk-proj-abcdef123456", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := keyring["openai"]; ok {
+		t.Error("Expected the key to be removed from the keyring")
+	}
+}
+
+func TestStore_EnvPrecedence(t *testing.T) {
+	store := newTestStore(t, "secret", memoryKeyring{})
+	if _, err := store.Set("openai", "sk-proj-stored123456", false); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-proj-fromenv123456")
+	key, source, err := store.Get("openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "sk-proj-fromenv123456" || source != SourceEnv {
+		t.Errorf("Expected the environment key, got %q from %s", key, source)
+	}
+
+	statuses, err := store.Status("openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].Source != SourceEnv {
+		t.Errorf("Expected one status from env, got %+v", statuses)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"openai":     "OPENAI_API_KEY",
+		"mistral":    "MISTRAL_API_KEY",
+		"local-llm":  "LOCAL_LLM_API_KEY",
+		"groq.cloud": "GROQ_CLOUD_API_KEY",
+	}
+	for provider, expected := range tests {
+		if got := EnvName(provider); got != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, provider, got)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := map[string]string{
+		"sk-proj-abcdefghijklmnop1234": "sk-proj-…1234",
+		"sk-abcdefghijklmnop1234":      "sk-…1234",
+		"short":                        "*****",
+	}
+	for key, expected := range tests {
+		got := Redact(key)
+		if got != expected {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+		if len(key) > 12 && strings.Contains(got, key[8:len(key)-4]) {
+			t.Errorf("Redacted key %s leaks the secret", got)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrWrongPassphrase is returned when the encrypted file cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// Key derivation settings of new encrypted files
+const (
+	fileVersion = 1
+	iterations  = 600_000
+	saltSize    = 16
+)
+
+// encryptedFile is the content of credentials.enc. Data is the JSON map of
+// the keys by provider, encrypted with AES-256-GCM and a key derived from
+// the passphrase with PBKDF2-SHA256.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+func (s *Store) filePath() string {
+	return filepath.Join(s.dir, "credentials.enc")
+}
+
+// getPassphrase returns the passphrase from the environment or asks it
+func (s *Store) getPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if s.passphrase == nil {
+		return "", fmt.Errorf("the credentials are encrypted, set %s", PassphraseEnv)
+	}
+	passphrase, err := s.passphrase(confirm)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("the passphrase must not be empty")
+	}
+	return passphrase, nil
+}
+
+// readFile decrypts the keys of the encrypted file, empty when it does not exist
+func (s *Store) readFile() (map[string]string, error) {
+	keys := map[string]string{}
+	data, err := os.ReadFile(s.filePath())
+	if os.IsNotExist(err) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	if file.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported credentials version %d", file.Version)
+	}
+
+	passphrase, err := s.getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	if err := json.Unmarshal(plain, &keys); err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	// Keep the passphrase for the rest of the command
+	s.passphrase = func(bool) (string, error) { return passphrase, nil }
+	return keys, nil
+}
+
+// writeFile encrypts the keys with a new salt and nonce
+func (s *Store) writeFile(keys map[string]string) error {
+	_, statErr := os.Stat(s.filePath())
+	passphrase, err := s.getPassphrase(os.IsNotExist(statErr))
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{
+		Version:    fileVersion,
+		Iterations: iterations,
+		Salt:       make([]byte, saltSize),
+	}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	aead, err := newAEAD(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+
+	plain, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	file.Data = aead.Seal(nil, file.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	if err := os.WriteFile(s.filePath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+
+	s.passphrase = func(bool) (string, error) { return passphrase, nil }
+	return nil
+}
+
+func newAEAD(passphrase string, salt []byte, iter int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iter, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
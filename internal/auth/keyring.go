@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService names the entries of persona in the OS keyring
+const keyringService = "persona"
+
+// Keyring stores secrets in the OS keyring
+type Keyring interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// SystemKeyring returns the keyring of the OS through its command line tool:
+// secret-tool (libsecret) on Linux and security on macOS. It returns nil when
+// none is available, as on Windows.
+func SystemKeyring() Keyring {
+	switch runtime.GOOS {
+	case "darwin":
+		if path, err := exec.LookPath("security"); err == nil {
+			return macKeyring{path: path}
+		}
+	case "linux", "freebsd", "openbsd":
+		if path, err := exec.LookPath("secret-tool"); err == nil {
+			return secretTool{path: path}
+		}
+	}
+	return nil
+}
+
+// secretTool uses the Secret Service (GNOME Keyring, KWallet) via libsecret
+type secretTool struct {
+	path string
+}
+
+func (k secretTool) Get(account string) (string, error) {
+	out, err := run(k.path, "", "lookup", "service", keyringService, "account", account)
+	return strings.TrimSpace(out), err
+}
+
+func (k secretTool) Set(account, secret string) error {
+	// The secret is read from stdin, so that it does not show in the process list
+	_, err := run(k.path, secret, "store", "--label", "persona "+account, "service", keyringService, "account", account)
+	return err
+}
+
+func (k secretTool) Delete(account string) error {
+	_, err := run(k.path, "", "clear", "service", keyringService, "account", account)
+	return err
+}
+
+// macKeyring uses the login keychain
+type macKeyring struct {
+	path string
+}
+
+func (k macKeyring) Get(account string) (string, error) {
+	out, err := run(k.path, "", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	return strings.TrimSpace(out), err
+}
+
+func (k macKeyring) Set(account, secret string) error {
+	// The interactive mode reads the command from stdin, so that the secret
+	// does not show in the process list. -X takes it hex encoded.
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", keyringService, account, hex.EncodeToString([]byte(secret)))
+	_, err := run(k.path, command, "-i")
+	return err
+}
+
+func (k macKeyring) Delete(account string) error {
+	_, err := run(k.path, "", "delete-generic-password", "-s", keyringService, "-a", account)
+	return err
+}
+
+func run(path, stdin string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(path, args...)
+	command.Stdin = strings.NewReader(stdin)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
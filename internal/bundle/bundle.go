@@ -0,0 +1,172 @@
+// Package bundle reads and writes persona bundles, the archives used to share personas.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"time"
+)
+
+// FormatVersion is the version of the bundle format written by this version
+const FormatVersion = 1
+
+// Extension is the file extension of persona bundles
+const Extension = ".persona.tgz"
+
+const (
+	// ManifestFile describes the bundle
+	ManifestFile = "manifest.json"
+	// PersonaFile is the persona definition
+	PersonaFile = "persona.yaml"
+	// HistoryFile is the optional conversation history
+	HistoryFile = "history.yaml"
+)
+
+// maxFileSize bounds the files read from a bundle
+const maxFileSize = 16 << 20
+
+// Manifest describes a bundle
+type Manifest struct {
+	Format      int       `json:"format"`
+	Name        string    `json:"name"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Created     time.Time `json:"created"`
+	// Checksum is the SHA-256 of the bundled files, see Bundle.Checksum
+	Checksum string `json:"checksum"`
+}
+
+// Bundle is a persona with its manifest
+type Bundle struct {
+	Manifest Manifest
+	// Files maps the bundled file names to their content
+	Files map[string][]byte
+}
+
+// Checksum returns the SHA-256 of the bundled files, in name order
+func (b *Bundle) Checksum() string {
+	hash := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(b.Files)) {
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(b.Files[name]))
+		hash.Write(b.Files[name])
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// Write writes the bundle as a gzipped tarball, setting its format version
+// and checksum
+func Write(w io.Writer, b *Bundle) error {
+	if _, ok := b.Files[PersonaFile]; !ok {
+		return fmt.Errorf("bundle has no %s", PersonaFile)
+	}
+	b.Manifest.Format = FormatVersion
+	b.Manifest.Checksum = b.Checksum()
+
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	// The manifest comes first so it can be read without the whole archive
+	files := append([]string{ManifestFile}, slices.Sorted(maps.Keys(b.Files))...)
+	for _, name := range files {
+		data := manifest
+		if name != ManifestFile {
+			data = b.Files[name]
+		}
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: b.Manifest.Created,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return gz.Close()
+}
+
+// Read reads a bundle and checks its format version and checksum
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a persona bundle: %w", err)
+	}
+	defer gz.Close()
+
+	b := &Bundle{Files: map[string][]byte{}}
+	var manifest []byte
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %q in bundle", header.Name)
+		}
+
+		// Only flat, known files are accepted, so a bundle cannot write
+		// outside the persona directory
+		name := path.Clean(header.Name)
+		if name != ManifestFile && name != PersonaFile && name != HistoryFile {
+			return nil, fmt.Errorf("unexpected file %q in bundle", header.Name)
+		}
+		if header.Size > maxFileSize {
+			return nil, fmt.Errorf("%s is too large", name)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if name == ManifestFile {
+			manifest = data
+		} else {
+			b.Files[name] = data
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("bundle has no %s", ManifestFile)
+	}
+	if err := json.Unmarshal(manifest, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if b.Manifest.Format < 1 || b.Manifest.Format > FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format %d, this version reads up to %d", b.Manifest.Format, FormatVersion)
+	}
+	if _, ok := b.Files[PersonaFile]; !ok {
+		return nil, fmt.Errorf("bundle has no %s", PersonaFile)
+	}
+	if checksum := b.Checksum(); checksum != b.Manifest.Checksum {
+		return nil, fmt.Errorf("checksum mismatch: the bundle is corrupted or was modified")
+	}
+
+	return b, nil
+}
@@ -0,0 +1,100 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Manifest: Manifest{
+			Name:        "sherlock",
+			Author:      "Alice",
+			Description: "Détective condescendant",
+			Tags:        []string{"debug", "fun"},
+			Created:     time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Files: map[string][]byte{
+			PersonaFile: []byte("name: sherlock\n"),
+			HistoryFile: []byte("[]\n"),
+		},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testBundle()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	b, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if b.Manifest.Format != FormatVersion {
+		t.Errorf("Expected format %d, got %d", FormatVersion, b.Manifest.Format)
+	}
+	if b.Manifest.Author != "Alice" || len(b.Manifest.Tags) != 2 {
+		t.Errorf("Unexpected manifest: %+v", b.Manifest)
+	}
+	if !strings.HasPrefix(b.Manifest.Checksum, "sha256:") {
+		t.Errorf("Expected a sha256 checksum, got %q", b.Manifest.Checksum)
+	}
+	if string(b.Files[PersonaFile]) != "name: sherlock\n" || string(b.Files[HistoryFile]) != "[]\n" {
+		t.Errorf("Unexpected files: %v", b.Files)
+	}
+}
+
+// writeRaw builds a bundle archive from raw entries
+func writeRaw(t *testing.T, entries map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestRead_RejectsInvalidBundles(t *testing.T) {
+	b := testBundle()
+	checksum := b.Checksum()
+	manifest := `{"format": 1, "name": "sherlock", "checksum": "` + checksum + `"}`
+
+	tests := []struct {
+		name    string
+		entries map[string]string
+		want    string
+	}{
+		{"modified", map[string]string{ManifestFile: manifest, PersonaFile: "name: moriarty\n", HistoryFile: "[]\n"}, "checksum mismatch"},
+		{"future format", map[string]string{ManifestFile: `{"format": 99}`, PersonaFile: "name: x\n"}, "unsupported bundle format"},
+		{"no manifest", map[string]string{PersonaFile: "name: x\n"}, "no manifest.json"},
+		{"path traversal", map[string]string{ManifestFile: manifest, "../../.bashrc": "boom"}, "unexpected file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(writeRaw(t, tt.entries))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := Read(strings.NewReader("not a tarball")); err == nil {
+		t.Error("Expected an error for a file that is not a bundle")
+	}
+}
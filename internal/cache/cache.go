@@ -0,0 +1,173 @@
+// Package cache keeps synthesized speech on disk, addressed by content, so
+// that a text spoken again in the same voice is not paid for twice.
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// extension is the extension of the cached files
+const extension = ".mp3"
+
+// Cache is a directory of files named after their key. Reading a file marks
+// it as recently used, and the least recently used files are evicted once the
+// directory grows past its size limit.
+type Cache struct {
+	dir   string
+	limit int64
+}
+
+// Stats describes the content of a cache
+type Stats struct {
+	Dir     string `json:"dir"`
+	Entries int    `json:"entries"`
+	Size    int64  `json:"size"`
+	Limit   int64  `json:"limit"`
+}
+
+// New returns the cache stored in dir, holding up to limit bytes
+func New(dir string, limit int64) *Cache {
+	return &Cache{dir: dir, limit: limit}
+}
+
+// path returns the file of a key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+extension)
+}
+
+// Path returns the file holding key, if cached, and marks it as used
+func (c *Cache) Path(key string) (string, bool) {
+	path := c.path(key)
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// Get returns the data cached under key and marks it as used
+func (c *Cache) Get(key string) ([]byte, bool) {
+	path, ok := c.Path(key)
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores data under key, through a temporary file so that a concurrent
+// reader never sees half of it, then evicts the least recently used entries
+// past the size limit
+func (c *Cache) Put(key string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return c.prune()
+}
+
+// entry is a cached file
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// entries lists the cached files, the least recently used first
+func (c *Cache) entries() ([]entry, error) {
+	files, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var entries []entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), extension) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{path: filepath.Join(c.dir, file.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return a.modTime.Compare(b.modTime) })
+	return entries, nil
+}
+
+// prune evicts the least recently used entries until the cache fits its limit
+func (c *Cache) prune() error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.size
+	}
+	for _, e := range entries {
+		if size <= c.limit {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to evict cache entry: %w", err)
+		}
+		size -= e.size
+	}
+	return nil
+}
+
+// Stats counts the entries of the cache and their size
+func (c *Cache) Stats() (Stats, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return Stats{}, err
+	}
+	stats := Stats{Dir: c.dir, Entries: len(entries), Limit: c.limit}
+	for _, e := range entries {
+		stats.Size += e.size
+	}
+	return stats, nil
+}
+
+// Clear removes every entry and returns how many were removed
+func (c *Cache) Clear() (int, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+	for i, e := range entries {
+		if err := os.Remove(e.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return i, fmt.Errorf("failed to clear cache: %w", err)
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache_PutGet(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "speech"), 1<<20)
+
+	if _, ok := c.Get("missing"); ok {
+		t.Error("Expected a miss on an empty cache")
+	}
+	if err := c.Put("hello", []byte("mp3 data")); err != nil {
+		t.Fatalf("Failed to put: %v", err)
+	}
+	data, ok := c.Get("hello")
+	if !ok || string(data) != "mp3 data" {
+		t.Errorf("Expected the cached data, got %q", data)
+	}
+	if path, ok := c.Path("hello"); !ok || filepath.Base(path) != "hello.mp3" {
+		t.Errorf("Expected the path of the entry, got %q", path)
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir, 25)
+
+	// Age the entries so that their order does not depend on the clock
+	age := func(key string, ago time.Duration) {
+		when := time.Now().Add(-ago)
+		if err := os.Chtimes(filepath.Join(dir, key+".mp3"), when, when); err != nil {
+			t.Fatalf("Failed to age %s: %v", key, err)
+		}
+	}
+	for _, key := range []string{"a", "b"} {
+		if err := c.Put(key, []byte("0123456789")); err != nil {
+			t.Fatalf("Failed to put %s: %v", key, err)
+		}
+	}
+	age("a", 2*time.Hour)
+	age("b", time.Hour)
+
+	// Reading a makes b the least recently used
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("Expected a to be cached")
+	}
+	if err := c.Put("c", []byte("0123456789")); err != nil {
+		t.Fatalf("Failed to put c: %v", err)
+	}
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.Entries != 2 || stats.Size != 20 || stats.Limit != 25 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestCache_Clear(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir, 1<<20)
+	c.Put("a", []byte("1"))
+	c.Put("b", []byte("2"))
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("kept"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	removed, err := c.Clear()
+	if err != nil || removed != 2 {
+		t.Fatalf("Expected 2 entries removed, got %d, %v", removed, err)
+	}
+	if stats, _ := c.Stats(); stats.Entries != 0 {
+		t.Errorf("Expected an empty cache, got %+v", stats)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("Expected other files to be left alone")
+	}
+
+	removed, err = New(filepath.Join(dir, "missing"), 1).Clear()
+	if err != nil || removed != 0 {
+		t.Errorf("Expected clearing a missing cache to do nothing, got %d, %v", removed, err)
+	}
+}
@@ -2,26 +2,89 @@
 package config
 
 import (
+	"cmp"
 	"encoding/json"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/usage"
+
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Models struct {
-		Transcription string `yaml:"transcription"`
-		Speech        string `yaml:"speech"`
-		Chat          string `yaml:"chat"`
-	} `yaml:"models"`
-	Audio struct {
-		InputDevice      string `yaml:"input_device"`
-		OutputDevice     string `yaml:"output_device"`
-		SilenceThreshold int    `yaml:"silence_threshold"`
-		SilenceDuration  int    `yaml:"silence_duration"`
-	} `yaml:"audio"`
+	Version int `yaml:"version,omitempty"`
+	// DefaultPersona is used when no persona is given, instead of "persona"
+	DefaultPersona string `yaml:"default_persona,omitempty"`
+	User           struct {
+		Name string `yaml:"name"`
+	} `yaml:"user"`
+	Models   Models   `yaml:"models"`
+	Audio    Audio    `yaml:"audio"`
+	Provider Provider `yaml:"provider,omitempty"`
+	Usage    Usage    `yaml:"usage,omitempty"`
+	Cache    Cache    `yaml:"cache,omitempty"`
+	// PersonaPaths lists read-only directories searched for personas after
+	// the user's own personas directory
+	PersonaPaths []string `yaml:"persona_paths,omitempty"`
+
+	// Profile is the active profile, overlaid on the settings above
+	Profile  string             `yaml:"profile,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+type Models struct {
+	Transcription string `yaml:"transcription,omitempty"`
+	Speech        string `yaml:"speech,omitempty"`
+	Chat          string `yaml:"chat,omitempty"`
+}
+
+type Audio struct {
+	InputDevice      string  `yaml:"input_device,omitempty"`
+	OutputDevice     string  `yaml:"output_device,omitempty"`
+	SilenceThreshold int     `yaml:"silence_threshold,omitempty"`
+	SilenceDuration  float64 `yaml:"silence_duration,omitempty"`
+}
+
+// Provider is the OpenAI compatible API the requests are sent to
+type Provider struct {
+	// Name selects the API key, see persona auth. Defaults to openai.
+	Name string `yaml:"name,omitempty"`
+	// BaseURL replaces https://api.openai.com/v1, e.g. for a proxy or a local server
+	BaseURL string `yaml:"base_url,omitempty"`
+}
+
+// Usage prices the API calls and caps their monthly cost, see persona usage
+type Usage struct {
+	// Prices replace or add models to the built-in price table, in US dollars
+	Prices usage.Prices `yaml:"prices,omitempty"`
+	// MonthlyBudget is the estimated cost allowed per month, no limit when zero
+	MonthlyBudget float64 `yaml:"monthly_budget,omitempty"`
+	// BudgetAction is warn or block once the budget is spent. Defaults to warn.
+	BudgetAction string `yaml:"budget_action,omitempty"`
+}
+
+// Cache limits the speech cache, see persona cache
+type Cache struct {
+	// MaxSize is in megabytes. Defaults to DefaultCacheSize.
+	MaxSize int `yaml:"max_size,omitempty"`
+}
+
+// DefaultCacheSize is the size of the speech cache in megabytes, when not
+// configured
+const DefaultCacheSize = 200
+
+// Profile holds the settings of an environment, such as a streaming or a
+// desk setup. Its non-empty settings replace those of the configuration.
+type Profile struct {
+	Models   Models   `yaml:"models,omitempty"`
+	Audio    Audio    `yaml:"audio,omitempty"`
+	Provider Provider `yaml:"provider,omitempty"`
 }
 
 func NewConfig() *Config {
@@ -53,6 +116,64 @@ func (c *Config) Load(path string) error {
 	return nil
 }
 
+// ApplyProfile overlays the settings of a profile and makes it the active one
+func (c *Config) ApplyProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q (profiles: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	overlay(&c.Models.Transcription, profile.Models.Transcription)
+	overlay(&c.Models.Speech, profile.Models.Speech)
+	overlay(&c.Models.Chat, profile.Models.Chat)
+	overlay(&c.Audio.InputDevice, profile.Audio.InputDevice)
+	overlay(&c.Audio.OutputDevice, profile.Audio.OutputDevice)
+	overlay(&c.Audio.SilenceThreshold, profile.Audio.SilenceThreshold)
+	overlay(&c.Audio.SilenceDuration, profile.Audio.SilenceDuration)
+	overlay(&c.Provider.Name, profile.Provider.Name)
+	overlay(&c.Provider.BaseURL, profile.Provider.BaseURL)
+	c.Profile = name
+	return nil
+}
+
+// ProfileNames returns the names of the profiles, sorted
+func (c *Config) ProfileNames() []string {
+	return slices.Sorted(maps.Keys(c.Profiles))
+}
+
+func overlay[T comparable](value *T, override T) {
+	var zero T
+	if override != zero {
+		*value = override
+	}
+}
+
+// ProviderName returns the provider whose API key is used
+func (c *Config) ProviderName() string {
+	return cmp.Or(c.Provider.Name, "openai")
+}
+
+// Prices returns the price table, the configured prices overriding the
+// built-in ones
+func (c *Config) Prices() usage.Prices {
+	return usage.DefaultPrices(c.Usage.Prices)
+}
+
+// Budget returns the monthly budget of the API calls
+func (c *Config) Budget() usage.Budget {
+	return usage.Budget{Monthly: c.Usage.MonthlyBudget, Action: cmp.Or(c.Usage.BudgetAction, usage.ActionWarn)}
+}
+
+// CacheLimit returns the size of the speech cache in bytes
+func (c *Config) CacheLimit() int64 {
+	return int64(cmp.Or(c.Cache.MaxSize, DefaultCacheSize)) << 20
+}
+
+// NewClient returns an OpenAI client using the configured models and provider
+func (c *Config) NewClient(apiKey, voice string) *openai.OpenAI {
+	return openai.New(apiKey, c.Models.Transcription, c.Models.Speech, c.Models.Chat, voice).WithBaseURL(c.Provider.BaseURL)
+}
+
 func (c *Config) Save(path string) error {
 	// Always save as YAML for better readability
 	data, err := yaml.Marshal(c)
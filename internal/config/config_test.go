@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -47,7 +49,7 @@ audio:
 		t.Errorf("Expected silence threshold -40, got %d", config.Audio.SilenceThreshold)
 	}
 	if config.Audio.SilenceDuration != 3 {
-		t.Errorf("Expected silence duration 3, got %d", config.Audio.SilenceDuration)
+		t.Errorf("Expected silence duration 3, got %g", config.Audio.SilenceDuration)
 	}
 }
 
@@ -122,3 +124,117 @@ func TestConfig_SaveToInvalidPath(t *testing.T) {
 		t.Error("Expected error saving to invalid path, got nil")
 	}
 }
+
+func TestConfig_SetGetUnset(t *testing.T) {
+	config := NewConfig()
+
+	if err := config.Set("audio.silence_duration", "1.5", false); err != nil {
+		t.Fatalf("Failed to set silence duration: %v", err)
+	}
+	if config.Audio.SilenceDuration != 1.5 {
+		t.Errorf("Expected silence duration 1.5, got %g", config.Audio.SilenceDuration)
+	}
+	if err := config.Set("audio.silence_duration", "-1", false); err == nil {
+		t.Error("Expected error for a negative silence duration, got nil")
+	}
+	if err := config.Set("audio.silence_threshold", "loud", false); err == nil {
+		t.Error("Expected error for a non-integer threshold, got nil")
+	}
+	if err := config.Set("models.chat", "gpt-9", false); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Expected ErrNotAllowed for an unknown model, got %v", err)
+	}
+	if err := config.Set("models.chat", "gpt-9", true); err != nil {
+		t.Errorf("Expected force to accept an unknown model, got %v", err)
+	}
+	if err := config.Set("persona_paths", "~/team, ./local", false); err != nil {
+		t.Fatalf("Failed to set persona paths: %v", err)
+	}
+	if value, _ := config.Get("persona_paths"); FormatValue(value) != "~/team,./local" {
+		t.Errorf("Expected persona paths '~/team,./local', got '%v'", value)
+	}
+	if _, err := config.Get("audio.volume"); err == nil {
+		t.Error("Expected error for an unknown key, got nil")
+	}
+
+	if err := config.Unset("models.chat"); err != nil {
+		t.Fatalf("Failed to unset chat model: %v", err)
+	}
+	if config.Models.Chat != "gpt-4o-mini" {
+		t.Errorf("Expected default chat model 'gpt-4o-mini', got '%s'", config.Models.Chat)
+	}
+}
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	t.Setenv("PERSONA_AUDIO_SILENCE_DURATION", "0.8")
+	t.Setenv("PERSONA_MODELS_CHAT", "gpt-5")
+
+	config := NewConfig()
+	overridden, err := config.ApplyEnv()
+	if err != nil {
+		t.Fatalf("Failed to apply environment: %v", err)
+	}
+	if len(overridden) != 2 {
+		t.Errorf("Expected 2 overridden keys, got %v", overridden)
+	}
+	if config.Audio.SilenceDuration != 0.8 || config.Models.Chat != "gpt-5" {
+		t.Errorf("Expected the environment values, got %g and '%s'", config.Audio.SilenceDuration, config.Models.Chat)
+	}
+
+	t.Setenv("PERSONA_AUDIO_SILENCE_THRESHOLD", "loud")
+	if _, err := NewConfig().ApplyEnv(); err == nil || !strings.Contains(err.Error(), "PERSONA_AUDIO_SILENCE_THRESHOLD") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestConfig_ApplyProfile(t *testing.T) {
+	config := NewConfig()
+	config.Models.Chat = "gpt-4.1"
+	config.Audio.InputDevice = "headset"
+	config.Audio.SilenceDuration = 2
+	config.Profiles = map[string]Profile{
+		"streaming": {
+			Models:   Models{Chat: "gpt-4.1-nano"},
+			Audio:    Audio{InputDevice: "CABLE Output", SilenceDuration: 0.8},
+			Provider: Provider{BaseURL: "http://localhost:8080/v1"},
+		},
+	}
+
+	if err := config.ApplyProfile("desk"); err == nil {
+		t.Error("Expected error for an unknown profile, got nil")
+	}
+	if err := config.ApplyProfile("streaming"); err != nil {
+		t.Fatalf("Failed to apply profile: %v", err)
+	}
+	if config.Models.Chat != "gpt-4.1-nano" || config.Audio.InputDevice != "CABLE Output" || config.Audio.SilenceDuration != 0.8 {
+		t.Errorf("Expected the profile settings, got %+v %+v", config.Models, config.Audio)
+	}
+	if config.Provider.BaseURL != "http://localhost:8080/v1" {
+		t.Errorf("Expected the profile provider, got '%s'", config.Provider.BaseURL)
+	}
+	if config.Profile != "streaming" {
+		t.Errorf("Expected 'streaming' to be the active profile, got '%s'", config.Profile)
+	}
+}
+
+func TestConfig_UsagePricesAndBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "usage:\n  monthly_budget: 5\n  prices:\n    local-llama:\n      input: 0.5\n    gpt-4o-mini:\n      output: 1\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	config := NewConfig()
+	if err := config.Load(path); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	prices := config.Prices()
+	if prices["local-llama"].Input != 0.5 || prices["gpt-4o-mini"].Output != 1 || prices["gpt-4o-mini"].Input != 0 {
+		t.Errorf("Expected the configured prices to replace the built-in ones, got %+v", prices)
+	}
+	if _, ok := prices["whisper-1"]; !ok {
+		t.Error("Expected the built-in prices to be kept")
+	}
+	if budget := config.Budget(); budget.Monthly != 5 || budget.Action != "warn" {
+		t.Errorf("Expected a budget of 5 with the warn action, got %+v", budget)
+	}
+}
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/usage"
+)
+
+// Kinds of configuration values
+const (
+	KindString  = "string"
+	KindInt     = "int"
+	KindFloat   = "float"
+	KindStrings = "strings"
+)
+
+// EnvPrefix starts the environment variables overriding configuration keys
+const EnvPrefix = "PERSONA_"
+
+// ErrNotAllowed is returned when a value is not in the allowed values of a key
+var ErrNotAllowed = errors.New("value not allowed")
+
+// Key describes a configuration key, addressed with a dotted name such as
+// audio.silence_duration
+type Key struct {
+	Name        string
+	Kind        string
+	Description string
+	// Default is restored by Unset
+	Default string
+	// Allowed lists the accepted values, any value is accepted when empty
+	Allowed []string
+
+	// value returns a pointer to the key's field in c
+	value func(c *Config) any
+	// check validates a parsed value
+	check func(v any) error
+}
+
+// EnvName returns the environment variable overriding the key
+func (k Key) EnvName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(k.Name, ".", "_"))
+}
+
+var keys = []Key{
+	{
+		Name: "default_persona", Kind: KindString,
+		Description: "Persona used when none is given",
+		value:       func(c *Config) any { return &c.DefaultPersona },
+	},
+	{
+		Name: "user.name", Kind: KindString,
+		Description: "Your name, substituted for {{user.name}} in prompts",
+		value:       func(c *Config) any { return &c.User.Name },
+	},
+	{
+		Name: "models.transcription", Kind: KindString,
+		Description: "Model transcribing voice messages",
+		Default:     "gpt-4o-mini-transcribe",
+		Allowed:     openai.TranscriptionModels(),
+		value:       func(c *Config) any { return &c.Models.Transcription },
+	},
+	{
+		Name: "models.speech", Kind: KindString,
+		Description: "Model speaking the answers",
+		Default:     "gpt-4o-mini-tts",
+		Allowed:     openai.SpeechModels(),
+		value:       func(c *Config) any { return &c.Models.Speech },
+	},
+	{
+		Name: "models.chat", Kind: KindString,
+		Description: "Model writing the answers",
+		Default:     "gpt-4o-mini",
+		Allowed:     openai.ChatModels(),
+		value:       func(c *Config) any { return &c.Models.Chat },
+	},
+	{
+		Name: "audio.input_device", Kind: KindString,
+		Description: "Audio input device, see persona ffmpeg list input",
+		value:       func(c *Config) any { return &c.Audio.InputDevice },
+	},
+	{
+		Name: "audio.output_device", Kind: KindString,
+		Description: "Audio output device",
+		value:       func(c *Config) any { return &c.Audio.OutputDevice },
+	},
+	{
+		Name: "audio.silence_threshold", Kind: KindInt,
+		Description: "Volume in dB under which the recording is silent",
+		Default:     "-50",
+		value:       func(c *Config) any { return &c.Audio.SilenceThreshold },
+		check: func(v any) error {
+			if v.(int) > 0 {
+				return fmt.Errorf("silence_threshold is in dB and must not be positive (e.g. -50)")
+			}
+			return nil
+		},
+	},
+	{
+		Name: "audio.silence_duration", Kind: KindFloat,
+		Description: "Seconds of silence ending a recording",
+		Default:     "2",
+		value:       func(c *Config) any { return &c.Audio.SilenceDuration },
+		check: func(v any) error {
+			if v.(float64) < 0 {
+				return fmt.Errorf("silence_duration must not be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name: "provider.name", Kind: KindString,
+		Description: "Provider whose API key is used, see persona auth",
+		Default:     "openai",
+		value:       func(c *Config) any { return &c.Provider.Name },
+	},
+	{
+		Name: "provider.base_url", Kind: KindString,
+		Description: "OpenAI compatible API, instead of https://api.openai.com/v1",
+		value:       func(c *Config) any { return &c.Provider.BaseURL },
+		check: func(v any) error {
+			if url := v.(string); url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+				return fmt.Errorf("provider.base_url must start with http:// or https://")
+			}
+			return nil
+		},
+	},
+	{
+		Name: "usage.monthly_budget", Kind: KindFloat,
+		Description: "Estimated cost allowed per month in US dollars, 0 for no limit",
+		value:       func(c *Config) any { return &c.Usage.MonthlyBudget },
+		check: func(v any) error {
+			if v.(float64) < 0 {
+				return fmt.Errorf("monthly_budget must not be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name: "usage.budget_action", Kind: KindString,
+		Description: "What happens once the monthly budget is spent",
+		Default:     usage.ActionWarn,
+		Allowed:     []string{usage.ActionWarn, usage.ActionBlock},
+		value:       func(c *Config) any { return &c.Usage.BudgetAction },
+	},
+	{
+		Name: "cache.max_size", Kind: KindInt,
+		Description: "Size of the speech cache in megabytes",
+		Default:     strconv.Itoa(DefaultCacheSize),
+		value:       func(c *Config) any { return &c.Cache.MaxSize },
+		check: func(v any) error {
+			if v.(int) < 0 {
+				return fmt.Errorf("max_size must not be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Name: "profile", Kind: KindString,
+		Description: "Active profile, see persona config use",
+		value:       func(c *Config) any { return &c.Profile },
+	},
+	{
+		Name: "persona_paths", Kind: KindStrings,
+		Description: "Read-only persona catalogs, separated by commas",
+		value:       func(c *Config) any { return &c.PersonaPaths },
+	},
+}
+
+// Keys returns the configuration keys
+func Keys() []Key {
+	return slices.Clone(keys)
+}
+
+// LookupKey returns the configuration key with the given name
+func LookupKey(name string) (Key, error) {
+	for _, key := range keys {
+		if key.Name == name {
+			return key, nil
+		}
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.Name
+	}
+	return Key{}, fmt.Errorf("unknown key %q (known keys: %s)", name, strings.Join(names, ", "))
+}
+
+// Parse converts value to the kind of the key and validates it. Values
+// outside of Allowed are rejected with ErrNotAllowed unless force is set.
+func (k Key) Parse(value string, force bool) (any, error) {
+	var parsed any
+	switch k.Kind {
+	case KindInt:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer, got %q", k.Name, value)
+		}
+		parsed = n
+	case KindFloat:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %q", k.Name, value)
+		}
+		parsed = f
+	case KindStrings:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		parsed = items
+	default:
+		parsed = value
+	}
+
+	if !force && len(k.Allowed) > 0 && value != "" && !slices.Contains(k.Allowed, value) {
+		return nil, fmt.Errorf("%w: %q for %s (allowed: %s)", ErrNotAllowed, value, k.Name, strings.Join(k.Allowed, ", "))
+	}
+	if k.check != nil {
+		if err := k.check(parsed); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// Get returns the value of a key
+func (c *Config) Get(name string) (any, error) {
+	key, err := LookupKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := key.value(c).(type) {
+	case *string:
+		return *value, nil
+	case *int:
+		return *value, nil
+	case *float64:
+		return *value, nil
+	case *[]string:
+		return *value, nil
+	}
+	return nil, fmt.Errorf("unsupported key %s", name)
+}
+
+// Set parses value and assigns it to a key. See Key.Parse for force.
+func (c *Config) Set(name, value string, force bool) error {
+	key, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	parsed, err := key.Parse(value, force)
+	if err != nil {
+		return err
+	}
+
+	switch field := key.value(c).(type) {
+	case *string:
+		*field = parsed.(string)
+	case *int:
+		*field = parsed.(int)
+	case *float64:
+		*field = parsed.(float64)
+	case *[]string:
+		*field = parsed.([]string)
+	}
+	return nil
+}
+
+// Unset restores the default value of a key
+func (c *Config) Unset(name string) error {
+	key, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	return c.Set(name, key.Default, true)
+}
+
+// ApplyEnv overrides keys with their PERSONA_* environment variables and
+// returns the names of the overridden keys
+func (c *Config) ApplyEnv() ([]string, error) {
+	var overridden []string
+	for _, key := range keys {
+		value, ok := os.LookupEnv(key.EnvName())
+		if !ok {
+			continue
+		}
+		// Environment values are trusted, a model missing from the list may be newer
+		if err := c.Set(key.Name, value, true); err != nil {
+			return overridden, fmt.Errorf("invalid %s: %w", key.EnvName(), err)
+		}
+		overridden = append(overridden, key.Name)
+	}
+	return overridden, nil
+}
+
+// FormatValue renders a value returned by Get the way Set accepts it
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,51 @@
+package diff
+
+import "testing"
+
+func TestUnified_Equal(t *testing.T) {
+	if got := Unified("a", "b", "x\ny\n", "x\ny\n"); got != "" {
+		t.Errorf("Expected no diff, got %q", got)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\ndeux\n4\n5\n6\n7\n8\n9\n10\n11\n12\ntreize\n"
+
+	expected := `--- local
++++ builtin
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++deux
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++treize
+`
+	if got := Unified("local", "builtin", a, b); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestUnified_MergesCloseChanges(t *testing.T) {
+	got := Unified("a", "b", "1\n2\n3\n4\n", "un\n2\n3\nquatre\n")
+	expected := `--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++un
+ 2
+ 3
+-4
++quatre
+`
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,172 @@
+// Package export renders a persona's conversation for sharing, as a document
+// and optionally with the replies voiced.
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+)
+
+// Formats lists the document formats of Render
+var Formats = []string{"md", "html", "json", "txt"}
+
+// CaptionFormats lists the caption formats of Captions
+var CaptionFormats = []string{"srt", "vtt"}
+
+// Transcript is a conversation with the metadata of its persona
+type Transcript struct {
+	Persona string         `json:"persona"`
+	Voice   string         `json:"voice"`
+	Models  persona.Models `json:"models"`
+	Session string         `json:"session,omitempty"`
+	// User is the name given to the user's messages, "Vous" when empty
+	User string `json:"user,omitempty"`
+	// UpdatedAt is when the history was last written
+	UpdatedAt  time.Time `json:"updated_at,omitzero"`
+	ExportedAt time.Time `json:"exported_at"`
+	Messages   []Message `json:"messages"`
+}
+
+// Message is a message of the transcript
+type Message struct {
+	Role    string `json:"role"`
+	Speaker string `json:"speaker"`
+	Content string `json:"content"`
+	// CreatedAt is empty for messages written before their metadata
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	// Audio is the file of the voiced reply, set by Synthesize
+	Audio string `json:"audio,omitempty"`
+	// Start and End place the reply in the concatenated audio
+	Start time.Duration `json:"-"`
+	End   time.Duration `json:"-"`
+}
+
+// New builds the transcript of a persona's history. user names the user's
+// messages.
+func New(p *persona.Persona, models persona.Models, user string) *Transcript {
+	t := &Transcript{
+		Persona:    p.Name,
+		Voice:      p.Voice.Name,
+		Models:     models,
+		User:       user,
+		ExportedAt: time.Now(),
+		Messages:   []Message{},
+	}
+	for _, message := range p.History {
+		if message.Role != "user" && message.Role != "assistant" {
+			continue
+		}
+		t.Messages = append(t.Messages, Message{
+			Role:      message.Role,
+			Speaker:   t.speaker(message),
+			Content:   message.Content,
+			CreatedAt: message.CreatedAt,
+		})
+	}
+	return t
+}
+
+func (t *Transcript) speaker(message persona.Message) string {
+	switch {
+	case message.Name != "":
+		return message.Name
+	case message.Role == "assistant":
+		return t.Persona
+	case t.User != "":
+		return t.User
+	}
+	return "Vous"
+}
+
+// SynthFunc voices the text of a reply
+type SynthFunc func(text string) (io.Reader, error)
+
+// DurationFunc returns the length of an audio file
+type DurationFunc func(path string) (time.Duration, error)
+
+// Synthesize gives an audio file to each reply, generated with synth into dir.
+// key identifies the voice settings: a file generated with the same key and
+// text is reused instead of being generated again. Start and End are set as
+// if the files were played one after the other.
+func (t *Transcript) Synthesize(dir, key string, synth SynthFunc, duration DurationFunc) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create audio directory: %w", err)
+	}
+
+	var offset time.Duration
+	for i := range t.Messages {
+		message := &t.Messages[i]
+		if message.Role != "assistant" {
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(key + "\x00" + message.Content))
+		path := filepath.Join(dir, hex.EncodeToString(sum[:8])+".mp3")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := generate(path, message.Content, synth); err != nil {
+				return fmt.Errorf("failed to voice message %d: %w", i+1, err)
+			}
+		}
+
+		length, err := duration(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		message.Audio = path
+		message.Start = offset
+		message.End = offset + length
+		offset = message.End
+	}
+	return nil
+}
+
+// AudioFiles returns the audio files of the replies, in order
+func (t *Transcript) AudioFiles() []string {
+	var files []string
+	for _, message := range t.Messages {
+		if message.Audio != "" {
+			files = append(files, message.Audio)
+		}
+	}
+	return files
+}
+
+// generate writes the audio of text, through a temporary file so that an
+// interrupted generation is not reused
+func generate(path, text string, synth SynthFunc) error {
+	audio, err := synth(text)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Render renders the transcript in one of Formats
+func Render(t *Transcript, format string) ([]byte, error) {
+	switch format {
+	case "md":
+		return []byte(Markdown(t)), nil
+	case "txt":
+		return []byte(Text(t)), nil
+	case "json":
+		return JSON(t)
+	case "html":
+		return HTML(t)
+	}
+	return nil, fmt.Errorf("unknown format %q (formats: %s)", format, strings.Join(Formats, ", "))
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+)
+
+func newTestTranscript() *Transcript {
+	p := persona.New("sherlock", persona.Voice{Name: "onyx"}, "Tu es Sherlock Holmes.")
+	p.History = []persona.Message{
+		{Role: "system", Content: "Tu es Sherlock Holmes."},
+		{Role: "user", Content: "Qui a volé la tarte ?"},
+		{Role: "assistant", Content: "Le jardinier, élémentaire."},
+		{Role: "user", Content: "Comment le savez-vous ?"},
+		{Role: "assistant", Content: "La farine sur ses bottes."},
+	}
+	return New(p, persona.Models{Chat: "gpt-4o-mini"}, "Watson")
+}
+
+func TestNew(t *testing.T) {
+	transcript := newTestTranscript()
+
+	if len(transcript.Messages) != 4 {
+		t.Fatalf("Expected 4 messages without the system prompt, got %d", len(transcript.Messages))
+	}
+	if transcript.Messages[0].Speaker != "Watson" {
+		t.Errorf("Expected the user's name as speaker, got %s", transcript.Messages[0].Speaker)
+	}
+	if transcript.Messages[1].Speaker != "sherlock" {
+		t.Errorf("Expected the persona as speaker, got %s", transcript.Messages[1].Speaker)
+	}
+
+	anonymous := New(persona.New("sherlock", persona.Voice{}, ""), persona.Models{}, "")
+	if speaker := anonymous.speaker(persona.Message{Role: "user"}); speaker != "Vous" {
+		t.Errorf("Expected Vous without a user name, got %s", speaker)
+	}
+}
+
+func TestSynthesize(t *testing.T) {
+	transcript := newTestTranscript()
+	dir := t.TempDir()
+
+	calls := 0
+	synth := func(text string) (io.Reader, error) {
+		calls++
+		return strings.NewReader("audio " + text), nil
+	}
+	duration := func(string) (time.Duration, error) { return 1500 * time.Millisecond, nil }
+
+	if err := transcript.Synthesize(dir, "onyx", synth, duration); err != nil {
+		t.Fatalf("Synthesize failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the 2 replies to be voiced, got %d calls", calls)
+	}
+	if files := transcript.AudioFiles(); len(files) != 2 {
+		t.Errorf("Expected 2 audio files, got %v", files)
+	}
+	second := transcript.Messages[3]
+	if second.Start != 1500*time.Millisecond || second.End != 3*time.Second {
+		t.Errorf("Expected the second reply from 1.5s to 3s, got %v to %v", second.Start, second.End)
+	}
+
+	// Voicing again reuses the files
+	if err := newTestTranscript().Synthesize(dir, "onyx", synth, duration); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the audio files to be reused, got %d calls", calls)
+	}
+
+	// Another voice generates them again
+	if err := newTestTranscript().Synthesize(dir, "nova", synth, duration); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected a new voice to generate the audio again, got %d calls", calls)
+	}
+}
+
+func TestCaptions(t *testing.T) {
+	transcript := newTestTranscript()
+	transcript.Messages[1].Audio = "1.mp3"
+	transcript.Messages[1].End = 2*time.Second + 500*time.Millisecond
+	transcript.Messages[3].Audio = "2.mp3"
+	transcript.Messages[3].Start = transcript.Messages[1].End
+	transcript.Messages[3].End = time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond
+
+	srt, err := Captions(transcript, "srt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "1\n00:00:00,000 --> 00:00:02,500\nLe jardinier, élémentaire.\n\n" +
+		"2\n00:00:02,500 --> 01:02:03,045\nLa farine sur ses bottes.\n\n"
+	if string(srt) != expected {
+		t.Errorf("Expected SRT:\n%s\ngot:\n%s", expected, srt)
+	}
+
+	vtt, err := Captions(transcript, "vtt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(vtt), "WEBVTT\n\n00:00:00.000 --> 00:00:02.500\n") {
+		t.Errorf("Unexpected WebVTT:\n%s", vtt)
+	}
+
+	if _, err := Captions(transcript, "ass"); err == nil {
+		t.Error("Expected an error for an unknown caption format")
+	}
+}
+
+func TestRender(t *testing.T) {
+	transcript := newTestTranscript()
+	transcript.Messages[0].Content = "<script>alert(1)</script>"
+
+	markdown, err := Render(transcript, "md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(markdown), "**sherlock** : Le jardinier, élémentaire.") {
+		t.Errorf("Unexpected Markdown:\n%s", markdown)
+	}
+
+	page, err := Render(transcript, "html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(page), "<script>") {
+		t.Error("Expected the messages to be escaped in HTML")
+	}
+
+	transcript.Messages[1].Audio = "1.mp3"
+	transcript.Messages[1].End = 2 * time.Second
+	data, err := Render(transcript, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Persona  string `json:"persona"`
+		Messages []struct {
+			Audio string   `json:"audio"`
+			End   *float64 `json:"end"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if decoded.Persona != "sherlock" || len(decoded.Messages) != 4 {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+	if end := decoded.Messages[1].End; end == nil || *end != 2 {
+		t.Errorf("Expected the reply to end at 2s, got %v", end)
+	}
+	if decoded.Messages[0].End != nil {
+		t.Error("Expected no timing for the user's messages")
+	}
+
+	if _, err := Render(transcript, "pdf"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,194 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+)
+
+// dateLayout formats the dates of the documents
+const dateLayout = "02/01/2006 15:04"
+
+// Markdown renders the transcript as a Markdown document
+func Markdown(t *Transcript) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conversation avec %s\n\n", t.Persona)
+	for _, line := range t.metadata() {
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	for _, message := range t.Messages {
+		if message.CreatedAt.IsZero() {
+			fmt.Fprintf(&b, "\n**%s** : %s\n", message.Speaker, message.Content)
+		} else {
+			fmt.Fprintf(&b, "\n**%s** _(%s)_ : %s\n", message.Speaker, message.CreatedAt.Format(dateLayout), message.Content)
+		}
+	}
+	return b.String()
+}
+
+// Text renders the transcript as plain text
+func Text(t *Transcript) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Conversation avec %s\n", t.Persona)
+	for _, line := range t.metadata() {
+		fmt.Fprintf(&b, "%s\n", line)
+	}
+	for _, message := range t.Messages {
+		if message.CreatedAt.IsZero() {
+			fmt.Fprintf(&b, "\n%s : %s\n", message.Speaker, message.Content)
+		} else {
+			fmt.Fprintf(&b, "\n[%s] %s : %s\n", message.CreatedAt.Format(dateLayout), message.Speaker, message.Content)
+		}
+	}
+	return b.String()
+}
+
+// JSON renders the transcript as JSON, with the position of the replies in
+// the concatenated audio in seconds
+func JSON(t *Transcript) ([]byte, error) {
+	type message struct {
+		Message
+		Start *float64 `json:"start,omitempty"`
+		End   *float64 `json:"end,omitempty"`
+	}
+	messages := make([]message, len(t.Messages))
+	for i, m := range t.Messages {
+		messages[i] = message{Message: m}
+		if m.Audio != "" {
+			start, end := m.Start.Seconds(), m.End.Seconds()
+			messages[i].Start, messages[i].End = &start, &end
+		}
+	}
+
+	data, err := json.MarshalIndent(struct {
+		*Transcript
+		Messages []message `json:"messages"`
+	}{t, messages}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// HTML renders the transcript as a self-contained page, the voiced replies
+// being embedded in audio players
+func HTML(t *Transcript) ([]byte, error) {
+	type message struct {
+		Message
+		User  bool
+		Audio template.URL
+	}
+	messages := make([]message, len(t.Messages))
+	for i, m := range t.Messages {
+		messages[i] = message{Message: m, User: m.Role == "user"}
+		if m.Audio == "" {
+			continue
+		}
+		data, err := os.ReadFile(m.Audio)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed audio: %w", err)
+		}
+		messages[i].Audio = template.URL("data:audio/mpeg;base64," + base64.StdEncoding.EncodeToString(data))
+	}
+
+	var b bytes.Buffer
+	err := htmlTemplate.Execute(&b, map[string]any{
+		"Persona":  t.Persona,
+		"Metadata": t.metadata(),
+		"Messages": messages,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// metadata describes the persona and the dates of the transcript
+func (t *Transcript) metadata() []string {
+	lines := []string{fmt.Sprintf("Voix : %s", t.Voice)}
+	if t.Models.Chat != "" {
+		lines = append(lines, fmt.Sprintf("Modèle : %s", t.Models.Chat))
+	}
+	if t.Session != "" {
+		lines = append(lines, fmt.Sprintf("Session : %s", t.Session))
+	}
+	if !t.UpdatedAt.IsZero() {
+		lines = append(lines, fmt.Sprintf("Dernier message : %s", t.UpdatedAt.Format(dateLayout)))
+	}
+	lines = append(lines, fmt.Sprintf("Exportée le %s", t.ExportedAt.Format(dateLayout)))
+	return lines
+}
+
+// Captions renders the timing of the voiced replies as SRT or WebVTT
+func Captions(t *Transcript, format string) ([]byte, error) {
+	var separator string
+	var b strings.Builder
+	switch format {
+	case "srt":
+		separator = ","
+	case "vtt":
+		separator = "."
+		b.WriteString("WEBVTT\n\n")
+	default:
+		return nil, fmt.Errorf("unknown caption format %q (formats: %s)", format, strings.Join(CaptionFormats, ", "))
+	}
+
+	cue := 0
+	for _, message := range t.Messages {
+		if message.Audio == "" {
+			continue
+		}
+		cue++
+		if format == "srt" {
+			fmt.Fprintf(&b, "%d\n", cue)
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			timestamp(message.Start, separator), timestamp(message.End, separator),
+			strings.TrimSpace(message.Content))
+	}
+	return []byte(b.String()), nil
+}
+
+// timestamp formats d as hh:mm:ss followed by the milliseconds
+func timestamp(d time.Duration, separator string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, separator, ms%1000)
+}
+
+var htmlTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Conversation avec {{.Persona}}</title>
+<style>
+  body { font-family: system-ui, sans-serif; max-width: 46rem; margin: 2rem auto; padding: 0 1rem; background: #1e1e2e; color: #cdd6f4; }
+  h1 { color: #cba6f7; }
+  .meta { color: #a6adc8; font-size: .9rem; list-style: none; padding: 0; }
+  .message { margin: 1rem 0; padding: .75rem 1rem; border-radius: .75rem; background: #313244; white-space: pre-wrap; }
+  .message.user { background: #45475a; margin-left: 3rem; }
+  .speaker { font-weight: bold; color: #89b4fa; display: block; margin-bottom: .25rem; }
+  .user .speaker { color: #a6e3a1; }
+  time { font-weight: normal; color: #a6adc8; font-size: .8rem; }
+  audio { display: block; width: 100%; margin-top: .5rem; }
+</style>
+</head>
+<body>
+<h1>Conversation avec {{.Persona}}</h1>
+<ul class="meta">
+{{- range .Metadata}}
+  <li>{{.}}</li>
+{{- end}}
+</ul>
+{{- range .Messages}}
+<div class="message{{if .User}} user{{end}}"><span class="speaker">{{.Speaker}}{{if not .CreatedAt.IsZero}} <time>{{.CreatedAt.Format "02/01/2006 15:04"}}</time>{{end}}</span>{{.Content}}
+{{- if .Audio}}<audio controls preload="none" src="{{.Audio}}"></audio>{{end}}</div>
+{{- end}}
+</body>
+</html>
+`))
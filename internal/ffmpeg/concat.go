@@ -0,0 +1,55 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Concat stitches audio files of the same format into a single output file
+func Concat(inputs []string, output string) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("no audio files to concatenate")
+	}
+
+	// The concat demuxer reads the inputs from a list file
+	list, err := os.CreateTemp("", "persona-concat-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create list file: %w", err)
+	}
+	defer os.Remove(list.Name())
+
+	var entries strings.Builder
+	for _, input := range inputs {
+		path, err := filepath.Abs(input)
+		if err != nil {
+			list.Close()
+			return fmt.Errorf("failed to resolve %s: %w", input, err)
+		}
+		fmt.Fprintf(&entries, "file '%s'\n", strings.ReplaceAll(path, "'", `'\''`))
+	}
+	if _, err := list.WriteString(entries.String()); err != nil {
+		list.Close()
+		return fmt.Errorf("failed to write list file: %w", err)
+	}
+	if err := list.Close(); err != nil {
+		return fmt.Errorf("failed to write list file: %w", err)
+	}
+
+	cmd := exec.Command(
+		"ffmpeg.exe",
+		"-y",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", list.Name(),
+		"-c", "copy",
+		output,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg concat failed: %w\nFFmpeg output:\n%s", err, string(out))
+	}
+
+	return nil
+}
@@ -13,7 +13,7 @@ import (
 type Recorder struct {
 	Input            string
 	SilenceThreshold int
-	SilenceDuration  int
+	SilenceDuration  float64
 }
 
 // Player holds configuration for audio playback
@@ -27,7 +27,7 @@ type FFmpeg struct {
 }
 
 // New creates a new FFmpeg instance with default values for optional parameters
-func New(input string, silenceThreshold int, silenceDuration int) *FFmpeg {
+func New(input string, silenceThreshold int, silenceDuration float64) *FFmpeg {
 	// Set default values if negative values are provided
 	if silenceThreshold == 0 {
 		silenceThreshold = -50
@@ -60,7 +60,7 @@ func (f *FFmpeg) Record() (string, error) {
 		"-f", "dshow",
 		"-y",
 		"-i", fmt.Sprintf("audio=%s", f.Recorder.Input),
-		"-af", fmt.Sprintf("silencedetect=n=%ddB:d=%d", f.Recorder.SilenceThreshold, f.Recorder.SilenceDuration),
+		"-af", fmt.Sprintf("silencedetect=n=%ddB:d=%g", f.Recorder.SilenceThreshold, f.Recorder.SilenceDuration),
 		tempFile.Name(),
 	)
 
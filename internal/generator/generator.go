@@ -0,0 +1,126 @@
+// Package generator drafts personas from a short description using the chat model.
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/schema"
+
+	"gopkg.in/yaml.v3"
+)
+
+const metaPrompt = `Tu conçois des personas pour un assistant vocal. Chaque persona a un prompt système, une voix de synthèse et des instructions de voix.
+
+À partir de la description donnée par l'utilisateur, réponds uniquement avec un objet JSON, sans texte autour, de la forme :
+{
+  "voice": "une voix parmi : %s",
+  "instructions": {
+    "voice": "timbre, accent et débit de la voix, en anglais",
+    "tone": "ton à adopter, en anglais",
+    "personality": "traits de personnalité, en anglais",
+    "pronunciation": "particularités de prononciation, en anglais",
+    "phrasing": "façon de construire ses phrases, en anglais"
+  },
+  "prompt": "le prompt système complet, en français, à la deuxième personne (Tu es ...)"
+}
+
+Le prompt décrit la personnalité, le contexte, la façon de répondre et reste adapté à une conversation orale : réponses courtes, pas de listes ni de Markdown.`
+
+// Chatter is the part of the AI client used to draft personas
+type Chatter interface {
+	Chat(messages []openai.Message) (string, error)
+}
+
+type draft struct {
+	Voice        string `json:"voice"`
+	Instructions struct {
+		Voice         string `json:"voice"`
+		Tone          string `json:"tone"`
+		Personality   string `json:"personality"`
+		Pronunciation string `json:"pronunciation"`
+		Phrasing      string `json:"phrasing"`
+	} `json:"instructions"`
+	Prompt string `json:"prompt"`
+}
+
+// Generate asks the chat model for a persona matching description. The voice
+// is chosen among voices.
+func Generate(ai Chatter, name, description string, voices []string) (*persona.Persona, error) {
+	if len(voices) == 0 {
+		return nil, fmt.Errorf("no voices available")
+	}
+
+	response, err := ai.Chat([]openai.Message{
+		{Role: "system", Content: fmt.Sprintf(metaPrompt, strings.Join(voices, ", "))},
+		{Role: "user", Content: description},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate persona: %w", err)
+	}
+
+	d, err := parse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	voice := strings.ToLower(strings.TrimSpace(d.Voice))
+	if !slices.Contains(voices, voice) {
+		// The model sometimes invents voices, fall back rather than failing
+		voice = voices[0]
+	}
+
+	instructions := []string{
+		"Voice: " + d.Instructions.Voice,
+		"Tone: " + d.Instructions.Tone,
+		"Personality: " + d.Instructions.Personality,
+		"Pronunciation: " + d.Instructions.Pronunciation,
+		"Phrasing: " + d.Instructions.Phrasing,
+	}
+
+	p := persona.New(name, persona.Voice{Name: voice, Instructions: strings.Join(instructions, "\n")}, strings.TrimSpace(d.Prompt))
+	p.Version = schema.Version
+	return p, nil
+}
+
+// YAML renders a generated persona as a persona.yaml template
+func YAML(p *persona.Persona) ([]byte, error) {
+	definition := *p
+	definition.History = nil
+
+	// Indent like the built-in personas
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&definition); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parse extracts the JSON object from the model response, ignoring code
+// fences or text around it
+func parse(response string) (*draft, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no persona found in the model response: %q", response)
+	}
+
+	d := &draft{}
+	if err := json.Unmarshal([]byte(response[start:end+1]), d); err != nil {
+		return nil, fmt.Errorf("failed to parse generated persona: %w", err)
+	}
+	if strings.TrimSpace(d.Prompt) == "" {
+		return nil, fmt.Errorf("the generated persona has no prompt")
+	}
+	return d, nil
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/schema"
+)
+
+type fakeChatter struct {
+	response string
+	err      error
+	messages []openai.Message
+}
+
+func (f *fakeChatter) Chat(messages []openai.Message) (string, error) {
+	f.messages = messages
+	return f.response, f.err
+}
+
+const sherlockResponse = "```json\n" + `{
+  "voice": "Onyx",
+  "instructions": {
+    "voice": "Refined British accent",
+    "tone": "Condescending",
+    "personality": "Brilliant detective",
+    "pronunciation": "Crisp consonants",
+    "phrasing": "Says 'Elementary'"
+  },
+  "prompt": "Tu es Sherlock Holmes."
+}` + "\n```"
+
+func TestGenerate(t *testing.T) {
+	ai := &fakeChatter{response: sherlockResponse}
+	voices := []string{"alloy", "onyx"}
+
+	p, err := Generate(ai, "sherlock", "Sherlock Holmes, détective", voices)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if !strings.Contains(ai.messages[0].Content, "alloy, onyx") {
+		t.Error("The meta-prompt should list the available voices")
+	}
+	if ai.messages[1].Content != "Sherlock Holmes, détective" {
+		t.Errorf("Expected the description as user message, got %q", ai.messages[1].Content)
+	}
+
+	if p.Name != "sherlock" || p.Voice.Name != "onyx" || p.Prompt != "Tu es Sherlock Holmes." {
+		t.Errorf("Unexpected persona: %+v", p)
+	}
+	expected := "Voice: Refined British accent\nTone: Condescending\nPersonality: Brilliant detective\nPronunciation: Crisp consonants\nPhrasing: Says 'Elementary'"
+	if p.Voice.Instructions != expected {
+		t.Errorf("Expected instructions %q, got %q", expected, p.Voice.Instructions)
+	}
+
+	data, err := YAML(p)
+	if err != nil {
+		t.Fatalf("YAML failed: %v", err)
+	}
+	if diagnostics := schema.ValidatePersona("persona.yaml", data, schema.PersonaOptions{Directory: "sherlock", SpeechModel: "gpt-4o-mini-tts"}); len(diagnostics) != 0 {
+		t.Errorf("Generated YAML should be valid, got %v", diagnostics)
+	}
+}
+
+func TestGenerate_UnknownVoiceFallsBack(t *testing.T) {
+	ai := &fakeChatter{response: `{"voice": "ballad2", "prompt": "Tu es un barde."}`}
+
+	p, err := Generate(ai, "barde", "Un barde", []string{"nova", "ballad"})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if p.Voice.Name != "nova" {
+		t.Errorf("Expected fallback voice 'nova', got '%s'", p.Voice.Name)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	voices := []string{"nova"}
+
+	if _, err := Generate(&fakeChatter{err: errors.New("boom")}, "x", "x", voices); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected the chat error, got %v", err)
+	}
+	if _, err := Generate(&fakeChatter{response: "Désolé, je ne peux pas."}, "x", "x", voices); err == nil {
+		t.Error("Expected an error without JSON")
+	}
+	if _, err := Generate(&fakeChatter{response: `{"voice": "nova", "prompt": ""}`}, "x", "x", voices); err == nil {
+		t.Error("Expected an error for an empty prompt")
+	}
+}
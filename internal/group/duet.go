@@ -0,0 +1,98 @@
+package group
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+)
+
+const duetTopic = `Sujet de la discussion : %s
+Échangez à tour de rôle comme dans un podcast : réagissez à ce que vient de dire l'autre, relancez la discussion et restez concis.`
+
+// ChatFunc asks a participant to answer the given messages
+type ChatFunc func(speaker string, messages []persona.Message) (string, error)
+
+// Duet is an autonomous conversation between two personas on a topic
+type Duet struct {
+	Topic    string
+	Session  *Session
+	personas map[string]*persona.Persona
+}
+
+// Line is one reply in a duet script
+type Line struct {
+	Speaker string `json:"speaker"`
+	Content string `json:"content"`
+}
+
+// Script is the written result of a duet
+type Script struct {
+	Topic        string   `json:"topic"`
+	Participants []string `json:"participants"`
+	Lines        []Line   `json:"lines"`
+}
+
+// NewDuet prepares a duet where the first persona speaks first
+func NewDuet(first, second *persona.Persona, topic string) *Duet {
+	session := New([]string{first.Name, second.Name}, ModeRoundtable)
+	session.AddUserMessage(fmt.Sprintf(duetTopic, topic))
+
+	return &Duet{
+		Topic:   topic,
+		Session: session,
+		personas: map[string]*persona.Persona{
+			first.Name:  first,
+			second.Name: second,
+		},
+	}
+}
+
+// Next asks the participant whose turn it is to answer and returns the reply
+func (d *Duet) Next(chat ChatFunc) (Line, error) {
+	speaker := d.Session.Participants[d.turn()%len(d.Session.Participants)]
+
+	response, err := chat(speaker, d.Session.MessagesFor(d.personas[speaker]))
+	if err != nil {
+		return Line{}, fmt.Errorf("%s: %w", speaker, err)
+	}
+	d.Session.AddReply(speaker, response)
+	return Line{Speaker: speaker, Content: response}, nil
+}
+
+// Script returns the replies exchanged so far
+func (d *Duet) Script() Script {
+	script := Script{
+		Topic:        d.Topic,
+		Participants: d.Session.Participants,
+		Lines:        []Line{},
+	}
+	for _, message := range d.Session.Transcript {
+		if message.Role == "assistant" {
+			script.Lines = append(script.Lines, Line{Speaker: message.Name, Content: message.Content})
+		}
+	}
+	return script
+}
+
+// Markdown renders the script as a Markdown document
+func (s Script) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", s.Topic)
+	fmt.Fprintf(&b, "_Avec %s_\n", strings.Join(s.Participants, " et "))
+	for _, line := range s.Lines {
+		fmt.Fprintf(&b, "\n**%s** : %s\n", line.Speaker, line.Content)
+	}
+	return b.String()
+}
+
+// turn returns the number of replies already given
+func (d *Duet) turn() int {
+	turns := 0
+	for _, message := range d.Session.Transcript {
+		if message.Role == "assistant" {
+			turns++
+		}
+	}
+	return turns
+}
@@ -0,0 +1,65 @@
+package group
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+)
+
+func TestDuet_Alternates(t *testing.T) {
+	freud := persona.New("freud", persona.Voice{Name: "onyx"}, "Tu es Freud.")
+	merlin := persona.New("merlin", persona.Voice{Name: "fable"}, "Tu es Merlin.")
+	d := NewDuet(freud, merlin, "Les rêves")
+
+	var seen [][]persona.Message
+	chat := func(speaker string, messages []persona.Message) (string, error) {
+		seen = append(seen, messages)
+		return "réplique de " + speaker, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := d.Next(chat); err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+	}
+
+	script := d.Script()
+	if len(script.Lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(script.Lines))
+	}
+	for i, expected := range []string{"freud", "merlin", "freud"} {
+		if script.Lines[i].Speaker != expected {
+			t.Errorf("Expected line %d by %s, got %s", i, expected, script.Lines[i].Speaker)
+		}
+	}
+
+	// Merlin is given the topic and Freud's opening line
+	merlinView := seen[1]
+	if !strings.Contains(merlinView[1].Content, "Les rêves") {
+		t.Errorf("Expected the topic to be given, got %q", merlinView[1].Content)
+	}
+	if last := merlinView[len(merlinView)-1]; last.Content != "[freud] réplique de freud" {
+		t.Errorf("Expected Freud's line attributed by name, got %q", last.Content)
+	}
+
+	markdown := script.Markdown()
+	if !strings.HasPrefix(markdown, "# Les rêves") || !strings.Contains(markdown, "**merlin** : réplique de merlin") {
+		t.Errorf("Unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestDuet_ErrorNamesSpeaker(t *testing.T) {
+	d := NewDuet(persona.New("freud", persona.Voice{}, ""), persona.New("merlin", persona.Voice{}, ""), "Rien")
+
+	_, err := d.Next(func(string, []persona.Message) (string, error) {
+		return "", errors.New("boom")
+	})
+	if err == nil || !strings.Contains(err.Error(), "freud") {
+		t.Errorf("Expected an error naming freud, got %v", err)
+	}
+	if len(d.Script().Lines) != 0 {
+		t.Error("A failed turn should not be recorded")
+	}
+}
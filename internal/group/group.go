@@ -0,0 +1,197 @@
+// Package group provides group conversations between the user and several personas.
+package group
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode decides who answers the user in a group conversation
+type Mode string
+
+const (
+	// ModeRoundtable lets every participant answer in turn
+	ModeRoundtable Mode = "roundtable"
+	// ModeModerator lets a moderator pick the participant who answers
+	ModeModerator Mode = "moderator"
+)
+
+// UserName is the name given to the user in transcripts shown to personas
+const UserName = "Utilisateur"
+
+const groupContext = `Tu participes à une discussion de groupe avec %s et l'utilisateur.
+Chaque message des autres participants est précédé de leur nom entre crochets.
+Réponds uniquement en tant que %s, sans préfixer ta réponse par ton nom, et sans parler à la place des autres.`
+
+const moderatorPrompt = `Tu es le modérateur d'une discussion de groupe entre l'utilisateur et les participants suivants :
+%s
+Lis la conversation et choisis le participant le plus pertinent pour répondre au dernier message.
+Réponds uniquement par son nom, exactement comme il est écrit dans la liste.`
+
+// Session is a conversation between the user and several personas
+type Session struct {
+	Name         string            `yaml:"name" json:"name"`
+	Participants []string          `yaml:"participants" json:"participants"`
+	Mode         Mode              `yaml:"mode" json:"mode"`
+	Transcript   []persona.Message `yaml:"-" json:"transcript,omitempty"`
+}
+
+// New creates a session named after its participants
+func New(participants []string, mode Mode) *Session {
+	if mode == "" {
+		mode = ModeRoundtable
+	}
+	return &Session{
+		Name:         DefaultName(participants),
+		Participants: participants,
+		Mode:         mode,
+		Transcript:   []persona.Message{},
+	}
+}
+
+// DefaultName returns the session name used for a set of participants,
+// independent of their order
+func DefaultName(participants []string) string {
+	names := append([]string{}, participants...)
+	sort.Strings(names)
+	return strings.Join(names, "+")
+}
+
+// RenameParticipant replaces a participant in the session and its transcript.
+// A session named after its participants is renamed too. It reports whether
+// the session changed.
+func (s *Session) RenameParticipant(oldName, newName string) bool {
+	index := slices.Index(s.Participants, oldName)
+	if index < 0 {
+		return false
+	}
+
+	defaultName := DefaultName(s.Participants)
+	s.Participants[index] = newName
+	if s.Name == defaultName {
+		s.Name = DefaultName(s.Participants)
+	}
+
+	for i, message := range s.Transcript {
+		if message.Name == oldName {
+			s.Transcript[i].Name = newName
+		}
+	}
+	return true
+}
+
+// AddUserMessage appends a user message to the transcript
+func (s *Session) AddUserMessage(content string) {
+	s.Transcript = append(s.Transcript, persona.NewMessage("user", content))
+}
+
+// AddReply appends a participant's reply to the transcript
+func (s *Session) AddReply(speaker, content string) {
+	reply := persona.NewMessage("assistant", content)
+	reply.Name = speaker
+	s.Transcript = append(s.Transcript, reply)
+}
+
+// MessagesFor returns the conversation as seen by one participant: its own
+// replies are assistant messages, everyone else's are attributed by name
+func (s *Session) MessagesFor(p *persona.Persona) []persona.Message {
+	var others []string
+	for _, name := range s.Participants {
+		if name != p.Name {
+			others = append(others, name)
+		}
+	}
+
+	system := p.SystemMessage()
+	system.Content += "\n\n" + fmt.Sprintf(groupContext, strings.Join(others, ", "), p.Name)
+
+	messages := []persona.Message{system}
+	for _, message := range s.Transcript {
+		if message.Role == "assistant" && message.Name == p.Name {
+			messages = append(messages, persona.Message{Role: "assistant", Content: message.Content})
+			continue
+		}
+		messages = append(messages, persona.Message{Role: "user", Content: attribute(message)})
+	}
+	return messages
+}
+
+// ModeratorMessages returns the messages asking a moderator who should speak next
+func (s *Session) ModeratorMessages() []persona.Message {
+	var lines []string
+	for _, message := range s.Transcript {
+		lines = append(lines, attribute(message))
+	}
+	return []persona.Message{
+		{Role: "system", Content: fmt.Sprintf(moderatorPrompt, "- "+strings.Join(s.Participants, "\n- "))},
+		{Role: "user", Content: strings.Join(lines, "\n")},
+	}
+}
+
+// ParseSpeaker finds the participant named first in a moderator response.
+// Names match as whole words, so "Al" is not found in "Alice".
+func (s *Session) ParseSpeaker(response string) (string, bool) {
+	speaker, first := "", -1
+	for _, name := range s.Participants {
+		word := regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_-])(` + regexp.QuoteMeta(name) + `)(?:[^\p{L}\p{N}_-]|$)`)
+		match := word.FindStringSubmatchIndex(response)
+		if match == nil {
+			continue
+		}
+		if start := match[2]; first < 0 || start < first {
+			speaker, first = name, start
+		}
+	}
+	return speaker, first >= 0
+}
+
+// Save writes the session description and its transcript
+func (s *Session) Save(sessionPath, historyPath string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(sessionPath, data, 0644); err != nil {
+		return err
+	}
+
+	transcript := &persona.Persona{History: s.Transcript}
+	return transcript.SaveHistory(historyPath)
+}
+
+// Load reads the session description and, if present, its transcript
+func (s *Session) Load(sessionPath, historyPath string) error {
+	data, err := os.ReadFile(sessionPath)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return err
+	}
+
+	transcript := &persona.Persona{}
+	if err := transcript.LoadHistory(historyPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.Transcript = transcript.History
+	if s.Transcript == nil {
+		s.Transcript = []persona.Message{}
+	}
+	return nil
+}
+
+func attribute(message persona.Message) string {
+	name := message.Name
+	if message.Role == "user" || name == "" {
+		name = UserName
+	}
+	return fmt.Sprintf("[%s] %s", name, message.Content)
+}
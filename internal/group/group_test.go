@@ -0,0 +1,134 @@
+package group
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+)
+
+func TestDefaultName(t *testing.T) {
+	if DefaultName([]string{"merlin", "freud"}) != DefaultName([]string{"freud", "merlin"}) {
+		t.Error("Expected the session name to ignore participant order")
+	}
+	if got := DefaultName([]string{"merlin", "freud"}); got != "freud+merlin" {
+		t.Errorf("Expected 'freud+merlin', got '%s'", got)
+	}
+}
+
+func TestSession_MessagesFor(t *testing.T) {
+	s := New([]string{"freud", "merlin"}, "")
+	if s.Mode != ModeRoundtable {
+		t.Errorf("Expected default mode %s, got %s", ModeRoundtable, s.Mode)
+	}
+
+	s.AddUserMessage("Bonjour")
+	s.AddReply("freud", "Parlez-moi de votre mère")
+	s.AddReply("merlin", "Par ma barbe !")
+
+	freud := &persona.Persona{Name: "freud", Prompt: "Tu es Freud."}
+	messages := s.MessagesFor(freud)
+
+	if len(messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(messages))
+	}
+	if messages[0].Role != "system" || !strings.HasPrefix(messages[0].Content, "Tu es Freud.") {
+		t.Errorf("Unexpected system message: %+v", messages[0])
+	}
+	if !strings.Contains(messages[0].Content, "merlin") {
+		t.Error("System message should name the other participants")
+	}
+	if messages[1].Role != "user" || messages[1].Content != "["+UserName+"] Bonjour" {
+		t.Errorf("Unexpected user message: %+v", messages[1])
+	}
+	if messages[2].Role != "assistant" || messages[2].Content != "Parlez-moi de votre mère" {
+		t.Errorf("Own reply should be an assistant message, got %+v", messages[2])
+	}
+	if messages[3].Role != "user" || messages[3].Content != "[merlin] Par ma barbe !" {
+		t.Errorf("Other replies should be attributed by name, got %+v", messages[3])
+	}
+}
+
+func TestSession_ParseSpeaker(t *testing.T) {
+	s := New([]string{"freud", "merlin"}, ModeModerator)
+
+	if speaker, ok := s.ParseSpeaker(" Merlin.\n"); !ok || speaker != "merlin" {
+		t.Errorf("Expected merlin, got '%s' (%v)", speaker, ok)
+	}
+	if _, ok := s.ParseSpeaker("personne"); ok {
+		t.Error("Expected no speaker for an unknown name")
+	}
+	if _, ok := s.ParseSpeaker("Un conseil merlinesque"); ok {
+		t.Error("Expected names to match whole words only")
+	}
+
+	overlapping := New([]string{"al", "alice", "sherlock"}, ModeModerator)
+	tests := map[string]string{
+		"Alice.":                            "alice",
+		"Al, puis Alice":                    "al",
+		"Sherlock doit répondre, pas Alice": "sherlock",
+		"La parole est à al-bert ou à Al":   "al",
+	}
+	for response, want := range tests {
+		if speaker, ok := overlapping.ParseSpeaker(response); !ok || speaker != want {
+			t.Errorf("Expected %s for %q, got '%s' (%v)", want, response, speaker, ok)
+		}
+	}
+
+	messages := s.ModeratorMessages()
+	if len(messages) != 2 || !strings.Contains(messages[0].Content, "- freud\n- merlin") {
+		t.Errorf("Unexpected moderator messages: %+v", messages)
+	}
+}
+
+func TestSession_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	sessionPath := filepath.Join(dir, "session.yaml")
+	historyPath := filepath.Join(dir, "history.yaml")
+
+	s := New([]string{"freud", "merlin"}, ModeModerator)
+	s.AddUserMessage("Bonjour")
+	s.AddReply("merlin", "Salutations")
+
+	if err := s.Save(sessionPath, historyPath); err != nil {
+		t.Fatalf("Failed to save session: %v", err)
+	}
+
+	loaded := &Session{}
+	if err := loaded.Load(sessionPath, historyPath); err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if loaded.Name != "freud+merlin" || loaded.Mode != ModeModerator || len(loaded.Participants) != 2 {
+		t.Errorf("Unexpected session: %+v", loaded)
+	}
+	if len(loaded.Transcript) != 2 || loaded.Transcript[1].Name != "merlin" {
+		t.Errorf("Speaker names should survive a reload, got %+v", loaded.Transcript)
+	}
+}
+
+func TestSession_RenameParticipant(t *testing.T) {
+	s := New([]string{"freud", "merlin"}, "")
+	s.AddReply("freud", "Parlez-moi de votre mère")
+
+	if !s.RenameParticipant("freud", "sigmund") {
+		t.Fatal("Expected the session to change")
+	}
+	if s.Name != "merlin+sigmund" {
+		t.Errorf("Expected the session to follow its participants, got '%s'", s.Name)
+	}
+	if s.Participants[0] != "sigmund" || s.Transcript[0].Name != "sigmund" {
+		t.Errorf("Unexpected session: %+v", s)
+	}
+
+	custom := New([]string{"freud", "merlin"}, "")
+	custom.Name = "atelier"
+	custom.RenameParticipant("merlin", "grognon")
+	if custom.Name != "atelier" {
+		t.Errorf("Expected a named session to keep its name, got '%s'", custom.Name)
+	}
+
+	if s.RenameParticipant("kevin", "kev") {
+		t.Error("Expected no change for a non-participant")
+	}
+}
@@ -0,0 +1,66 @@
+package openai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SpeechCache keeps synthesized speech, so that a text spoken again in the
+// same voice is not paid for twice
+type SpeechCache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, data []byte) error
+	// Path returns the file holding key, if cached
+	Path(key string) (string, bool)
+}
+
+// WithSpeechCache looks up the speech of the client in cache before asking
+// the API, and stores what the API returns
+func (o *OpenAI) WithSpeechCache(cache SpeechCache) *OpenAI {
+	o.speechCache = cache
+	return o
+}
+
+// SpeechKey identifies the speech of text by everything shaping it
+func (o *OpenAI) SpeechKey(text, instructions string) string {
+	fields := []string{o.baseURL, o.speechModel, o.voice, instructions, strconv.FormatFloat(o.options.Speed, 'f', -1, 64), text}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SpeechPath returns the file caching the speech of text, once it was read
+// to the end
+func (o *OpenAI) SpeechPath(text, instructions string) (string, bool) {
+	if o.speechCache == nil {
+		return "", false
+	}
+	return o.speechCache.Path(o.SpeechKey(text, instructions))
+}
+
+// cachingBody streams the speech as it downloads, and caches it once read to
+// the end. Speech closed before its end is not cached.
+type cachingBody struct {
+	body  io.ReadCloser
+	data  bytes.Buffer
+	key   string
+	cache SpeechCache
+}
+
+func (c *cachingBody) Read(p []byte) (int, error) {
+	n, err := c.body.Read(p)
+	c.data.Write(p[:n])
+	if err == io.EOF && c.cache != nil {
+		// Failing to cache only means paying again next time
+		_ = c.cache.Put(c.key, c.data.Bytes())
+		c.cache = nil
+	}
+	return n, err
+}
+
+func (c *cachingBody) Close() error {
+	return c.body.Close()
+}
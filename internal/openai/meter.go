@@ -0,0 +1,73 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Kinds of API calls
+const (
+	CallChat          = "chat"
+	CallSpeech        = "speech"
+	CallTranscription = "transcription"
+)
+
+// Call describes what an API call consumed, for usage accounting
+type Call struct {
+	Kind string
+	// Persona is the persona the call was made for, empty outside of one
+	Persona          string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	// Characters is the length of the spoken text
+	Characters int
+	// AudioSeconds is the duration of the transcribed audio
+	AudioSeconds float64
+}
+
+// Meter accounts for the API calls of clients
+type Meter interface {
+	// Allow is asked before each call and may refuse it, e.g. past a budget
+	Allow() error
+	// Record is told about each successful call
+	Record(call Call)
+}
+
+// WithMeter reports the calls of the client to meter, on behalf of persona
+func (o *OpenAI) WithMeter(meter Meter, persona string) *OpenAI {
+	o.meter = meter
+	o.persona = persona
+	return o
+}
+
+// allow asks the meter, if any, whether a call may be made
+func (o *OpenAI) allow() error {
+	if o.meter == nil {
+		return nil
+	}
+	return o.meter.Allow()
+}
+
+// record reports a successful call to the meter, if any
+func (o *OpenAI) record(call Call) {
+	if o.meter == nil {
+		return
+	}
+	call.Persona = o.persona
+	o.meter.Record(call)
+}
+
+// wavSeconds returns the duration of a WAV recording from its header, or
+// zero for other formats
+func wavSeconds(data []byte) float64 {
+	const headerSize = 44
+	if len(data) < headerSize || !bytes.Equal(data[0:4], []byte("RIFF")) || !bytes.Equal(data[8:12], []byte("WAVE")) {
+		return 0
+	}
+	byteRate := binary.LittleEndian.Uint32(data[28:32])
+	if byteRate == 0 {
+		return 0
+	}
+	return float64(len(data)-headerSize) / float64(byteRate)
+}
@@ -0,0 +1,30 @@
+package openai
+
+import (
+	"maps"
+	"slices"
+)
+
+// Chat models known to work with the chat completions endpoint
+var chatModels = []string{
+	"gpt-4o-mini", "gpt-4o", "gpt-4.1", "gpt-4.1-mini", "gpt-4.1-nano",
+	"gpt-4-turbo", "gpt-4", "gpt-3.5-turbo", "o3-mini", "o4-mini",
+}
+
+// Transcription models known to work with the transcriptions endpoint
+var transcriptionModels = []string{"gpt-4o-mini-transcribe", "gpt-4o-transcribe", "whisper-1"}
+
+// ChatModels returns the known chat models
+func ChatModels() []string {
+	return slices.Clone(chatModels)
+}
+
+// TranscriptionModels returns the known transcription models
+func TranscriptionModels() []string {
+	return slices.Clone(transcriptionModels)
+}
+
+// SpeechModels returns the known speech models, sorted
+func SpeechModels() []string {
+	return slices.Sorted(maps.Keys(speechVoices))
+}
@@ -3,11 +3,17 @@ package openai
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type OpenAI struct {
@@ -16,6 +22,38 @@ type OpenAI struct {
 	speechModel        string
 	chatModel          string
 	voice              string
+	options            Options
+	baseURL            string
+
+	// meter accounts for the calls, on behalf of persona
+	meter   Meter
+	persona string
+
+	speechCache SpeechCache
+}
+
+// DefaultBaseURL is the endpoint of the OpenAI API
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Options override the configured models and tune the requests of a client.
+// Empty fields keep the defaults of the API.
+type Options struct {
+	TranscriptionModel string
+	SpeechModel        string
+	ChatModel          string
+
+	Temperature     *float64
+	TopP            *float64
+	MaxTokens       int
+	PresencePenalty *float64
+
+	// Speed of the generated speech, from 0.25 to 4
+	Speed float64
+
+	// Language of the recordings, as an ISO-639-1 code
+	Language string
+	// TranscriptionPrompt guides the transcription, e.g. with names and jargon
+	TranscriptionPrompt string
 }
 
 type Message struct {
@@ -24,25 +62,55 @@ type Message struct {
 }
 
 type ChatRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model           string    `json:"model"`
+	Messages        []Message `json:"messages"`
+	Temperature     *float64  `json:"temperature,omitempty"`
+	TopP            *float64  `json:"top_p,omitempty"`
+	MaxTokens       int       `json:"max_completion_tokens,omitempty"`
+	PresencePenalty *float64  `json:"presence_penalty,omitempty"`
 }
 
 type ChatResponse struct {
+	Model   string `json:"model"`
 	Choices []struct {
 		Message Message `json:"message"`
 	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+// Usage counts the tokens billed for a chat completion
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Completion is a reply of the chat model with its metadata
+type Completion struct {
+	Content string
+	// Model is the model version that answered, e.g. gpt-4o-mini-2024-07-18
+	Model   string
+	Usage   Usage
+	Latency time.Duration
 }
 
 type AudioRequest struct {
-	Model        string `json:"model"`
-	Input        string `json:"input"`
-	Voice        string `json:"voice"`
-	Instructions string `json:"instructions,omitempty"`
+	Model        string  `json:"model"`
+	Input        string  `json:"input"`
+	Voice        string  `json:"voice"`
+	Instructions string  `json:"instructions,omitempty"`
+	Speed        float64 `json:"speed,omitempty"`
 }
 
 type TranscriptionResponse struct {
 	Text string `json:"text"`
+	// Usage is billed either by duration or by tokens, depending on the model
+	Usage struct {
+		Type         string  `json:"type"`
+		Seconds      float64 `json:"seconds"`
+		InputTokens  int     `json:"input_tokens"`
+		OutputTokens int     `json:"output_tokens"`
+	} `json:"usage"`
 }
 
 func New(apiKey string, transcriptionModel string, speechModel string, chatModel string, voice string) *OpenAI {
@@ -52,10 +120,44 @@ func New(apiKey string, transcriptionModel string, speechModel string, chatModel
 		speechModel:        speechModel,
 		chatModel:          chatModel,
 		voice:              voice,
+		baseURL:            DefaultBaseURL,
+	}
+}
+
+// WithBaseURL sends the requests to an OpenAI compatible API, such as a proxy
+// or a local server. An empty URL keeps the OpenAI API.
+func (o *OpenAI) WithBaseURL(baseURL string) *OpenAI {
+	if baseURL != "" {
+		o.baseURL = strings.TrimSuffix(baseURL, "/")
 	}
+	return o
+}
+
+// WithOptions applies options to the client and returns it
+func (o *OpenAI) WithOptions(options Options) *OpenAI {
+	if options.TranscriptionModel != "" {
+		o.transcriptionModel = options.TranscriptionModel
+	}
+	if options.SpeechModel != "" {
+		o.speechModel = options.SpeechModel
+	}
+	if options.ChatModel != "" {
+		o.chatModel = options.ChatModel
+	}
+	o.options = options
+	return o
 }
 
 func (o *OpenAI) Transcribe(audioFile io.Reader) (string, error) {
+	if err := o.allow(); err != nil {
+		return "", err
+	}
+
+	audio, err := io.ReadAll(audioFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read audio data: %w", err)
+	}
+
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 
@@ -63,13 +165,23 @@ func (o *OpenAI) Transcribe(audioFile io.Reader) (string, error) {
 	if err := writer.WriteField("model", o.transcriptionModel); err != nil {
 		return "", fmt.Errorf("failed to write model field: %w", err)
 	}
+	if o.options.Language != "" {
+		if err := writer.WriteField("language", o.options.Language); err != nil {
+			return "", fmt.Errorf("failed to write language field: %w", err)
+		}
+	}
+	if o.options.TranscriptionPrompt != "" {
+		if err := writer.WriteField("prompt", o.options.TranscriptionPrompt); err != nil {
+			return "", fmt.Errorf("failed to write prompt field: %w", err)
+		}
+	}
 
 	formFile, err := writer.CreateFormFile("file", "audio.wav")
 	if err != nil {
 		return "", fmt.Errorf("failed to create form file: %w", err)
 	}
 
-	if _, err := io.Copy(formFile, audioFile); err != nil {
+	if _, err := formFile.Write(audio); err != nil {
 		return "", fmt.Errorf("failed to copy audio data: %w", err)
 	}
 
@@ -78,7 +190,7 @@ func (o *OpenAI) Transcribe(audioFile io.Reader) (string, error) {
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/audio/transcriptions", &buf)
+	req, err := http.NewRequest("POST", o.baseURL+"/audio/transcriptions", &buf)
 	if err != nil {
 		return "", fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -97,7 +209,7 @@ func (o *OpenAI) Transcribe(audioFile io.Reader) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("failed to read response body: %w", err)
 		}
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, o.redact(string(body)))
 	}
 
 	var transcriptionResp TranscriptionResponse
@@ -110,15 +222,40 @@ func (o *OpenAI) Transcribe(audioFile io.Reader) (string, error) {
 		return "", fmt.Errorf("failed to close response body: %w", err)
 	}
 
+	call := Call{
+		Kind:             CallTranscription,
+		Model:            o.transcriptionModel,
+		PromptTokens:     transcriptionResp.Usage.InputTokens,
+		CompletionTokens: transcriptionResp.Usage.OutputTokens,
+		AudioSeconds:     transcriptionResp.Usage.Seconds,
+	}
+	if call.AudioSeconds == 0 {
+		call.AudioSeconds = wavSeconds(audio)
+	}
+	o.record(call)
+
 	return transcriptionResp.Text, nil
 }
 
 func (o *OpenAI) GenerateAudio(text string, instructions string) (io.Reader, error) {
+	var key string
+	if o.speechCache != nil {
+		key = o.SpeechKey(text, instructions)
+		if data, ok := o.speechCache.Get(key); ok {
+			return bytes.NewReader(data), nil
+		}
+	}
+
+	if err := o.allow(); err != nil {
+		return nil, err
+	}
+
 	audioReq := AudioRequest{
 		Model:        o.speechModel,
 		Input:        text,
 		Voice:        o.voice,
 		Instructions: instructions,
+		Speed:        o.options.Speed,
 	}
 
 	jsonData, err := json.Marshal(audioReq)
@@ -126,7 +263,7 @@ func (o *OpenAI) GenerateAudio(text string, instructions string) (io.Reader, err
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/audio/speech", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", o.baseURL+"/audio/speech", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -149,55 +286,131 @@ func (o *OpenAI) GenerateAudio(text string, instructions string) (io.Reader, err
 		if err != nil {
 			return nil, fmt.Errorf("failed to close response body: %w", err)
 		}
-		return nil, fmt.Errorf("API request failed with status: %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API request failed with status: %d: %s", resp.StatusCode, o.redact(string(body)))
 	}
 
-	return resp.Body, nil
+	o.record(Call{Kind: CallSpeech, Model: o.speechModel, Characters: utf8.RuneCountInString(text)})
+	if o.speechCache == nil {
+		return resp.Body, nil
+	}
+
+	return &cachingBody{body: resp.Body, key: key, cache: o.speechCache}, nil
 }
 
 func (o *OpenAI) Chat(messages []Message) (string, error) {
+	completion, err := o.Complete(messages)
+	if err != nil {
+		return "", err
+	}
+	return completion.Content, nil
+}
+
+// Complete asks the chat model for a reply and returns it with its token
+// usage and latency
+func (o *OpenAI) Complete(messages []Message) (*Completion, error) {
+	if err := o.allow(); err != nil {
+		return nil, err
+	}
+
 	chatReq := ChatRequest{
-		Model:    o.chatModel,
-		Messages: messages,
+		Model:           o.chatModel,
+		Messages:        messages,
+		Temperature:     o.options.Temperature,
+		TopP:            o.options.TopP,
+		MaxTokens:       o.options.MaxTokens,
+		PresencePenalty: o.options.PresencePenalty,
 	}
 
 	jsonData, err := json.Marshal(chatReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", o.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+o.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("API request failed with status: %d: %s", resp.StatusCode, o.redact(string(body)))
 	}
 
 	var chatResp ChatResponse
 	err = json.NewDecoder(resp.Body).Decode(&chatResp)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from API")
+		return nil, fmt.Errorf("no response from API")
 	}
 
 	err = resp.Body.Close()
 	if err != nil {
-		return "", fmt.Errorf("failed to close response body: %w", err)
+		return nil, fmt.Errorf("failed to close response body: %w", err)
+	}
+
+	o.record(Call{
+		Kind:             CallChat,
+		Model:            cmp.Or(chatResp.Model, o.chatModel),
+		PromptTokens:     chatResp.Usage.PromptTokens,
+		CompletionTokens: chatResp.Usage.CompletionTokens,
+	})
+
+	return &Completion{
+		Content: chatResp.Choices[0].Message.Content,
+		Model:   chatResp.Model,
+		Usage:   chatResp.Usage,
+		Latency: time.Since(start),
+	}, nil
+}
+
+// ErrInvalidKey is returned by ValidateKey when the API rejects the key
+var ErrInvalidKey = errors.New("invalid API key")
+
+// ValidateKey checks the API key by listing the models, which costs nothing
+func (o *OpenAI) ValidateKey() error {
+	req, err := http.NewRequest("GET", o.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
 	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return ErrInvalidKey
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status: %d: %s", resp.StatusCode, o.redact(string(body)))
+	}
+	return nil
+}
 
-	return chatResp.Choices[0].Message.Content, nil
+// keyPattern matches OpenAI style secret keys
+var keyPattern = regexp.MustCompile(`sk-[A-Za-z0-9_*-]{6,}`)
+
+// redact hides the API key in text, such as an error body echoing it
+func (o *OpenAI) redact(text string) string {
+	if o.apiKey != "" {
+		text = strings.ReplaceAll(text, o.apiKey, "[REDACTED]")
+	}
+	return keyPattern.ReplaceAllString(text, "sk-[REDACTED]")
 }
@@ -0,0 +1,171 @@
+package openai
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// memoryMeter records the calls and refuses them once blocked
+type memoryMeter struct {
+	mu      sync.Mutex
+	calls   []Call
+	blocked error
+}
+
+func (m *memoryMeter) Allow() error { return m.blocked }
+
+func (m *memoryMeter) Record(call Call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+type memoryCache map[string][]byte
+
+func (c memoryCache) Get(key string) ([]byte, bool) {
+	data, ok := c[key]
+	return data, ok
+}
+
+func (c memoryCache) Put(key string, data []byte) error {
+	c[key] = data
+	return nil
+}
+
+func (c memoryCache) Path(key string) (string, bool) {
+	_, ok := c[key]
+	return "memory/" + key, ok
+}
+
+func newTestServer(t *testing.T, requests map[string]int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/audio/speech":
+			w.Write([]byte("ID3 speech"))
+		case "/chat/completions":
+			w.Write([]byte(`{"model":"gpt-4o-mini-2024-07-18","choices":[{"message":{"role":"assistant","content":"Salut"}}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAI_MeterAndSpeechCache(t *testing.T) {
+	requests := map[string]int{}
+	server := newTestServer(t, requests)
+	meter := &memoryMeter{}
+	cache := memoryCache{}
+	client := New("sk-test", "whisper-1", "tts-1", "gpt-4o-mini", "nova").
+		WithBaseURL(server.URL).
+		WithMeter(meter, "coach").
+		WithSpeechCache(cache)
+
+	for range 2 {
+		audio, err := client.GenerateAudio("Bonjour à tous", "")
+		if err != nil {
+			t.Fatalf("Failed to generate audio: %v", err)
+		}
+		if data, _ := io.ReadAll(audio); string(data) != "ID3 speech" {
+			t.Errorf("Expected the speech, got %q", data)
+		}
+	}
+	if requests["/audio/speech"] != 1 {
+		t.Errorf("Expected the second synthesis to come from the cache, got %d requests", requests["/audio/speech"])
+	}
+	if _, err := client.GenerateAudio("Bonjour à tous", "Avec entrain"); err != nil {
+		t.Fatalf("Failed to generate audio: %v", err)
+	}
+	if requests["/audio/speech"] != 2 {
+		t.Error("Expected other instructions to miss the cache")
+	}
+
+	if _, err := client.Complete([]Message{{Role: "user", Content: "Salut"}}); err != nil {
+		t.Fatalf("Failed to complete: %v", err)
+	}
+
+	if len(meter.calls) != 3 {
+		t.Fatalf("Expected the 3 API calls to be recorded, got %+v", meter.calls)
+	}
+	speech, chat := meter.calls[0], meter.calls[2]
+	if speech.Kind != CallSpeech || speech.Characters != 14 || speech.Persona != "coach" || speech.Model != "tts-1" {
+		t.Errorf("Unexpected speech call: %+v", speech)
+	}
+	if chat.Kind != CallChat || chat.Model != "gpt-4o-mini-2024-07-18" || chat.PromptTokens != 12 || chat.CompletionTokens != 3 {
+		t.Errorf("Unexpected chat call: %+v", chat)
+	}
+
+	// A blocked meter stops the API calls, not the cached speech
+	meter.blocked = errors.New("budget")
+	if _, err := client.Complete([]Message{{Role: "user", Content: "Salut"}}); err == nil {
+		t.Error("Expected the meter to refuse the call")
+	}
+	if _, err := client.GenerateAudio("Bonjour à tous", ""); err != nil {
+		t.Errorf("Expected cached speech to play past the budget, got %v", err)
+	}
+	if requests["/chat/completions"] != 1 {
+		t.Errorf("Expected no request once refused, got %d", requests["/chat/completions"])
+	}
+}
+
+func TestOpenAI_SpeechCachedOnceRead(t *testing.T) {
+	requests := map[string]int{}
+	server := newTestServer(t, requests)
+	cache := memoryCache{}
+	client := New("sk-test", "whisper-1", "tts-1", "gpt-4o-mini", "nova").
+		WithBaseURL(server.URL).
+		WithSpeechCache(cache)
+
+	// Speech stopped before its end is not cached
+	audio, err := client.GenerateAudio("Bonjour", "")
+	if err != nil {
+		t.Fatalf("Failed to generate audio: %v", err)
+	}
+	audio.Read(make([]byte, 3))
+	audio.(io.Closer).Close()
+	if len(cache) != 0 {
+		t.Error("Expected partial speech not to be cached")
+	}
+	if _, ok := client.SpeechPath("Bonjour", ""); ok {
+		t.Error("Expected no file for partial speech")
+	}
+
+	audio, err = client.GenerateAudio("Bonjour", "")
+	if err != nil {
+		t.Fatalf("Failed to generate audio: %v", err)
+	}
+	io.ReadAll(audio)
+	audio.(io.Closer).Close()
+	if data, ok := cache[client.SpeechKey("Bonjour", "")]; !ok || string(data) != "ID3 speech" {
+		t.Errorf("Expected the speech cached once read, got %q", data)
+	}
+	if path, ok := client.SpeechPath("Bonjour", ""); !ok || path != "memory/"+client.SpeechKey("Bonjour", "") {
+		t.Errorf("Expected the file of the cached speech, got %q", path)
+	}
+	if requests["/audio/speech"] != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests["/audio/speech"])
+	}
+}
+
+func TestWavSeconds(t *testing.T) {
+	header := make([]byte, 44)
+	copy(header, "RIFF")
+	copy(header[8:], "WAVE")
+	header[28] = 0x80 // 16000 bytes per second, 16 kHz mono 8 bits
+	header[29] = 0x3e
+	data := append(header, make([]byte, 24000)...)
+
+	if got := wavSeconds(data); got != 1.5 {
+		t.Errorf("Expected 1.5 seconds, got %v", got)
+	}
+	if got := wavSeconds([]byte("ID3 not a wav")); got != 0 {
+		t.Errorf("Expected 0 for other formats, got %v", got)
+	}
+}
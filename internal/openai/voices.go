@@ -0,0 +1,14 @@
+package openai
+
+// Voices supported by each speech model
+var speechVoices = map[string][]string{
+	"tts-1":           {"alloy", "ash", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer"},
+	"tts-1-hd":        {"alloy", "ash", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer"},
+	"gpt-4o-mini-tts": {"alloy", "ash", "ballad", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer", "verse"},
+}
+
+// Voices returns the voices supported by a speech model, or nil when the
+// model is unknown
+func Voices(speechModel string) []string {
+	return speechVoices[speechModel]
+}
@@ -0,0 +1,89 @@
+package persona
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// Compose builds the persona on top of its parent (nil when it extends
+// nothing) and the contents of its included fragments. Voice, model and
+// generation settings left empty are inherited from the parent.
+func (p *Persona) Compose(parent *Persona, fragments []string) {
+	p.inherited = ""
+	if parent != nil {
+		p.inherited = parent.FullPrompt()
+		if p.Voice.Name == "" {
+			p.Voice.Name = parent.Voice.Name
+		}
+		if p.Voice.Instructions == "" {
+			p.Voice.Instructions = parent.Voice.Instructions
+		}
+		p.inheritSettings(parent)
+	}
+	p.fragments = fragments
+}
+
+// inheritSettings copies the model and generation settings the persona
+// leaves empty from its parent
+func (p *Persona) inheritSettings(parent *Persona) {
+	inherit(&p.Voice.Speed, parent.Voice.Speed)
+	inherit(&p.Models.Chat, parent.Models.Chat)
+	inherit(&p.Models.Speech, parent.Models.Speech)
+	inherit(&p.Models.Transcription, parent.Models.Transcription)
+	inherit(&p.Generation.Temperature, parent.Generation.Temperature)
+	inherit(&p.Generation.TopP, parent.Generation.TopP)
+	inherit(&p.Generation.MaxTokens, parent.Generation.MaxTokens)
+	inherit(&p.Generation.PresencePenalty, parent.Generation.PresencePenalty)
+	inherit(&p.Transcription.Language, parent.Transcription.Language)
+	inherit(&p.Transcription.Prompt, parent.Transcription.Prompt)
+}
+
+func inherit[T comparable](value *T, parent T) {
+	var zero T
+	if *value == zero {
+		*value = parent
+	}
+}
+
+// AddContext appends extra context, such as the description of the current
+// project, after the persona's prompt and fragments
+func (p *Persona) AddContext(context string) {
+	p.fragments = append(p.fragments, context)
+}
+
+// FullPrompt returns the inherited prompt, the persona's own prompt and its
+// fragments, before variables are expanded
+func (p *Persona) FullPrompt() string {
+	var parts []string
+	for _, part := range append([]string{p.inherited, p.Prompt}, p.fragments...) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ExpandVariables replaces {{date}}, {{cwd}} and the persona's Variables in
+// text. Unknown placeholders are left untouched.
+func (p *Persona) ExpandVariables(text string) string {
+	return variablePattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := variablePattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := p.Variables[name]; ok {
+			return value
+		}
+
+		switch name {
+		case "date":
+			return time.Now().Format("2006-01-02")
+		case "cwd":
+			if cwd, err := os.Getwd(); err == nil {
+				return cwd
+			}
+		}
+		return placeholder
+	})
+}
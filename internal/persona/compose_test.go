@@ -0,0 +1,64 @@
+package persona
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPersona_Compose(t *testing.T) {
+	base := New("base", Voice{Name: "nova", Instructions: "Calme"}, "Tu es un assistant.")
+	base.Compose(nil, []string{"Contexte d'équipe."})
+
+	child := &Persona{Name: "child", Extends: "base", Voice: Voice{Instructions: "Enjoué"}, Prompt: "Tu aimes les blagues."}
+	child.Compose(base, []string{"  ", "Règles du projet."})
+
+	expected := "Tu es un assistant.\n\nContexte d'équipe.\n\nTu aimes les blagues.\n\nRègles du projet."
+	if got := child.FullPrompt(); got != expected {
+		t.Errorf("Expected prompt %q, got %q", expected, got)
+	}
+	if child.Voice.Name != "nova" {
+		t.Errorf("Expected inherited voice 'nova', got '%s'", child.Voice.Name)
+	}
+	if child.Voice.Instructions != "Enjoué" {
+		t.Errorf("Expected own instructions to win, got '%s'", child.Voice.Instructions)
+	}
+	if child.Prompt != "Tu aimes les blagues." {
+		t.Error("Compose should not modify the persona's own prompt")
+	}
+}
+
+func TestPersona_ComposeInheritsSettings(t *testing.T) {
+	temperature, lower := 0.9, 0.2
+	base := New("base", Voice{Name: "nova", Speed: 1.2}, "Tu es un assistant.")
+	base.Models = Models{Chat: "gpt-4.1", Speech: "tts-1"}
+	base.Generation = Generation{Temperature: &temperature, MaxTokens: 500}
+	base.Transcription = Transcription{Language: "fr"}
+
+	child := &Persona{Name: "coach", Extends: "base", Prompt: "Sois bref."}
+	child.Models.Chat = "gpt-4.1-nano"
+	child.Generation.Temperature = &lower
+	child.Compose(base, nil)
+
+	options := child.ClientOptions()
+	if options.ChatModel != "gpt-4.1-nano" || options.SpeechModel != "tts-1" {
+		t.Errorf("Expected own chat model and inherited speech model, got %+v", child.Models)
+	}
+	if options.Temperature == nil || *options.Temperature != 0.2 {
+		t.Errorf("Expected own temperature 0.2, got %v", options.Temperature)
+	}
+	if options.MaxTokens != 500 || options.Speed != 1.2 || options.Language != "fr" {
+		t.Errorf("Expected inherited max tokens, speed and language, got %+v", options)
+	}
+}
+
+func TestPersona_ExpandVariables(t *testing.T) {
+	p := New("test", Voice{}, "Bonjour {{user.name}}, nous sommes le {{ date }} dans {{cwd}}. {{inconnu}}")
+	p.Variables = map[string]string{"user.name": "Alice"}
+
+	cwd, _ := os.Getwd()
+	expected := "Bonjour Alice, nous sommes le " + time.Now().Format("2006-01-02") + " dans " + cwd + ". {{inconnu}}"
+	if got := p.SystemMessage().Content; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
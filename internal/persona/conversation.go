@@ -0,0 +1,260 @@
+package persona
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Conversation keeps every branch of a persona's history as a tree. The
+// active path, from the root to the Active node, is what the persona's
+// History holds and what is sent to the model.
+type Conversation struct {
+	Nodes  []Node `yaml:"nodes" json:"nodes"`
+	Active string `yaml:"active,omitempty" json:"active,omitempty"`
+}
+
+// Node is a message in the conversation tree, identified by the message ID.
+// Nodes are stored in creation order, so the last child of a node is its
+// most recent branch.
+type Node struct {
+	Parent  string `yaml:"parent,omitempty" json:"parent,omitempty"`
+	Message `yaml:",inline"`
+}
+
+// NewConversation builds a linear conversation from a history
+func NewConversation(history []Message) *Conversation {
+	c := &Conversation{Nodes: []Node{}}
+	for _, message := range history {
+		c.Append(message)
+	}
+	return c
+}
+
+// Path returns the messages on the active path
+func (c *Conversation) Path() []Message {
+	ids := c.pathIDs()
+	messages := make([]Message, 0, len(ids))
+	for _, id := range ids {
+		messages = append(messages, c.node(id).Message)
+	}
+	return messages
+}
+
+// Append adds a message after the active node and makes it active. The
+// message gets a new ID unless it has one not used in the tree yet.
+func (c *Conversation) Append(message Message) string {
+	if message.ID == "" || c.node(message.ID) != nil {
+		message.ID = NewID()
+	}
+	c.Nodes = append(c.Nodes, Node{Parent: c.Active, Message: message})
+	c.Active = message.ID
+	return message.ID
+}
+
+// Update replaces the message with the same ID, such as a reply whose audio
+// was kept. It reports whether the message was found.
+func (c *Conversation) Update(message Message) bool {
+	node := c.node(message.ID)
+	if node == nil {
+		return false
+	}
+	node.Message = message
+	return true
+}
+
+// Rewind makes the parent of the message at index the active node, so the
+// next Append creates an alternative branch for that message
+func (c *Conversation) Rewind(index int) error {
+	ids := c.pathIDs()
+	if index < 0 || index >= len(ids) {
+		return fmt.Errorf("message index %d out of range", index)
+	}
+	c.Active = c.node(ids[index]).Parent
+	return nil
+}
+
+// Siblings returns the 1-based position of the message at index among its
+// alternative branches, and the number of branches
+func (c *Conversation) Siblings(index int) (position, total int) {
+	ids := c.pathIDs()
+	if index < 0 || index >= len(ids) {
+		return 0, 0
+	}
+	siblings := c.children(c.node(ids[index]).Parent)
+	for i, id := range siblings {
+		if id == ids[index] {
+			position = i + 1
+		}
+	}
+	return position, len(siblings)
+}
+
+// SwitchBranch moves the message at index to a neighbouring branch (delta
+// -1 or +1) and follows the most recent messages of that branch
+func (c *Conversation) SwitchBranch(index int, delta int) error {
+	ids := c.pathIDs()
+	if index < 0 || index >= len(ids) {
+		return fmt.Errorf("message index %d out of range", index)
+	}
+
+	siblings := c.children(c.node(ids[index]).Parent)
+	position := -1
+	for i, id := range siblings {
+		if id == ids[index] {
+			position = i
+		}
+	}
+
+	target := position + delta
+	if target < 0 || target >= len(siblings) {
+		return fmt.Errorf("no branch in that direction")
+	}
+
+	// Follow the latest branch down to a leaf
+	active := siblings[target]
+	for {
+		children := c.children(active)
+		if len(children) == 0 {
+			break
+		}
+		active = children[len(children)-1]
+	}
+	c.Active = active
+	return nil
+}
+
+// Delete removes count messages starting at index from the active path,
+// together with any alternative branches hanging off them. The messages that
+// followed them are kept and reattached to the previous message.
+func (c *Conversation) Delete(index, count int) error {
+	ids := c.pathIDs()
+	if index < 0 || count <= 0 || index+count > len(ids) {
+		return fmt.Errorf("cannot delete messages %d to %d", index, index+count-1)
+	}
+
+	parent := c.node(ids[index]).Parent
+	var keep string
+	if index+count < len(ids) {
+		keep = ids[index+count]
+	}
+
+	// Collect the removed nodes and their subtrees, except the kept continuation
+	removed := map[string]bool{}
+	var mark func(id string)
+	mark = func(id string) {
+		if id == keep {
+			return
+		}
+		removed[id] = true
+		for _, child := range c.children(id) {
+			mark(child)
+		}
+	}
+	mark(ids[index])
+
+	nodes := make([]Node, 0, len(c.Nodes))
+	for _, node := range c.Nodes {
+		if removed[node.ID] {
+			continue
+		}
+		if node.ID == keep {
+			node.Parent = parent
+		}
+		nodes = append(nodes, node)
+	}
+	c.Nodes = nodes
+
+	if keep == "" {
+		c.Active = parent
+	}
+	return nil
+}
+
+// Sync reconciles the tree with a history written by another command or
+// instance: the longest matching prefix of the active path is kept and the
+// remaining messages are appended as a new branch
+func (c *Conversation) Sync(history []Message) {
+	ids := c.pathIDs()
+
+	common := 0
+	for common < len(ids) && common < len(history) && c.node(ids[common]).Same(history[common]) {
+		// The history may carry newer metadata, such as the audio of a reply
+		if history[common].ID != "" {
+			c.node(ids[common]).Message = history[common]
+		}
+		common++
+	}
+	if common == len(ids) && common == len(history) {
+		return
+	}
+
+	c.Active = ""
+	if common > 0 {
+		c.Active = ids[common-1]
+	}
+	for _, message := range history[common:] {
+		c.Append(message)
+	}
+}
+
+func (c *Conversation) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (c *Conversation) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, c)
+}
+
+// pathIDs returns the node IDs from the root to the active node
+func (c *Conversation) pathIDs() []string {
+	var ids []string
+	for id := c.Active; id != ""; {
+		node := c.node(id)
+		if node == nil {
+			break
+		}
+		ids = append([]string{id}, ids...)
+		id = node.Parent
+	}
+	return ids
+}
+
+func (c *Conversation) node(id string) *Node {
+	for i := range c.Nodes {
+		if c.Nodes[i].ID == id {
+			return &c.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func (c *Conversation) children(parent string) []string {
+	var ids []string
+	for _, node := range c.Nodes {
+		if node.Parent == parent {
+			ids = append(ids, node.ID)
+		}
+	}
+	return ids
+}
+
+// NewID returns a random message ID
+func NewID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
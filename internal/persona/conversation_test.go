@@ -0,0 +1,202 @@
+package persona
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func contents(messages []Message) []string {
+	var result []string
+	for _, message := range messages {
+		result = append(result, message.Content)
+	}
+	return result
+}
+
+func assertPath(t *testing.T, c *Conversation, expected ...string) {
+	t.Helper()
+	path := contents(c.Path())
+	if len(path) != len(expected) {
+		t.Fatalf("Expected path %v, got %v", expected, path)
+	}
+	for i := range expected {
+		if path[i] != expected[i] {
+			t.Fatalf("Expected path %v, got %v", expected, path)
+		}
+	}
+}
+
+func newLinearConversation() *Conversation {
+	return NewConversation([]Message{
+		{Role: "user", Content: "Q1"},
+		{Role: "assistant", Content: "A1"},
+		{Role: "user", Content: "Q2"},
+		{Role: "assistant", Content: "A2"},
+	})
+}
+
+func TestNewConversation(t *testing.T) {
+	c := newLinearConversation()
+	assertPath(t, c, "Q1", "A1", "Q2", "A2")
+
+	if position, total := c.Siblings(1); position != 1 || total != 1 {
+		t.Errorf("Expected 1/1 for a linear history, got %d/%d", position, total)
+	}
+}
+
+func TestConversation_RegenerateCreatesBranch(t *testing.T) {
+	c := newLinearConversation()
+
+	if err := c.Rewind(3); err != nil {
+		t.Fatalf("Rewind failed: %v", err)
+	}
+	assertPath(t, c, "Q1", "A1", "Q2")
+
+	c.Append(Message{Role: "assistant", Content: "A2 bis"})
+	assertPath(t, c, "Q1", "A1", "Q2", "A2 bis")
+
+	if position, total := c.Siblings(3); position != 2 || total != 2 {
+		t.Errorf("Expected 2/2, got %d/%d", position, total)
+	}
+
+	if err := c.SwitchBranch(3, -1); err != nil {
+		t.Fatalf("SwitchBranch failed: %v", err)
+	}
+	assertPath(t, c, "Q1", "A1", "Q2", "A2")
+
+	if err := c.SwitchBranch(3, -1); err == nil {
+		t.Error("Expected an error when switching before the first branch")
+	}
+}
+
+func TestConversation_EditFollowsLatestBranch(t *testing.T) {
+	c := newLinearConversation()
+
+	// Edit the first question: the whole continuation lives on the old branch
+	if err := c.Rewind(0); err != nil {
+		t.Fatalf("Rewind failed: %v", err)
+	}
+	c.Append(Message{Role: "user", Content: "Q1 edited"})
+	c.Append(Message{Role: "assistant", Content: "A1 edited"})
+	assertPath(t, c, "Q1 edited", "A1 edited")
+
+	if err := c.SwitchBranch(0, -1); err != nil {
+		t.Fatalf("SwitchBranch failed: %v", err)
+	}
+	assertPath(t, c, "Q1", "A1", "Q2", "A2")
+
+	if err := c.SwitchBranch(0, 1); err != nil {
+		t.Fatalf("SwitchBranch failed: %v", err)
+	}
+	assertPath(t, c, "Q1 edited", "A1 edited")
+}
+
+func TestConversation_DeleteTurnPair(t *testing.T) {
+	c := newLinearConversation()
+
+	if err := c.Delete(0, 2); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	assertPath(t, c, "Q2", "A2")
+	if len(c.Nodes) != 2 {
+		t.Errorf("Expected 2 nodes left, got %d", len(c.Nodes))
+	}
+
+	if err := c.Delete(0, 2); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	assertPath(t, c)
+
+	if err := c.Delete(0, 1); err == nil {
+		t.Error("Expected an error deleting from an empty path")
+	}
+}
+
+func TestConversation_Sync(t *testing.T) {
+	c := newLinearConversation()
+
+	// Another command appended a turn
+	history := append(c.Path(), Message{Role: "user", Content: "Q3"}, Message{Role: "assistant", Content: "A3"})
+	c.Sync(history)
+	assertPath(t, c, "Q1", "A1", "Q2", "A2", "Q3", "A3")
+	if len(c.Nodes) != 6 {
+		t.Errorf("Expected 6 nodes, got %d", len(c.Nodes))
+	}
+
+	// Another instance rewrote the end of the conversation
+	c.Sync([]Message{{Role: "user", Content: "Q1"}, {Role: "assistant", Content: "Other"}})
+	assertPath(t, c, "Q1", "Other")
+	if position, total := c.Siblings(1); position != 2 || total != 2 {
+		t.Errorf("Expected the rewrite to be kept as a branch, got %d/%d", position, total)
+	}
+}
+
+func TestConversation_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "branches.yaml")
+
+	c := newLinearConversation()
+	if err := c.Rewind(1); err != nil {
+		t.Fatalf("Rewind failed: %v", err)
+	}
+	c.Append(Message{Role: "assistant", Content: "A1 bis"})
+
+	if err := c.Save(path); err != nil {
+		t.Fatalf("Failed to save conversation: %v", err)
+	}
+
+	loaded := &Conversation{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Failed to load conversation: %v", err)
+	}
+	assertPath(t, loaded, "Q1", "A1 bis")
+	if len(loaded.Nodes) != 5 {
+		t.Errorf("Expected 5 nodes, got %d", len(loaded.Nodes))
+	}
+}
+
+func TestConversation_SyncMetadata(t *testing.T) {
+	c := NewConversation([]Message{NewMessage("user", "Q1"), NewMessage("assistant", "A1")})
+
+	// The reply gained its audio and usage in another instance
+	history := c.Path()
+	history[1].Audio = "a1.mp3"
+	history[1].Usage = Usage{PromptTokens: 10, CompletionTokens: 4, TotalTokens: 14}
+	c.Sync(history)
+
+	if len(c.Nodes) != 2 {
+		t.Fatalf("Expected the metadata not to create a branch, got %d nodes", len(c.Nodes))
+	}
+	if reply := c.Path()[1]; reply.Audio != "a1.mp3" || reply.Usage.TotalTokens != 14 {
+		t.Errorf("Expected the newer metadata, got %+v", reply)
+	}
+
+	// Messages without IDs, from an old history, match by content
+	legacy := NewConversation([]Message{{Role: "user", Content: "Q1"}, {Role: "assistant", Content: "A1"}})
+	legacy.Sync([]Message{{Role: "user", Content: "Q1"}, {Role: "assistant", Content: "A1"}})
+	if len(legacy.Nodes) != 2 {
+		t.Errorf("Expected an unchanged legacy history to be kept, got %d nodes", len(legacy.Nodes))
+	}
+}
+
+func TestConversation_AppendKeepsMessageID(t *testing.T) {
+	c := &Conversation{}
+	message := NewMessage("user", "Q1")
+	if id := c.Append(message); id != message.ID {
+		t.Errorf("Expected the message ID %s, got %s", message.ID, id)
+	}
+
+	// An ID already in the tree is replaced
+	if id := c.Append(message); id == message.ID || id == "" {
+		t.Errorf("Expected a new ID for a duplicate, got %s", id)
+	}
+
+	reply := NewMessage("assistant", "A1")
+	c.Append(reply)
+	reply.Audio = "a1.mp3"
+	if !c.Update(reply) || c.Path()[2].Audio != "a1.mp3" {
+		t.Error("Expected Update to replace the message")
+	}
+	if c.Update(NewMessage("assistant", "unknown")) {
+		t.Error("Expected Update to report an unknown message")
+	}
+}
@@ -0,0 +1,20 @@
+package persona
+
+import "github.com/ctrl-vfr/persona/internal/openai"
+
+// ClientOptions returns the models and request settings of the persona, to
+// apply to its OpenAI client
+func (p *Persona) ClientOptions() openai.Options {
+	return openai.Options{
+		TranscriptionModel:  p.Models.Transcription,
+		SpeechModel:         p.Models.Speech,
+		ChatModel:           p.Models.Chat,
+		Temperature:         p.Generation.Temperature,
+		TopP:                p.Generation.TopP,
+		MaxTokens:           p.Generation.MaxTokens,
+		PresencePenalty:     p.Generation.PresencePenalty,
+		Speed:               p.Voice.Speed,
+		Language:            p.Transcription.Language,
+		TranscriptionPrompt: p.Transcription.Prompt,
+	}
+}
@@ -6,25 +6,112 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Persona struct {
+	Version int       `yaml:"version,omitempty" json:"version,omitempty"`
 	Name    string    `yaml:"name" json:"name"`
+	Extends string    `yaml:"extends,omitempty" json:"extends,omitempty"`
 	Voice   Voice     `yaml:"voice" json:"voice"`
 	Prompt  string    `yaml:"prompt" json:"prompt"`
 	History []Message `yaml:"history,omitempty" json:"history,omitempty"`
+	// Includes lists prompt fragment files added after the prompt
+	Includes []string `yaml:"includes,omitempty" json:"includes,omitempty"`
+	// Models override the models of the configuration for this persona
+	Models Models `yaml:"models,omitempty" json:"models,omitempty"`
+	// Generation tunes the answers of the chat model
+	Generation Generation `yaml:"generation,omitempty" json:"generation,omitempty"`
+	// Transcription helps transcribing the user's voice messages
+	Transcription Transcription `yaml:"transcription,omitempty" json:"transcription,omitempty"`
+	// Variables are substituted for {{name}} placeholders in the system prompt
+	Variables map[string]string `yaml:"-" json:"-"`
+
+	// Prompt parts resolved by Compose
+	inherited string
+	fragments []string
 }
 
 type Voice struct {
 	Name         string `yaml:"name" json:"name"`
 	Instructions string `yaml:"instructions" json:"instructions"`
+	// Speed of the speech, from 0.25 to 4 (1 when empty)
+	Speed float64 `yaml:"speed,omitempty" json:"speed,omitempty"`
 }
 
+// Models override the models of the configuration, empty ones are kept
+type Models struct {
+	Chat          string `yaml:"chat,omitempty" json:"chat,omitempty"`
+	Speech        string `yaml:"speech,omitempty" json:"speech,omitempty"`
+	Transcription string `yaml:"transcription,omitempty" json:"transcription,omitempty"`
+}
+
+// Generation holds the sampling parameters of the chat model. Unset ones
+// keep the defaults of the API.
+type Generation struct {
+	Temperature     *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	TopP            *float64 `yaml:"top_p,omitempty" json:"top_p,omitempty"`
+	MaxTokens       int      `yaml:"max_tokens,omitempty" json:"max_tokens,omitempty"`
+	PresencePenalty *float64 `yaml:"presence_penalty,omitempty" json:"presence_penalty,omitempty"`
+}
+
+// Transcription describes the user's voice messages to the transcription model
+type Transcription struct {
+	// Language is an ISO-639-1 code such as "fr"
+	Language string `yaml:"language,omitempty" json:"language,omitempty"`
+	// Prompt lists names and jargon the transcription should recognize
+	Prompt string `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+}
+
+// Input modalities of the user's messages
+const (
+	ModalityTyped = "typed"
+	ModalityVoice = "voice"
+)
+
+// Message is a message of a conversation. Histories written before the
+// metadata only have the role and content, the other fields are then empty.
 type Message struct {
+	// ID identifies the message in the conversation tree
+	ID      string `yaml:"id,omitempty" json:"id,omitempty"`
 	Role    string `yaml:"role" json:"role"`
 	Content string `yaml:"content" json:"content"`
+	// Name attributes the message to a speaker in group conversations
+	Name      string    `yaml:"name,omitempty" json:"name,omitempty"`
+	CreatedAt time.Time `yaml:"created_at,omitempty" json:"created_at,omitzero"`
+	// Modality is how the user gave the message, ModalityTyped or ModalityVoice
+	Modality string `yaml:"modality,omitempty" json:"modality,omitempty"`
+	// Model wrote the reply
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// Usage counts the tokens of the request that produced the reply
+	Usage Usage `yaml:"usage,omitempty" json:"usage,omitzero"`
+	// Latency is the time the API took to reply
+	Latency time.Duration `yaml:"latency,omitempty" json:"latency,omitempty"`
+	// Audio is the file keeping the voiced reply
+	Audio string `yaml:"audio,omitempty" json:"audio,omitempty"`
+}
+
+// Usage counts the tokens billed for a reply
+type Usage struct {
+	PromptTokens     int `yaml:"prompt_tokens,omitempty" json:"prompt_tokens,omitempty"`
+	CompletionTokens int `yaml:"completion_tokens,omitempty" json:"completion_tokens,omitempty"`
+	TotalTokens      int `yaml:"total_tokens,omitempty" json:"total_tokens,omitempty"`
+}
+
+// NewMessage returns a message with a new ID, created now
+func NewMessage(role, content string) Message {
+	return Message{ID: NewID(), Role: role, Content: content, CreatedAt: time.Now().Truncate(time.Millisecond)}
+}
+
+// Same reports whether two messages are the same message of a conversation:
+// same ID, or same role, speaker and content for messages without ID
+func (m Message) Same(other Message) bool {
+	if m.ID != "" && other.ID != "" {
+		return m.ID == other.ID
+	}
+	return m.Role == other.Role && m.Name == other.Name && m.Content == other.Content
 }
 
 func New(name string, voice Voice, prompt string) *Persona {
@@ -123,13 +210,17 @@ func (p *Persona) LoadPersona(path string) error {
 	return nil
 }
 
-func (p *Persona) GetMessages() []Message {
-	prompt := Message{
+// SystemMessage returns the system prompt message of the persona, with its
+// inherited prompt, fragments and variables resolved
+func (p *Persona) SystemMessage() Message {
+	return Message{
 		Role:    "system",
-		Content: p.Prompt,
+		Content: p.ExpandVariables(p.FullPrompt()),
 	}
+}
 
-	history := []Message{prompt}
+func (p *Persona) GetMessages() []Message {
+	history := []Message{p.SystemMessage()}
 	history = append(history, p.History...)
 	return history
 }
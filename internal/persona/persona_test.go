@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -171,6 +172,45 @@ func TestPersona_SaveAndLoadHistory(t *testing.T) {
 	}
 }
 
+func TestPersona_HistoryMetadata(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.yaml")
+
+	// Histories written before the metadata only have the role and content
+	legacy := "- role: user\n  content: Bonjour\n- role: assistant\n  content: Salut\n"
+	if err := os.WriteFile(historyPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := New("test", Voice{Name: "nova"}, "test prompt")
+	if err := p.LoadHistory(historyPath); err != nil {
+		t.Fatalf("Failed to load legacy history: %v", err)
+	}
+	if len(p.History) != 2 || p.History[1].Content != "Salut" || p.History[1].ID != "" {
+		t.Errorf("Unexpected legacy history: %+v", p.History)
+	}
+
+	reply := NewMessage("assistant", "Salut")
+	reply.Model = "gpt-4o-mini-2024-07-18"
+	reply.Usage = Usage{PromptTokens: 20, CompletionTokens: 3, TotalTokens: 23}
+	reply.Latency = 1200 * time.Millisecond
+	question := NewMessage("user", "Bonjour")
+	question.Modality = ModalityVoice
+	p.History = []Message{question, reply}
+	if err := p.SaveHistory(historyPath); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+
+	loaded := New("test", Voice{Name: "nova"}, "test prompt")
+	if err := loaded.LoadHistory(historyPath); err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if loaded.History[0].Modality != ModalityVoice || !loaded.History[0].CreatedAt.Equal(question.CreatedAt) {
+		t.Errorf("Expected the question metadata, got %+v", loaded.History[0])
+	}
+	if got := loaded.History[1]; got.ID != reply.ID || got.Model != reply.Model || got.Usage != reply.Usage || got.Latency != reply.Latency {
+		t.Errorf("Expected the reply metadata %+v, got %+v", reply, got)
+	}
+}
+
 func TestPersona_LoadInvalidHistory(t *testing.T) {
 	p := New("test", Voice{Name: "nova"}, "test prompt")
 
@@ -0,0 +1,73 @@
+// Package project finds the project file choosing the persona used in a directory tree.
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ctrl-vfr/persona/internal/schema"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of project files
+const FileName = ".persona.yaml"
+
+// Project is the persona setup of a directory tree
+type Project struct {
+	Version int `yaml:"version,omitempty"`
+	// Persona is opened by persona chat without arguments
+	Persona string `yaml:"persona,omitempty"`
+	// Session keeps the project's conversations apart from the others
+	Session string `yaml:"session,omitempty"`
+	// Context is added to the system prompt of the personas
+	Context string `yaml:"context,omitempty"`
+
+	// Path is the project file the project was loaded from
+	Path string `yaml:"-"`
+}
+
+// Find looks for a project file in dir and its parents. It returns nil
+// without error when there is none.
+func Find(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return Load(path)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads and validates a project file
+func Load(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Check(schema.ValidateProject(path, data)); err != nil {
+		return nil, fmt.Errorf("failed to load project file: %w", err)
+	}
+
+	p := &Project{Path: path}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to load project file: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,43 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "src", "internal")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("persona: sherlock\nsession: enquete\ncontext: Un projet Go.\n")
+	if err := os.WriteFile(filepath.Join(root, FileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Find(nested)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if p == nil {
+		t.Fatal("Expected the project file of a parent directory")
+	}
+	if p.Persona != "sherlock" || p.Session != "enquete" || p.Context != "Un projet Go." {
+		t.Errorf("Unexpected project: %+v", p)
+	}
+	if p.Path != filepath.Join(root, FileName) {
+		t.Errorf("Expected path %s, got %s", filepath.Join(root, FileName), p.Path)
+	}
+}
+
+func TestFind_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("persona: [a, b]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Find(dir); err == nil {
+		t.Error("Expected an error for an invalid project file")
+	}
+}
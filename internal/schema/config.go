@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	modelsSchema = &field{kind: kindMapping, fields: map[string]*field{
+		"transcription": {kind: kindString},
+		"speech":        {kind: kindString},
+		"chat":          {kind: kindString},
+	}}
+	audioSchema = &field{kind: kindMapping, fields: map[string]*field{
+		"input_device":      {kind: kindString},
+		"output_device":     {kind: kindString},
+		"silence_threshold": {kind: kindInt},
+		"silence_duration":  {kind: kindNumber},
+	}}
+	providerSchema = &field{kind: kindMapping, fields: map[string]*field{
+		"name":     {kind: kindString},
+		"base_url": {kind: kindString},
+	}}
+)
+
+var configSchema = &field{kind: kindMapping, fields: map[string]*field{
+	"version":         {kind: kindInt},
+	"default_persona": {kind: kindString},
+	"user": {kind: kindMapping, fields: map[string]*field{
+		"name": {kind: kindString},
+	}},
+	"models":   modelsSchema,
+	"audio":    audioSchema,
+	"provider": providerSchema,
+	"usage": {kind: kindMapping, fields: map[string]*field{
+		"prices": {kind: kindMapping, values: &field{kind: kindMapping, fields: map[string]*field{
+			"input":      {kind: kindNumber},
+			"output":     {kind: kindNumber},
+			"characters": {kind: kindNumber},
+			"minute":     {kind: kindNumber},
+		}}},
+		"monthly_budget": {kind: kindNumber},
+		"budget_action":  {kind: kindString},
+	}},
+	"cache": {kind: kindMapping, fields: map[string]*field{
+		"max_size": {kind: kindInt},
+	}},
+	"persona_paths": {kind: kindStrings},
+	"profile":       {kind: kindString},
+	"profiles": {kind: kindMapping, values: &field{kind: kindMapping, fields: map[string]*field{
+		"models":   modelsSchema,
+		"audio":    audioSchema,
+		"provider": providerSchema,
+	}}},
+}}
+
+// ValidateConfig checks the content of a config.yaml file
+func ValidateConfig(file string, data []byte) []Diagnostic {
+	v := &validator{file: file}
+	if !v.parse(data) {
+		return v.diagnostics
+	}
+	v.walk(v.root, configSchema, "")
+	if v.root.Kind != yaml.MappingNode {
+		return v.diagnostics
+	}
+	v.checkVersion()
+
+	if speech, node := v.scalar("models", "speech"); node != nil && speech != "" && openai.Voices(speech) == nil {
+		v.report(node, SeverityWarning, "models.speech", "unknown speech model %q, persona voices cannot be checked", speech)
+	}
+	if chat, node := v.scalar("models", "chat"); node != nil && chat != "" && !slices.Contains(openai.ChatModels(), chat) {
+		v.report(node, SeverityWarning, "models.chat", "unknown chat model %q", chat)
+	}
+	if transcription, node := v.scalar("models", "transcription"); node != nil && transcription != "" && !slices.Contains(openai.TranscriptionModels(), transcription) {
+		v.report(node, SeverityWarning, "models.transcription", "unknown transcription model %q", transcription)
+	}
+
+	if value, node := v.scalar("audio", "silence_threshold"); node != nil && node.Tag == "!!int" {
+		if threshold, _ := strconv.Atoi(value); threshold > 0 {
+			v.report(node, SeverityWarning, "audio.silence_threshold", "silence_threshold is in dB and is usually negative (e.g. -50)")
+		}
+	}
+	if value, node := v.scalar("audio", "silence_duration"); node != nil && (node.Tag == "!!int" || node.Tag == "!!float") {
+		if duration, _ := strconv.ParseFloat(value, 64); duration < 0 {
+			v.report(node, SeverityError, "audio.silence_duration", "silence_duration must not be negative")
+		}
+	}
+
+	if value, node := v.scalar("usage", "monthly_budget"); node != nil && (node.Tag == "!!int" || node.Tag == "!!float") {
+		if budget, _ := strconv.ParseFloat(value, 64); budget < 0 {
+			v.report(node, SeverityError, "usage.monthly_budget", "monthly_budget must not be negative")
+		}
+	}
+	if action, node := v.scalar("usage", "budget_action"); node != nil && action != "" && action != "warn" && action != "block" {
+		v.report(node, SeverityError, "usage.budget_action", "unknown budget_action %q (actions: warn, block)", action)
+	}
+
+	if profile, node := v.scalar("profile"); node != nil && profile != "" {
+		profiles := v.lookup("profiles")
+		if profiles == nil || profiles.Kind != yaml.MappingNode || !hasKey(profiles, profile) {
+			v.report(node, SeverityError, "profile", "profile %q is not defined in profiles", profile)
+		}
+	}
+
+	return v.sorted()
+}
+
+func hasKey(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LongPrompt is the prompt length, in characters, above which a warning is reported
+const LongPrompt = 6000
+
+var personaSchema = &field{kind: kindMapping, fields: map[string]*field{
+	"version": {kind: kindInt},
+	"name":    {kind: kindString},
+	"extends": {kind: kindString},
+	"voice": {kind: kindMapping, fields: map[string]*field{
+		"name":         {kind: kindString},
+		"instructions": {kind: kindString},
+		"speed":        {kind: kindNumber},
+	}},
+	"prompt":   {kind: kindString},
+	"includes": {kind: kindStrings},
+	"history":  {kind: kindAny},
+	"models": {kind: kindMapping, fields: map[string]*field{
+		"chat":          {kind: kindString},
+		"speech":        {kind: kindString},
+		"transcription": {kind: kindString},
+	}},
+	"generation": {kind: kindMapping, fields: map[string]*field{
+		"temperature":      {kind: kindNumber},
+		"top_p":            {kind: kindNumber},
+		"max_tokens":       {kind: kindInt},
+		"presence_penalty": {kind: kindNumber},
+	}},
+	"transcription": {kind: kindMapping, fields: map[string]*field{
+		"language": {kind: kindString},
+		"prompt":   {kind: kindString},
+	}},
+}}
+
+// PersonaOptions gives the context needed to validate a persona
+type PersonaOptions struct {
+	// Directory is the name of the directory holding the persona
+	Directory string
+	// SpeechModel is the configured speech model, used to check the voice
+	// unless the persona overrides it
+	SpeechModel string
+}
+
+// ValidatePersona checks the content of a persona.yaml file
+func ValidatePersona(file string, data []byte, opts PersonaOptions) []Diagnostic {
+	v := &validator{file: file}
+	if !v.parse(data) {
+		return v.diagnostics
+	}
+	v.walk(v.root, personaSchema, "")
+	if v.root.Kind != yaml.MappingNode {
+		return v.diagnostics
+	}
+	v.checkVersion()
+
+	extends, _ := v.scalar("extends")
+
+	name, nameNode := v.scalar("name")
+	switch {
+	case nameNode == nil:
+		v.report(v.root, SeverityError, "name", "name is required")
+	case opts.Directory != "" && name != opts.Directory:
+		// Personas created by older versions all say "marceline": they load
+		// under their directory name
+		v.report(nameNode, SeverityWarning, "name", "name %q does not match the persona directory %q, which is used instead", name, opts.Directory)
+	}
+
+	speechModel := opts.SpeechModel
+	if model, node := v.scalar("models", "speech"); node != nil && model != "" {
+		speechModel = model
+		if openai.Voices(model) == nil {
+			v.report(node, SeverityWarning, "models.speech", "unknown speech model %q, the voice cannot be checked", model)
+		}
+	}
+	if chat, node := v.scalar("models", "chat"); node != nil && chat != "" && !slices.Contains(openai.ChatModels(), chat) {
+		v.report(node, SeverityWarning, "models.chat", "unknown chat model %q", chat)
+	}
+	if transcription, node := v.scalar("models", "transcription"); node != nil && transcription != "" && !slices.Contains(openai.TranscriptionModels(), transcription) {
+		v.report(node, SeverityWarning, "models.transcription", "unknown transcription model %q", transcription)
+	}
+
+	v.checkRange(0.25, 4, "voice", "speed")
+	v.checkRange(0, 2, "generation", "temperature")
+	v.checkRange(0, 1, "generation", "top_p")
+	v.checkRange(1, math.Inf(1), "generation", "max_tokens")
+	v.checkRange(-2, 2, "generation", "presence_penalty")
+
+	voice, voiceNode := v.scalar("voice", "name")
+	if voiceNode == nil && extends == "" {
+		v.report(v.keyNode("voice"), SeverityError, "voice.name", "voice.name is required")
+	}
+	if voiceNode != nil {
+		if known := openai.Voices(speechModel); known != nil && !slices.Contains(known, voice) {
+			message := fmt.Sprintf("unknown voice %q for speech model %s", voice, speechModel)
+			if suggestion := closest(voice, known); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			} else {
+				message += fmt.Sprintf(" (known voices: %s)", strings.Join(known, ", "))
+			}
+			v.report(voiceNode, SeverityError, "voice.name", "%s", message)
+		}
+	}
+
+	prompt, promptNode := v.scalar("prompt")
+	switch {
+	case strings.TrimSpace(prompt) == "" && extends == "":
+		v.report(v.keyNode("prompt"), SeverityError, "prompt", "prompt must not be empty")
+	case utf8.RuneCountInString(prompt) > LongPrompt:
+		v.report(promptNode, SeverityWarning, "prompt", "prompt is %d characters long (over %d), which makes every request slower and more expensive", utf8.RuneCountInString(prompt), LongPrompt)
+	}
+
+	return v.sorted()
+}
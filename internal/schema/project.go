@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+var projectSchema = &field{kind: kindMapping, fields: map[string]*field{
+	"version": {kind: kindInt},
+	"persona": {kind: kindString},
+	"session": {kind: kindString},
+	"context": {kind: kindString},
+}}
+
+var sessionName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateProject checks the content of a project .persona.yaml file
+func ValidateProject(file string, data []byte) []Diagnostic {
+	v := &validator{file: file}
+	if !v.parse(data) {
+		return v.diagnostics
+	}
+	v.walk(v.root, projectSchema, "")
+	if v.root.Kind != yaml.MappingNode {
+		return v.diagnostics
+	}
+	v.checkVersion()
+
+	if session, node := v.scalar("session"); node != nil && session != "" && !sessionName.MatchString(session) {
+		v.report(node, SeverityError, "session", "invalid session name %q: use letters, digits, '-' and '_'", session)
+	}
+
+	return v.sorted()
+}
@@ -0,0 +1,355 @@
+// Package schema validates persona and configuration files and reports
+// diagnostics with their position in the file.
+package schema
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the latest schema version of persona.yaml and config.yaml.
+// Files without a version field are read as version 1.
+const Version = 1
+
+// Severity tells whether a diagnostic prevents a file from being used
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in a file
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Field    string   `json:"field,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// Error is returned when a file has error diagnostics
+type Error struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, 0, len(e.Diagnostics))
+	for _, diagnostic := range e.Diagnostics {
+		lines = append(lines, diagnostic.String())
+	}
+	return "invalid file:\n" + strings.Join(lines, "\n")
+}
+
+// Check returns an *Error holding the error diagnostics, or nil if there are none
+func Check(diagnostics []Diagnostic) error {
+	var errors []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			errors = append(errors, diagnostic)
+		}
+	}
+	if len(errors) == 0 {
+		return nil
+	}
+	return &Error{Diagnostics: errors}
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindNumber
+	kindMapping
+	kindStrings
+	kindAny
+)
+
+// field describes the expected shape of a YAML value
+type field struct {
+	kind   kind
+	fields map[string]*field
+	// values is the shape of every value of a mapping with arbitrary keys
+	values *field
+}
+
+func (k kind) String() string {
+	switch k {
+	case kindString:
+		return "a string"
+	case kindInt:
+		return "an integer"
+	case kindNumber:
+		return "a number"
+	case kindMapping:
+		return "a mapping"
+	case kindStrings:
+		return "a list of strings"
+	}
+	return "a value"
+}
+
+// validator collects the diagnostics of one file
+type validator struct {
+	file        string
+	root        *yaml.Node
+	diagnostics []Diagnostic
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// parse reads the document and reports syntax errors. It returns false when
+// the file cannot be validated further.
+func (v *validator) parse(data []byte) bool {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		line := 1
+		if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		v.diagnostics = append(v.diagnostics, Diagnostic{
+			File:     v.file,
+			Line:     line,
+			Column:   1,
+			Severity: SeverityError,
+			Message:  strings.TrimPrefix(err.Error(), "yaml: "),
+		})
+		return false
+	}
+
+	if len(document.Content) == 0 {
+		v.report(&document, SeverityError, "", "file is empty")
+		return false
+	}
+	v.root = document.Content[0]
+	return true
+}
+
+func (v *validator) report(node *yaml.Node, severity Severity, path, format string, args ...any) {
+	line, column := 1, 1
+	if node != nil && node.Line > 0 {
+		line, column = node.Line, node.Column
+	}
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		File:     v.file,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Field:    path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// sorted returns the diagnostics ordered by position in the file
+func (v *validator) sorted() []Diagnostic {
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		a, b := v.diagnostics[i], v.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.diagnostics
+}
+
+// walk checks node against its expected shape, reporting unknown keys and
+// values of the wrong type
+func (v *validator) walk(node *yaml.Node, expected *field, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+
+	switch expected.kind {
+	case kindString:
+		if node.Kind != yaml.ScalarNode {
+			v.report(node, SeverityError, path, "%s must be %s", path, expected.kind)
+		}
+	case kindInt:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			v.report(node, SeverityError, path, "%s must be %s", path, expected.kind)
+		}
+	case kindNumber:
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			v.report(node, SeverityError, path, "%s must be %s", path, expected.kind)
+		}
+	case kindStrings:
+		if node.Kind != yaml.SequenceNode {
+			v.report(node, SeverityError, path, "%s must be %s", path, expected.kind)
+			return
+		}
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				v.report(item, SeverityError, path, "%s must be %s", path, expected.kind)
+			}
+		}
+	case kindMapping:
+		if node.Kind != yaml.MappingNode {
+			v.report(node, SeverityError, path, "%s must be %s", orRoot(path), expected.kind)
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child, ok := expected.fields[key.Value]
+			if expected.values != nil {
+				child, ok = expected.values, true
+			}
+			if !ok {
+				message := fmt.Sprintf("unknown field %q", join(path, key.Value))
+				if suggestion := closest(key.Value, keys(expected.fields)); suggestion != "" {
+					message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				v.report(key, SeverityError, join(path, key.Value), "%s", message)
+				continue
+			}
+			v.walk(value, child, join(path, key.Value))
+		}
+	}
+}
+
+// lookup returns the value node at path, or nil when it is absent
+func (v *validator) lookup(path ...string) *yaml.Node {
+	node := v.root
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// keyNode returns the key node of the last element of path, used to point
+// at a field rather than at its value
+func (v *validator) keyNode(path ...string) *yaml.Node {
+	parent := v.root
+	if len(path) > 1 {
+		parent = v.lookup(path[:len(path)-1]...)
+	}
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return v.root
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			return parent.Content[i]
+		}
+	}
+	return parent
+}
+
+// scalar returns the value at path when it is a scalar
+func (v *validator) scalar(path ...string) (string, *yaml.Node) {
+	node := v.lookup(path...)
+	if node == nil || node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+		return "", nil
+	}
+	return node.Value, node
+}
+
+// checkRange reports a number outside of [min, max], where max may be +Inf.
+// Values of the wrong type are left to walk.
+func (v *validator) checkRange(min, max float64, path ...string) {
+	value, node := v.scalar(path...)
+	if node == nil || (node.Tag != "!!int" && node.Tag != "!!float") {
+		return
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < min || number > max {
+		name := strings.Join(path, ".")
+		if math.IsInf(max, 1) {
+			v.report(node, SeverityError, name, "%s must be at least %g", name, min)
+			return
+		}
+		v.report(node, SeverityError, name, "%s must be between %g and %g", name, min, max)
+	}
+}
+
+func (v *validator) checkVersion() {
+	value, node := v.scalar("version")
+	if node == nil || node.Tag != "!!int" {
+		return
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		v.report(node, SeverityError, "version", "version must be a positive integer")
+		return
+	}
+	if version > Version {
+		v.report(node, SeverityError, "version", "schema version %d is not supported (latest is %d), upgrade persona", version, Version)
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func orRoot(path string) string {
+	if path == "" {
+		return "the document"
+	}
+	return path
+}
+
+func keys(fields map[string]*field) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// closest returns the candidate nearest to value, if it is close enough to
+// be a typo
+func closest(value string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if distance := levenshtein(strings.ToLower(value), candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
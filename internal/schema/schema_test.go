@@ -0,0 +1,211 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func findDiagnostic(diagnostics []Diagnostic, field string) *Diagnostic {
+	for i := range diagnostics {
+		if diagnostics[i].Field == field {
+			return &diagnostics[i]
+		}
+	}
+	return nil
+}
+
+func TestValidatePersona_Valid(t *testing.T) {
+	data := []byte("version: 1\nname: freud\nvoice:\n  name: ballad\n  instructions: Grave\nprompt: Tu es Freud.\n")
+
+	diagnostics := ValidatePersona("persona.yaml", data, PersonaOptions{Directory: "freud", SpeechModel: "gpt-4o-mini-tts"})
+	if len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestValidatePersona_Errors(t *testing.T) {
+	data := []byte(`name: marceline
+voise:
+  name: nova
+prompt: ""
+`)
+
+	diagnostics := ValidatePersona("persona.yaml", data, PersonaOptions{Directory: "persona"})
+	if Check(diagnostics) == nil {
+		t.Fatal("Expected errors")
+	}
+
+	typo := findDiagnostic(diagnostics, "voise")
+	if typo == nil {
+		t.Fatalf("Expected an unknown field diagnostic, got %v", diagnostics)
+	}
+	if typo.Line != 2 || typo.Column != 1 || !strings.Contains(typo.Message, `did you mean "voice"`) {
+		t.Errorf("Unexpected typo diagnostic: %+v", typo)
+	}
+
+	name := findDiagnostic(diagnostics, "name")
+	if name == nil || name.Line != 1 || name.Column != 7 || name.Severity != SeverityWarning {
+		t.Errorf("Expected a name mismatch warning at 1:7, got %+v", name)
+	}
+
+	if findDiagnostic(diagnostics, "voice.name") == nil {
+		t.Error("Expected a missing voice diagnostic")
+	}
+	if prompt := findDiagnostic(diagnostics, "prompt"); prompt == nil || prompt.Line != 4 {
+		t.Errorf("Expected an empty prompt diagnostic on line 4, got %+v", prompt)
+	}
+}
+
+func TestValidatePersona_UnknownVoice(t *testing.T) {
+	data := []byte("name: merlin\nvoice:\n  name: ballad2\nprompt: Tu es Merlin.\n")
+
+	diagnostics := ValidatePersona("persona.yaml", data, PersonaOptions{Directory: "merlin", SpeechModel: "gpt-4o-mini-tts"})
+	voice := findDiagnostic(diagnostics, "voice.name")
+	if voice == nil || voice.Line != 3 || voice.Column != 9 {
+		t.Fatalf("Expected an unknown voice at 3:9, got %v", diagnostics)
+	}
+	if !strings.Contains(voice.Message, `did you mean "ballad"`) {
+		t.Errorf("Expected a suggestion, got %q", voice.Message)
+	}
+
+	// The voice cannot be checked against an unknown model
+	diagnostics = ValidatePersona("persona.yaml", data, PersonaOptions{Directory: "merlin", SpeechModel: "custom-tts"})
+	if len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics for an unknown model, got %v", diagnostics)
+	}
+}
+
+func TestValidatePersona_ExtendsAndWarnings(t *testing.T) {
+	data := []byte("name: child\nextends: base\nincludes: team.md\n")
+
+	diagnostics := ValidatePersona("persona.yaml", data, PersonaOptions{Directory: "child"})
+	if len(diagnostics) != 1 || diagnostics[0].Field != "includes" {
+		t.Errorf("Expected only the includes type error, got %v", diagnostics)
+	}
+
+	long := "name: bavard\nvoice:\n  name: nova\nprompt: " + strings.Repeat("a", LongPrompt+1) + "\n"
+	diagnostics = ValidatePersona("persona.yaml", []byte(long), PersonaOptions{Directory: "bavard"})
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SeverityWarning {
+		t.Errorf("Expected a single long prompt warning, got %v", diagnostics)
+	}
+	if Check(diagnostics) != nil {
+		t.Error("Warnings should not make the check fail")
+	}
+}
+
+func TestValidatePersona_SyntaxAndVersion(t *testing.T) {
+	diagnostics := ValidatePersona("persona.yaml", []byte("name: a\nvoice: [\n"), PersonaOptions{})
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SeverityError || diagnostics[0].Line < 2 {
+		t.Errorf("Expected a syntax error with its line, got %v", diagnostics)
+	}
+
+	diagnostics = ValidatePersona("persona.yaml", []byte("version: 2\nname: a\nvoice:\n  name: nova\nprompt: p\n"), PersonaOptions{})
+	if version := findDiagnostic(diagnostics, "version"); version == nil || !strings.Contains(version.Message, "not supported") {
+		t.Errorf("Expected an unsupported version error, got %v", diagnostics)
+	}
+}
+
+func TestValidatePersona_Settings(t *testing.T) {
+	data := []byte(`name: coach
+voice:
+  name: ballad
+  speed: 5
+prompt: Sois bref.
+models:
+  speech: tts-1
+generation:
+  temperature: 0.3
+  top_p: 1.5
+  max_tokens: 0
+transcription:
+  language: fr
+`)
+
+	diagnostics := ValidatePersona("persona.yaml", data, PersonaOptions{SpeechModel: "gpt-4o-mini-tts"})
+	if voice := findDiagnostic(diagnostics, "voice.name"); voice == nil || !strings.Contains(voice.Message, "tts-1") {
+		t.Errorf("Expected the voice to be checked against the persona's speech model, got %v", diagnostics)
+	}
+	for _, path := range []string{"voice.speed", "generation.top_p", "generation.max_tokens"} {
+		if findDiagnostic(diagnostics, path) == nil {
+			t.Errorf("Expected a range error on %s, got %v", path, diagnostics)
+		}
+	}
+	if temperature := findDiagnostic(diagnostics, "generation.temperature"); temperature != nil {
+		t.Errorf("Expected a valid temperature, got %v", temperature)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	data := []byte(`models:
+  speech: gpt-4o-mini-tts
+audio:
+  silence_threshold: high
+  silence_duraton: 2
+`)
+
+	diagnostics := ValidateConfig("config.yaml", data)
+	threshold := findDiagnostic(diagnostics, "audio.silence_threshold")
+	if threshold == nil || threshold.Line != 4 || !strings.Contains(threshold.Message, "integer") {
+		t.Errorf("Expected a type error on line 4, got %v", diagnostics)
+	}
+	typo := findDiagnostic(diagnostics, "audio.silence_duraton")
+	if typo == nil || !strings.Contains(typo.Message, `did you mean "silence_duration"`) {
+		t.Errorf("Expected a typo suggestion, got %v", diagnostics)
+	}
+
+	err := Check(diagnostics)
+	if err == nil || !strings.Contains(err.Error(), "config.yaml:4:22: error") {
+		t.Errorf("Expected the error to list positions, got %v", err)
+	}
+}
+
+func TestValidateConfig_Numbers(t *testing.T) {
+	data := []byte("models:\n  chat: gpt-9\naudio:\n  silence_duration: 1.5\n")
+
+	diagnostics := ValidateConfig("config.yaml", data)
+	if duration := findDiagnostic(diagnostics, "audio.silence_duration"); duration != nil {
+		t.Errorf("Expected a fractional silence_duration to be valid, got %v", duration)
+	}
+	if chat := findDiagnostic(diagnostics, "models.chat"); chat == nil || chat.Severity != SeverityWarning {
+		t.Errorf("Expected an unknown chat model warning, got %v", diagnostics)
+	}
+
+	diagnostics = ValidateConfig("config.yaml", []byte("audio:\n  silence_duration: -0.5\n"))
+	if duration := findDiagnostic(diagnostics, "audio.silence_duration"); duration == nil || duration.Severity != SeverityError {
+		t.Errorf("Expected a negative silence_duration error, got %v", diagnostics)
+	}
+}
+
+func TestValidateConfig_Profiles(t *testing.T) {
+	data := []byte(`profile: streaming
+profiles:
+  desk:
+    models:
+      chat: gpt-4.1
+    audoi:
+      input_device: headset
+`)
+
+	diagnostics := ValidateConfig("config.yaml", data)
+	if profile := findDiagnostic(diagnostics, "profile"); profile == nil || !strings.Contains(profile.Message, "not defined") {
+		t.Errorf("Expected an undefined profile error, got %v", diagnostics)
+	}
+	if typo := findDiagnostic(diagnostics, "profiles.desk.audoi"); typo == nil || !strings.Contains(typo.Message, `did you mean "audio"`) {
+		t.Errorf("Expected a typo suggestion inside the profile, got %v", diagnostics)
+	}
+}
+
+func TestValidateProject(t *testing.T) {
+	valid := []byte("persona: sherlock\nsession: mon-projet\ncontext: Un projet Go.\n")
+	if diagnostics := ValidateProject(".persona.yaml", valid); len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diagnostics)
+	}
+
+	diagnostics := ValidateProject(".persona.yaml", []byte("persona: sherlock\nsession: ../autre\ncontxt: oups\n"))
+	if findDiagnostic(diagnostics, "session") == nil {
+		t.Errorf("Expected an invalid session error, got %v", diagnostics)
+	}
+	if typo := findDiagnostic(diagnostics, "contxt"); typo == nil || !strings.Contains(typo.Message, `did you mean "context"`) {
+		t.Errorf("Expected a typo suggestion, got %v", diagnostics)
+	}
+}
@@ -0,0 +1,143 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetWidth is the number of characters of the search snippets
+const snippetWidth = 100
+
+// Span locates a match as byte offsets in a text
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// accents maps the accented letters to their base letter
+var accents = map[rune]rune{
+	'à': 'a', 'â': 'a', 'ä': 'a', 'á': 'a', 'ã': 'a', 'å': 'a',
+	'ç': 'c',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'î': 'i', 'ï': 'i', 'í': 'i', 'ì': 'i',
+	'ô': 'o', 'ö': 'o', 'ó': 'o', 'ò': 'o', 'õ': 'o',
+	'ù': 'u', 'û': 'u', 'ü': 'u', 'ú': 'u',
+	'ÿ': 'y', 'ý': 'y',
+	'ñ': 'n',
+}
+
+// fold lowers a letter and removes its accent, so that "Été" matches "ete"
+func fold(r rune) rune {
+	r = unicode.ToLower(r)
+	if base, ok := accents[r]; ok {
+		return base
+	}
+	return r
+}
+
+// Match returns the spans of every occurrence of the terms in text, sorted,
+// or nil unless all the terms appear. Case and accents are ignored.
+func Match(text string, terms []string) []Span {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	// Fold the text rune by rune, keeping the byte offset of each rune
+	var folded []rune
+	var offsets []int
+	for i, r := range text {
+		folded = append(folded, fold(r))
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+
+	var spans []Span
+	for _, term := range terms {
+		pattern := []rune(strings.Map(fold, term))
+		if len(pattern) == 0 {
+			continue
+		}
+		found := false
+		for i := 0; i+len(pattern) <= len(folded); i++ {
+			if slices.Equal(folded[i:i+len(pattern)], pattern) {
+				spans = append(spans, Span{Start: offsets[i], End: offsets[i+len(pattern)]})
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	slices.SortFunc(spans, func(a, b Span) int { return a.Start - b.Start })
+	return spans
+}
+
+// Snippet returns about width characters of text around the first span, on
+// a single line, with the spans moved into the snippet
+func Snippet(text string, spans []Span, width int) (string, []Span) {
+	start, end := 0, len(text)
+	if len(spans) > 0 && utf8.RuneCountInString(text) > width {
+		// Start a third of the width before the first match
+		start = spans[0].Start
+		for n := 0; start > 0 && n < width/3; n++ {
+			_, size := utf8.DecodeLastRuneInString(text[:start])
+			start -= size
+		}
+		end = start
+		for n := 0; end < len(text) && n < width; n++ {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+		}
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(text) {
+		suffix = "…"
+	}
+
+	var moved []Span
+	for _, span := range spans {
+		if span.Start >= start && span.End <= end {
+			moved = append(moved, Span{
+				Start: span.Start - start + len(prefix),
+				End:   span.End - start + len(prefix),
+			})
+		}
+	}
+
+	// Line breaks are replaced one for one, keeping the offsets
+	snippet := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' {
+			return ' '
+		}
+		return r
+	}, text[start:end])
+	return prefix + snippet + suffix, moved
+}
+
+// Highlight wraps the spans of text with the result of mark
+func Highlight(text string, spans []Span, mark func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span.Start < last {
+			// Overlapping matches, such as "kube" and "kubernetes"
+			if span.End > last {
+				b.WriteString(mark(text[last:span.End]))
+				last = span.End
+			}
+			continue
+		}
+		b.WriteString(text[last:span.Start])
+		b.WriteString(mark(text[span.Start:span.End]))
+		last = span.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,247 @@
+// Package search finds messages in the persona histories, through an index
+// kept up to date with the history files.
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+)
+
+// indexVersion is bumped when the index format changes, to rebuild it
+const indexVersion = 1
+
+// GroupPersona stands for the persona of the group conversations, whose
+// sources are named by their group session. It is not a valid persona name.
+const GroupPersona = "@group"
+
+// Source is a history file to index
+type Source struct {
+	Persona string
+	Session string
+	Path    string
+}
+
+// Entry is an indexed message
+type Entry struct {
+	Persona string `json:"persona"`
+	Session string `json:"session,omitempty"`
+	// Index is the position of the message in its history
+	Index   int    `json:"index"`
+	ID      string `json:"id,omitempty"`
+	Role    string `json:"role"`
+	Name    string `json:"name,omitempty"`
+	Content string `json:"content"`
+	// Time is when the message was created, or when its history file was
+	// written for messages without date
+	Time time.Time `json:"time"`
+}
+
+// file is the indexed content of a history file, refreshed when the file
+// changes
+type file struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Index holds the messages of the history files
+type Index struct {
+	Version int              `json:"version"`
+	Files   map[string]*file `json:"files"`
+	path    string
+}
+
+// Open loads the index saved at path. A missing or outdated index is empty
+// and filled by Update.
+func Open(path string) (*Index, error) {
+	index := &Index{Version: indexVersion, Files: map[string]*file{}, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	var saved Index
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Version != indexVersion || saved.Files == nil {
+		// The index is a cache, rebuild it rather than failing
+		return index, nil
+	}
+	saved.path = path
+	return &saved, nil
+}
+
+// Update indexes the sources whose file changed since the last update and
+// forgets the files that are not sources anymore. Unreadable histories are
+// left out, lint reports them. It reports whether the index changed.
+func (ix *Index) Update(sources []Source) bool {
+	changed := false
+	seen := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		info, err := os.Stat(source.Path)
+		if err != nil {
+			continue
+		}
+		seen[source.Path] = true
+		if indexed, ok := ix.Files[source.Path]; ok && indexed.ModTime.Equal(info.ModTime()) && indexed.Size == info.Size() {
+			continue
+		}
+
+		p := &persona.Persona{}
+		if err := p.LoadHistory(source.Path); err != nil {
+			delete(seen, source.Path)
+			continue
+		}
+		indexed := &file{ModTime: info.ModTime(), Size: info.Size(), Entries: []Entry{}}
+		for i, message := range p.History {
+			if message.Role != "user" && message.Role != "assistant" {
+				continue
+			}
+			entry := Entry{
+				Persona: source.Persona,
+				Session: source.Session,
+				Index:   i,
+				ID:      message.ID,
+				Role:    message.Role,
+				Name:    message.Name,
+				Content: message.Content,
+				Time:    message.CreatedAt,
+			}
+			if entry.Time.IsZero() {
+				entry.Time = info.ModTime()
+			}
+			indexed.Entries = append(indexed.Entries, entry)
+		}
+		ix.Files[source.Path] = indexed
+		changed = true
+	}
+
+	for path := range ix.Files {
+		if !seen[path] {
+			delete(ix.Files, path)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Save writes the index, through a temporary file so that a concurrent
+// search never reads half of it
+func (ix *Index) Save() error {
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	if err := os.Rename(tmp, ix.path); err != nil {
+		return fmt.Errorf("failed to save search index: %w", err)
+	}
+	return nil
+}
+
+// Query filters the messages of a search
+type Query struct {
+	// Terms must all appear in a message, case and accents ignored
+	Terms   []string
+	Persona string
+	Session string
+	Role    string
+	// Since keeps the messages created after it, when set
+	Since time.Time
+	// Limit caps the number of results, when positive
+	Limit int
+}
+
+// Result is a message matching a query
+type Result struct {
+	Entry
+	// Snippet is the part of the content around the first match
+	Snippet string `json:"snippet"`
+	// Highlights locate the terms in Snippet
+	Highlights []Span `json:"highlights"`
+}
+
+// Search returns the messages matching q, the most recent first
+func (ix *Index) Search(q Query) []Result {
+	var results []Result
+	for _, indexed := range ix.Files {
+		for _, entry := range indexed.Entries {
+			if (q.Persona != "" && entry.Persona != q.Persona) ||
+				(q.Session != "" && entry.Session != q.Session) ||
+				(q.Role != "" && entry.Role != q.Role) ||
+				(!q.Since.IsZero() && entry.Time.Before(q.Since)) {
+				continue
+			}
+			spans := Match(entry.Content, q.Terms)
+			if spans == nil {
+				continue
+			}
+			snippet, highlights := Snippet(entry.Content, spans, snippetWidth)
+			results = append(results, Result{Entry: entry, Snippet: snippet, Highlights: highlights})
+		}
+	}
+
+	slices.SortFunc(results, func(a, b Result) int {
+		if c := b.Time.Compare(a.Time); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Persona, b.Persona); c != 0 {
+			return c
+		}
+		return b.Index - a.Index
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// ParseTerms splits a query in terms, "quoted words" making a single term
+func ParseTerms(query string) []string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			if part = strings.TrimSpace(part); part != "" {
+				terms = append(terms, part)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	return terms
+}
+
+// ParseSince converts a duration such as 7d, 2w or 12h, or a date such as
+// 2025-09-01, into the time it designates
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	if unit, ok := units[value[max(len(value)-1, 0):]]; ok {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration such as 7d, 2w or 12h, or a date such as 2025-09-01", value)
+}
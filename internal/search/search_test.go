@@ -0,0 +1,201 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/persona"
+)
+
+func TestMatch(t *testing.T) {
+	text := "L'Été à Montréal, puis l'été à Paris"
+
+	spans := Match(text, []string{"ete"})
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 matches ignoring case and accents, got %v", spans)
+	}
+	if got := text[spans[0].Start:spans[0].End]; got != "Été" {
+		t.Errorf("Expected the span to cover %q, got %q", "Été", got)
+	}
+
+	if Match(text, []string{"été", "berlin"}) != nil {
+		t.Error("Expected no match unless all the terms appear")
+	}
+	if spans := Match(text, []string{"paris", "MONTREAL"}); len(spans) != 2 || spans[0].Start > spans[1].Start {
+		t.Errorf("Expected sorted spans for both terms, got %v", spans)
+	}
+	if Match(text, nil) != nil {
+		t.Error("Expected no match without terms")
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("avant ", 30) + "kubernetes\n" + strings.Repeat("après ", 30)
+	spans := Match(text, []string{"kubernetes"})
+
+	snippet, highlights := Snippet(text, spans, 40)
+	if !strings.HasPrefix(snippet, "…") || !strings.HasSuffix(snippet, "…") {
+		t.Errorf("Expected ellipses around a cut snippet, got %q", snippet)
+	}
+	if strings.Contains(snippet, "\n") {
+		t.Errorf("Expected a single line snippet, got %q", snippet)
+	}
+	if len(highlights) != 1 || snippet[highlights[0].Start:highlights[0].End] != "kubernetes" {
+		t.Fatalf("Expected the highlight to follow the match, got %v in %q", highlights, snippet)
+	}
+
+	marked := Highlight(snippet, highlights, func(s string) string { return "[" + s + "]" })
+	if !strings.Contains(marked, "[kubernetes]") {
+		t.Errorf("Expected the match to be marked, got %q", marked)
+	}
+
+	short, _ := Snippet("court", Match("court", []string{"court"}), 40)
+	if short != "court" {
+		t.Errorf("Expected a short text to be kept whole, got %q", short)
+	}
+}
+
+func TestParseTerms(t *testing.T) {
+	got := ParseTerms(`deploy "pod security" kube`)
+	want := []string{"deploy", "pod security", "kube"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"7d":         now.AddDate(0, 0, -7),
+		"2w":         now.AddDate(0, 0, -14),
+		"12h":        now.Add(-12 * time.Hour),
+		"2025-09-01": time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for value, want := range tests {
+		got, err := ParseSince(value, now)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("Expected %v for %q, got %v", want, value, got)
+		}
+	}
+
+	for _, value := range []string{"", "d", "-3d", "hier"} {
+		if _, err := ParseSince(value, now); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func writeHistory(t *testing.T, path string, messages ...persona.Message) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	p := &persona.Persona{History: messages}
+	if err := p.SaveHistory(path); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+}
+
+func TestIndex_UpdateAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	recent := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	sources := []Source{
+		{Persona: "devops", Path: filepath.Join(dir, "devops.yaml")},
+		{Persona: "devops", Session: "k8s", Path: filepath.Join(dir, "k8s.yaml")},
+		{Persona: "coach", Path: filepath.Join(dir, "coach.yaml")},
+	}
+	writeHistory(t, sources[0].Path,
+		persona.Message{Role: "system", Content: "Tu parles de Kubernetes"},
+		persona.Message{Role: "user", Content: "Comment déployer sur Kubernetes ?", CreatedAt: old},
+		persona.Message{Role: "assistant", Content: "Avec kubectl apply sur Kubernetes.", CreatedAt: old.Add(time.Second)},
+	)
+	writeHistory(t, sources[1].Path,
+		persona.Message{Role: "user", Content: "kubernetes pod security", CreatedAt: recent},
+	)
+	writeHistory(t, sources[2].Path,
+		persona.Message{Role: "user", Content: "Je cours le matin", CreatedAt: recent},
+	)
+
+	indexPath := filepath.Join(dir, "cache", "search.json")
+	index, err := Open(indexPath)
+	if err != nil {
+		t.Fatalf("Failed to open index: %v", err)
+	}
+	if !index.Update(sources) {
+		t.Error("Expected the first update to change the index")
+	}
+	if err := index.Save(); err != nil {
+		t.Fatalf("Failed to save index: %v", err)
+	}
+
+	results := index.Search(Query{Terms: []string{"kubernetes"}})
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results without the system message, got %d", len(results))
+	}
+	if results[0].Session != "k8s" || results[1].Role != "assistant" || results[1].Index != 2 {
+		t.Errorf("Expected the most recent results first, got %+v", results)
+	}
+
+	filtered := map[string]Query{
+		"persona": {Terms: []string{"kubernetes"}, Persona: "coach"},
+		"session": {Terms: []string{"kubernetes"}, Session: "k8s"},
+		"role":    {Terms: []string{"kubernetes"}, Role: "assistant"},
+		"since":   {Terms: []string{"kubernetes"}, Since: recent.Add(-time.Hour)},
+		"limit":   {Terms: []string{"kubernetes"}, Limit: 1},
+	}
+	counts := map[string]int{"persona": 0, "session": 1, "role": 1, "since": 1, "limit": 1}
+	for name, query := range filtered {
+		if got := len(index.Search(query)); got != counts[name] {
+			t.Errorf("Expected %d results filtered by %s, got %d", counts[name], name, got)
+		}
+	}
+
+	// Reopening an unchanged tree does not touch the index
+	index, err = Open(indexPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen index: %v", err)
+	}
+	if index.Update(sources) {
+		t.Error("Expected unchanged histories to be skipped")
+	}
+
+	// A changed history is indexed again, a removed one is forgotten
+	writeHistory(t, sources[2].Path,
+		persona.Message{Role: "user", Content: "Je cours le matin", CreatedAt: recent},
+		persona.Message{Role: "assistant", Content: "Et Kubernetes ?", CreatedAt: recent},
+	)
+	if err := os.Remove(sources[1].Path); err != nil {
+		t.Fatalf("Failed to remove history: %v", err)
+	}
+	if !index.Update(sources) {
+		t.Error("Expected changed histories to update the index")
+	}
+	results = index.Search(Query{Terms: []string{"kubernetes"}})
+	if len(results) != 3 || results[0].Persona != "coach" {
+		t.Errorf("Expected the new message and not the removed session, got %+v", results)
+	}
+}
+
+func TestOpen_CorruptIndexIsRebuilt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "search.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+
+	index, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected a corrupt index to be rebuilt, got %v", err)
+	}
+	if len(index.Files) != 0 {
+		t.Errorf("Expected an empty index, got %d files", len(index.Files))
+	}
+}
@@ -0,0 +1,123 @@
+package speak
+
+import (
+	"io"
+	"sync"
+
+	"github.com/faiface/beep"
+)
+
+// download is an mp3 decoded on its own goroutine as it downloads, so that
+// waiting for the network never holds up the speaker. It streams and seeks
+// within the samples decoded so far, and streams silence when playback
+// catches up with the download.
+type download struct {
+	// audio is what the decoder reads, closed to stop the download
+	audio io.Closer
+
+	mu       sync.Mutex
+	samples  [][2]float32
+	position int
+	finished bool
+	closed   bool
+	err      error
+}
+
+// newDownload decodes streamer on its own goroutine. audio is the mp3 the
+// streamer reads.
+func newDownload(streamer beep.StreamSeekCloser, audio io.Closer) *download {
+	d := &download{audio: audio}
+	go d.decode(streamer)
+	return d
+}
+
+// decode appends the samples of streamer until its end or until the
+// download is closed
+func (d *download) decode(streamer beep.StreamSeekCloser) {
+	buffer := make([][2]float64, 512)
+	for {
+		n, ok := streamer.Stream(buffer)
+		d.mu.Lock()
+		for _, sample := range buffer[:n] {
+			d.samples = append(d.samples, [2]float32{float32(sample[0]), float32(sample[1])})
+		}
+		closed := d.closed
+		d.mu.Unlock()
+		if !ok || closed {
+			break
+		}
+	}
+
+	err := streamer.Err()
+	streamer.Close()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.finished = true
+	if !d.closed {
+		d.err = err
+	}
+}
+
+// Stream fills samples from the current position, with silence while the
+// next samples are being downloaded
+func (d *download) Stream(samples [][2]float64) (n int, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for n < len(samples) && d.position < len(d.samples) {
+		sample := d.samples[d.position]
+		samples[n] = [2]float64{float64(sample[0]), float64(sample[1])}
+		n++
+		d.position++
+	}
+	if d.finished {
+		return n, n > 0
+	}
+	clear(samples[n:])
+	return len(samples), true
+}
+
+// Err returns the error of the decoding, once finished
+func (d *download) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// Len returns the number of samples decoded so far
+func (d *download) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.samples)
+}
+
+// Position returns the current position in samples
+func (d *download) Position() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.position
+}
+
+// Seek moves to position, within the samples decoded so far
+func (d *download) Seek(position int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.position = min(max(position, 0), len(d.samples))
+	return nil
+}
+
+// Finished reports whether the whole mp3 is decoded, its length known
+func (d *download) Finished() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.finished
+}
+
+// Close stops the download and drops the samples
+func (d *download) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	d.samples = nil
+	d.position = 0
+	d.mu.Unlock()
+	return d.audio.Close()
+}
@@ -0,0 +1,105 @@
+package speak
+
+import (
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// gatedStreamer decodes head samples, then waits for release before the
+// tail, like an mp3 waiting for the network
+type gatedStreamer struct {
+	head, tail int
+	release    chan struct{}
+	position   int
+	closed     bool
+}
+
+func (g *gatedStreamer) Stream(samples [][2]float64) (int, bool) {
+	if g.position == g.head {
+		<-g.release
+	}
+	end := g.head
+	if g.position >= g.head {
+		end = g.head + g.tail
+	}
+	n := min(len(samples), end-g.position)
+	for i := range n {
+		samples[i] = [2]float64{0.5, 0.5}
+	}
+	g.position += n
+	return n, n > 0
+}
+
+func (g *gatedStreamer) Err() error     { return nil }
+func (g *gatedStreamer) Len() int       { return g.head + g.tail }
+func (g *gatedStreamer) Position() int  { return g.position }
+func (g *gatedStreamer) Seek(int) error { return nil }
+func (g *gatedStreamer) Close() error   { g.closed = true; return nil }
+
+// waitFor polls condition until it holds
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPlayer_Download(t *testing.T) {
+	p := newTestPlayer()
+	format := beep.Format{SampleRate: SampleRate, NumChannels: 2, Precision: 2}
+	second := SampleRate.N(time.Second)
+	gate := &gatedStreamer{head: second, tail: second, release: make(chan struct{})}
+	var closed int
+	d := newDownload(gate, nopCloser{closed: &closed})
+	if err := p.enqueue(d, format); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	waitFor(t, func() bool { return d.Len() == second })
+
+	// The speaker gets silence rather than waiting for the network
+	samples := make([][2]float64, 2*second)
+	done := make(chan struct{})
+	go func() {
+		p.Stream(samples)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the player not to wait for the download")
+	}
+	if samples[second-1][0] != 0.5 || samples[second][0] != 0 {
+		t.Errorf("Expected the downloaded second then silence, got %v, %v", samples[second-1], samples[second])
+	}
+	if status := p.Status(); !status.Playing || status.Duration != 0 {
+		t.Errorf("Expected an unknown duration while downloading, got %+v", status)
+	}
+
+	// Seeking stays within what is downloaded
+	if err := p.Seek(-500 * time.Millisecond); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	if position := p.Status().Position; position != 500*time.Millisecond {
+		t.Errorf("Expected to seek back in a downloading clip, got %v", position)
+	}
+	p.Seek(5 * time.Second)
+	if position := p.Status().Position; position != time.Second {
+		t.Errorf("Expected to seek up to the downloaded second, got %v", position)
+	}
+
+	close(gate.release)
+	waitFor(t, d.Finished)
+	if status := p.Status(); status.Duration != 2*time.Second {
+		t.Errorf("Expected the duration once downloaded, got %+v", status)
+	}
+	p.Stop()
+	if closed != 1 || !gate.closed {
+		t.Errorf("Expected the audio and the decoder closed, got %d, %v", closed, gate.closed)
+	}
+}
@@ -0,0 +1,309 @@
+package speak
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/speaker"
+)
+
+// Playback speed and volume bounds. A speed of 1 and a volume of 1 play the
+// audio unchanged.
+const (
+	MinSpeed   = 0.5
+	MaxSpeed   = 2.0
+	SpeedStep  = 0.25
+	MaxVolume  = 1.5
+	VolumeStep = 0.1
+)
+
+// SampleRate is the rate of the audio device, every clip is resampled to it
+const SampleRate beep.SampleRate = 44100
+
+// resampleQuality is the quality of the resampling to the device rate
+const resampleQuality = 4
+
+// device is the speaker, initialized once for the whole process
+var device struct {
+	once sync.Once
+	err  error
+}
+
+// initDevice opens the speaker at SampleRate, on first use
+func initDevice() error {
+	device.once.Do(func() {
+		device.err = speaker.Init(SampleRate, SampleRate.N(time.Second/10))
+	})
+	return device.err
+}
+
+// Status describes the playback
+type Status struct {
+	// Playing is false when the queue is empty, when the other fields but
+	// Speed and Volume are zero
+	Playing bool
+	Paused  bool
+	// Queued counts the clips waiting after the one being played
+	Queued   int
+	Position time.Duration
+	// Duration is zero when unknown, for a clip still downloading
+	Duration time.Duration
+	Speed    float64
+	Volume   float64
+}
+
+// Player plays a queue of mp3 clips on the speaker, one after the other, and
+// controls the one being played. Speed and volume carry over to the next
+// clips. The player is a beep.Streamer mixed into the speaker, streaming
+// silence while its queue is empty.
+type Player struct {
+	// start plays the player on the device, once
+	start func() error
+	once  sync.Once
+	err   error
+
+	mu     sync.Mutex
+	speed  float64
+	volume float64
+	queue  []*clip
+	// idle is closed when the queue drains, nil while it is empty
+	idle chan struct{}
+	// failed is the first error of a clip since the last Wait
+	failed error
+}
+
+// clip is a queued mp3, resampled to the device rate, stretched to the
+// speed, then paused and scaled to the volume
+type clip struct {
+	streamer  beep.StreamSeekCloser
+	format    beep.Format
+	resampler *beep.Resampler
+	stretcher *stretcher
+	ctrl      *beep.Ctrl
+	volume    *effects.Volume
+}
+
+// NewPlayer returns a player at normal speed and volume
+func NewPlayer() *Player {
+	p := &Player{speed: 1, volume: 1}
+	p.start = func() error {
+		if err := initDevice(); err != nil {
+			return err
+		}
+		speaker.Play(p)
+		return nil
+	}
+	return p
+}
+
+// Play plays an mp3 and blocks until the end of the queue
+func (p *Player) Play(audio io.Reader) error {
+	if err := p.Enqueue(audio); err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+// Enqueue decodes the header of an mp3 and queues it. The rest of the audio
+// is decoded on its own goroutine, so an HTTP body starts playing before it
+// is downloaded, and can be sought within what is downloaded. The player
+// closes audio when it is an io.Closer, once played or stopped.
+func (p *Player) Enqueue(audio io.Reader) error {
+	rc, ok := audio.(io.ReadCloser)
+	if !ok {
+		rc = io.NopCloser(audio)
+	}
+	streamer, format, err := mp3.Decode(rc)
+	if err != nil {
+		rc.Close()
+		return err
+	}
+	return p.enqueue(newDownload(streamer, rc), format)
+}
+
+// enqueue queues a decoded clip and starts the player
+func (p *Player) enqueue(streamer beep.StreamSeekCloser, format beep.Format) error {
+	p.once.Do(func() { p.err = p.start() })
+	if p.err != nil {
+		streamer.Close()
+		return p.err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = append(p.queue, p.newClip(streamer, format))
+	if p.idle == nil {
+		p.idle = make(chan struct{})
+	}
+	return nil
+}
+
+// newClip wraps a decoded mp3 with the controls of the player. The caller
+// holds p.mu.
+func (p *Player) newClip(streamer beep.StreamSeekCloser, format beep.Format) *clip {
+	resampler := beep.Resample(resampleQuality, format.SampleRate, SampleRate, streamer)
+	stretcher := newStretcher(resampler)
+	ctrl := &beep.Ctrl{Streamer: stretcher}
+	c := &clip{
+		streamer:  streamer,
+		format:    format,
+		resampler: resampler,
+		stretcher: stretcher,
+		ctrl:      ctrl,
+		volume:    &effects.Volume{Streamer: ctrl, Base: 2},
+	}
+	stretcher.setSpeed(p.speed)
+	c.setVolume(p.volume)
+	return c
+}
+
+// setVolume scales the clip linearly, silencing it at zero
+func (c *clip) setVolume(volume float64) {
+	c.volume.Silent = volume <= 0
+	if volume > 0 {
+		c.volume.Volume = math.Log2(volume)
+	}
+}
+
+// Stream fills samples with the queue, one clip after the other, then with
+// silence. It never drains, so the player stays on the speaker.
+func (p *Player) Stream(samples [][2]float64) (n int, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for n < len(samples) && len(p.queue) > 0 {
+		c := p.queue[0]
+		streamed, more := c.volume.Stream(samples[n:])
+		n += streamed
+		if !more || streamed == 0 {
+			p.pop()
+		}
+	}
+	clear(samples[n:])
+	return len(samples), true
+}
+
+// Err is always nil, the errors of the clips are returned by Wait
+func (p *Player) Err() error {
+	return nil
+}
+
+// pop closes the clip at the head of the queue and wakes up Wait when the
+// queue drains. The caller holds p.mu.
+func (p *Player) pop() {
+	c := p.queue[0]
+	p.queue = p.queue[1:]
+	if err := c.streamer.Err(); err != nil && p.failed == nil {
+		p.failed = err
+	}
+	c.streamer.Close()
+	if len(p.queue) == 0 && p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+}
+
+// Wait blocks until the queue drains, and returns the first error of a clip
+// since the last Wait
+func (p *Player) Wait() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.mu.Unlock()
+	if idle != nil {
+		<-idle
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.failed
+	p.failed = nil
+	return err
+}
+
+// Stop drops the queue, cutting the clip being played
+func (p *Player) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.queue) > 0 {
+		p.pop()
+	}
+}
+
+// TogglePause pauses or resumes the clip being played, and reports whether
+// it is now paused
+func (p *Player) TogglePause() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.queue) == 0 {
+		return false
+	}
+	c := p.queue[0]
+	c.ctrl.Paused = !c.ctrl.Paused
+	return c.ctrl.Paused
+}
+
+// Seek moves the clip being played by offset, within its bounds or, while
+// it downloads, within what is downloaded
+func (p *Player) Seek(offset time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.queue) == 0 {
+		return nil
+	}
+	c := p.queue[0]
+	position := c.streamer.Position() + c.format.SampleRate.N(offset)
+	if err := c.streamer.Seek(min(max(position, 0), c.streamer.Len())); err != nil {
+		return err
+	}
+	c.stretcher.reset()
+	return nil
+}
+
+// SetSpeed changes the speed of the playback, bounded by MinSpeed and
+// MaxSpeed, and returns the speed applied. The voice keeps its pitch.
+func (p *Player) SetSpeed(speed float64) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.speed = min(max(speed, MinSpeed), MaxSpeed)
+	for _, c := range p.queue {
+		c.stretcher.setSpeed(p.speed)
+	}
+	return p.speed
+}
+
+// SetVolume changes the volume of the playback, between 0 and MaxVolume,
+// and returns the volume applied
+func (p *Player) SetVolume(volume float64) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Steps of 0.1 do not add up exactly to 0 or 1
+	p.volume = math.Round(min(max(volume, 0), MaxVolume)*100) / 100
+	for _, c := range p.queue {
+		c.setVolume(p.volume)
+	}
+	return p.volume
+}
+
+// Status returns the state of the playback
+func (p *Player) Status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := Status{Speed: p.speed, Volume: p.volume}
+	if len(p.queue) == 0 {
+		return status
+	}
+	c := p.queue[0]
+	status.Playing = true
+	status.Paused = c.ctrl.Paused
+	status.Queued = len(p.queue) - 1
+	status.Position = c.format.SampleRate.D(c.streamer.Position())
+	if d, ok := c.streamer.(*download); !ok || d.Finished() {
+		status.Duration = c.format.SampleRate.D(max(c.streamer.Len(), 0))
+	}
+	return status
+}
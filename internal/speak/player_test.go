@@ -0,0 +1,204 @@
+package speak
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// nopCloser turns a buffer streamer into a decoded mp3
+type nopCloser struct {
+	beep.StreamSeeker
+	closed *int
+}
+
+func (c nopCloser) Close() error {
+	*c.closed++
+	return nil
+}
+
+// newTestPlayer returns a player kept off the speaker, streamed by the test
+func newTestPlayer() *Player {
+	p := NewPlayer()
+	p.start = func() error { return nil }
+	return p
+}
+
+// enqueueTone queues a clip of constant samples lasting d at the device rate
+func enqueueTone(t *testing.T, p *Player, d time.Duration, value float64, closed *int) {
+	t.Helper()
+	format := beep.Format{SampleRate: SampleRate, NumChannels: 2, Precision: 2}
+	buffer := beep.NewBuffer(format)
+	samples := make([][2]float64, SampleRate.N(d))
+	for i := range samples {
+		samples[i] = [2]float64{value, value}
+	}
+	buffer.Append(beep.StreamerFunc(func(s [][2]float64) (int, bool) {
+		n := copy(s, samples)
+		samples = samples[n:]
+		return n, n > 0
+	}))
+	if err := p.enqueue(nopCloser{buffer.Streamer(0, buffer.Len()), closed}, format); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+}
+
+func TestPlayer_Queue(t *testing.T) {
+	p := newTestPlayer()
+	var closed int
+	enqueueTone(t, p, 10*time.Millisecond, 0.5, &closed)
+	enqueueTone(t, p, 10*time.Millisecond, 0.25, &closed)
+
+	if status := p.Status(); !status.Playing || status.Queued != 1 || status.Duration != 10*time.Millisecond {
+		t.Fatalf("Expected the first clip playing before another, got %+v", status)
+	}
+
+	// The clips follow each other, then the player streams silence
+	samples := make([][2]float64, SampleRate.N(30*time.Millisecond))
+	if n, ok := p.Stream(samples); n != len(samples) || !ok {
+		t.Fatalf("Expected the player never to drain, got %d, %v", n, ok)
+	}
+	clip := SampleRate.N(10 * time.Millisecond)
+	// The buffers quantize the samples to 16 bits
+	near := func(sample [2]float64, value float64) bool { return math.Abs(sample[0]-value) < 0.001 }
+	if !near(samples[clip/2], 0.5) || !near(samples[clip+clip/2], 0.25) || samples[len(samples)-1][0] != 0 {
+		t.Errorf("Expected both clips then silence, got %v, %v, %v", samples[clip/2], samples[clip+clip/2], samples[len(samples)-1])
+	}
+
+	done := make(chan error)
+	go func() { done <- p.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the queue to play without error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Wait to return once the queue drained")
+	}
+	if closed != 2 || p.Status().Playing {
+		t.Errorf("Expected both clips closed, got %d", closed)
+	}
+}
+
+func TestPlayer_Stop(t *testing.T) {
+	p := newTestPlayer()
+	var closed int
+	enqueueTone(t, p, time.Second, 0.5, &closed)
+	enqueueTone(t, p, time.Second, 0.5, &closed)
+
+	done := make(chan error)
+	go func() { done <- p.Wait() }()
+	p.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Stop to wake up Wait")
+	}
+	if closed != 2 {
+		t.Errorf("Expected the stopped clips closed, got %d", closed)
+	}
+}
+
+func TestPlayer_StartError(t *testing.T) {
+	p := NewPlayer()
+	p.start = func() error { return errors.New("no device") }
+	var closed int
+	format := beep.Format{SampleRate: SampleRate, NumChannels: 2, Precision: 2}
+	buffer := beep.NewBuffer(format)
+	if err := p.enqueue(nopCloser{buffer.Streamer(0, 0), &closed}, format); err == nil || closed != 1 {
+		t.Errorf("Expected the device error and the clip closed, got %v", err)
+	}
+}
+
+func TestPlayer_Idle(t *testing.T) {
+	p := newTestPlayer()
+	if p.TogglePause() {
+		t.Error("Expected nothing to pause between clips")
+	}
+	if err := p.Seek(time.Second); err != nil {
+		t.Errorf("Expected seeking between clips to do nothing, got %v", err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Errorf("Expected Wait to return at once, got %v", err)
+	}
+	if status := p.Status(); status.Playing || status.Speed != 1 || status.Volume != 1 {
+		t.Errorf("Expected an idle player at normal speed and volume, got %+v", status)
+	}
+}
+
+func TestPlayer_Controls(t *testing.T) {
+	p := newTestPlayer()
+	var closed int
+	enqueueTone(t, p, 2*time.Second, 0.5, &closed)
+	p.Seek(time.Second)
+
+	status := p.Status()
+	if status.Position != time.Second || status.Duration != 2*time.Second {
+		t.Fatalf("Expected the clip at 1s of 2s, got %+v", status)
+	}
+
+	if !p.TogglePause() || !p.Status().Paused {
+		t.Error("Expected the clip to be paused")
+	}
+	samples := make([][2]float64, 100)
+	p.Stream(samples)
+	if samples[0][0] != 0 || p.Status().Position != time.Second {
+		t.Error("Expected a paused clip to stream silence without moving")
+	}
+	if p.TogglePause() {
+		t.Error("Expected the clip to be resumed")
+	}
+
+	// Seeking stops at the bounds of the clip
+	p.Seek(-5 * time.Second)
+	if position := p.Status().Position; position != 0 {
+		t.Errorf("Expected to seek back to the start, got %v", position)
+	}
+	p.Seek(5 * time.Second)
+	if position := p.Status().Position; position != 2*time.Second {
+		t.Errorf("Expected to seek to the end, got %v", position)
+	}
+
+	if speed := p.SetSpeed(3); speed != MaxSpeed || p.queue[0].stretcher.speed != MaxSpeed {
+		t.Errorf("Expected the speed capped at %v, got %v", MaxSpeed, speed)
+	}
+	if speed := p.SetSpeed(0.1); speed != MinSpeed {
+		t.Errorf("Expected the speed floored at %v, got %v", MinSpeed, speed)
+	}
+
+	volume := 1.0
+	for range 10 {
+		volume = p.SetVolume(volume - VolumeStep)
+	}
+	if volume != 0 || !p.queue[0].volume.Silent {
+		t.Errorf("Expected the volume down to silence, got %v", volume)
+	}
+	if volume := p.SetVolume(0.5); volume != 0.5 || p.queue[0].volume.Silent || p.queue[0].volume.Volume != -1 {
+		t.Errorf("Expected half the volume, got %v", volume)
+	}
+}
+
+func TestPlayer_Resample(t *testing.T) {
+	p := newTestPlayer()
+	p.SetSpeed(1.5)
+	p.SetVolume(0.5)
+
+	// A clip at half the device rate is resampled, and keeps the settings
+	var closed int
+	format := beep.Format{SampleRate: SampleRate / 2, NumChannels: 2, Precision: 2}
+	buffer := beep.NewBuffer(format)
+	buffer.Append(beep.Silence(1000))
+	if err := p.enqueue(nopCloser{buffer.Streamer(0, buffer.Len()), &closed}, format); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if ratio := p.queue[0].resampler.Ratio(); ratio != 0.5 || p.queue[0].stretcher.speed != 1.5 {
+		t.Errorf("Expected a ratio of 0.5 stretched to 1.5, got %v", ratio)
+	}
+	if p.queue[0].volume.Volume != -1 {
+		t.Errorf("Expected the clip at the player volume, got %v", p.queue[0].volume.Volume)
+	}
+
+}
@@ -0,0 +1,183 @@
+package speak
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// The time-stretch overlap-adds frames of the input, half a frame apart in
+// the output and speed times that apart in the input. Each frame is moved by
+// up to stretchTolerance to line up with the one before, so that the voice
+// keeps its pitch without echoing (WSOLA).
+var (
+	stretchFrame     = SampleRate.N(40 * time.Millisecond)
+	stretchTolerance = SampleRate.N(5 * time.Millisecond)
+)
+
+// stretchStep is the stride of the frame alignment search, in samples
+const stretchStep = 4
+
+// stretcher plays a streamer at the device rate faster or slower, keeping
+// its pitch. At speed 1 it passes the streamer through.
+type stretcher struct {
+	source beep.Streamer
+	speed  float64
+	window []float64
+
+	// in holds the input from the earliest sample a frame may still start at
+	in [][2]float64
+	// pos is where the next frame starts in in, before it is lined up
+	pos float64
+	// next is where the input follows the last frame in in, and target the
+	// samples there, which the next frame is lined up with
+	next   int
+	target [][2]float64
+	// out sums the frames still overlapping, ready the output to stream
+	out     [][2]float64
+	ready   [][2]float64
+	buffer  [][2]float64
+	drained bool
+	flushed bool
+}
+
+// newStretcher returns a stretcher of source at normal speed
+func newStretcher(source beep.Streamer) *stretcher {
+	window := make([]float64, stretchFrame)
+	for i := range window {
+		// A periodic Hann window, whose halves add up to 1
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(len(window)))
+	}
+	return &stretcher{
+		source: source,
+		speed:  1,
+		window: window,
+		out:    make([][2]float64, stretchFrame),
+		buffer: make([][2]float64, 512),
+	}
+}
+
+// setSpeed changes the speed. Back to normal speed, the input read ahead is
+// played from where the last frame left it.
+func (s *stretcher) setSpeed(speed float64) {
+	if speed == s.speed {
+		return
+	}
+	if speed == 1 && s.next < len(s.in) {
+		s.ready = append(s.ready, s.in[s.next:]...)
+	}
+	s.speed = speed
+	s.reset()
+}
+
+// reset drops the input read ahead and the frames being summed, when the
+// speed changes or the source is sought
+func (s *stretcher) reset() {
+	s.in = s.in[:0]
+	s.pos, s.next, s.target = 0, 0, nil
+	clear(s.out)
+	s.drained, s.flushed = false, false
+}
+
+// Stream fills samples with the source, stretched to the speed
+func (s *stretcher) Stream(samples [][2]float64) (n int, ok bool) {
+	n = copy(samples, s.ready)
+	s.ready = s.ready[n:]
+	if s.speed == 1 {
+		if n < len(samples) {
+			streamed, _ := s.source.Stream(samples[n:])
+			n += streamed
+		}
+		return n, n > 0
+	}
+
+	for n < len(samples) {
+		if len(s.ready) == 0 && !s.frame() {
+			break
+		}
+		copied := copy(samples[n:], s.ready)
+		s.ready = s.ready[copied:]
+		n += copied
+	}
+	return n, n > 0
+}
+
+// Err returns the error of the source
+func (s *stretcher) Err() error {
+	return s.source.Err()
+}
+
+// frame adds the next frame to the output and makes half a frame of output
+// ready. It reports false once the source is played.
+func (s *stretcher) frame() bool {
+	hop := len(s.window) / 2
+	s.fill(int(s.pos) + stretchTolerance + len(s.window))
+	if s.drained && int(s.pos) >= len(s.in) {
+		if s.flushed {
+			return false
+		}
+		// The end of the last frame
+		s.flushed = true
+		s.ready = append(s.ready[:0], s.out[:hop]...)
+		return true
+	}
+
+	start := int(s.pos)
+	if s.target != nil {
+		start = s.align(start)
+	}
+	for i, weight := range s.window {
+		if start+i >= len(s.in) {
+			break
+		}
+		s.out[i][0] += s.in[start+i][0] * weight
+		s.out[i][1] += s.in[start+i][1] * weight
+	}
+	s.ready = append(s.ready[:0], s.out[:hop]...)
+	copy(s.out, s.out[hop:])
+	clear(s.out[len(s.out)-hop:])
+
+	s.next = start + hop
+	s.target = append(s.target[:0], s.in[min(s.next, len(s.in)):min(s.next+hop, len(s.in))]...)
+	s.pos += float64(hop) * s.speed
+
+	// Forget the input no frame can start at anymore
+	if drop := min(int(s.pos)-stretchTolerance, s.next, len(s.in)); drop > 0 {
+		s.in = s.in[:copy(s.in, s.in[drop:])]
+		s.pos -= float64(drop)
+		s.next -= drop
+	}
+	return true
+}
+
+// fill reads the source until in holds size samples or the source is played
+func (s *stretcher) fill(size int) {
+	for !s.drained && len(s.in) < size {
+		n, ok := s.source.Stream(s.buffer)
+		s.in = append(s.in, s.buffer[:n]...)
+		if !ok || n == 0 {
+			s.drained = true
+		}
+	}
+}
+
+// align returns the start of the frame near start that best follows the
+// last frame, the one most correlated with its target
+func (s *stretcher) align(start int) int {
+	best, score := start, math.Inf(-1)
+	for candidate := max(start-stretchTolerance, 0); candidate <= start+stretchTolerance; candidate++ {
+		if candidate+len(s.target) > len(s.in) {
+			break
+		}
+		var correlation float64
+		for i := 0; i < len(s.target); i += stretchStep {
+			a, b := s.in[candidate+i], s.target[i]
+			correlation += (a[0] + a[1]) * (b[0] + b[1])
+		}
+		if correlation > score {
+			best, score = candidate, correlation
+		}
+	}
+	return best
+}
@@ -0,0 +1,85 @@
+package speak
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// sine returns a tone of frequency lasting d at the device rate
+func sine(frequency float64, d time.Duration) beep.Streamer {
+	samples := make([][2]float64, SampleRate.N(d))
+	for i := range samples {
+		value := 0.5 * math.Sin(2*math.Pi*frequency*float64(i)/float64(SampleRate))
+		samples[i] = [2]float64{value, value}
+	}
+	return beep.StreamerFunc(func(s [][2]float64) (int, bool) {
+		n := copy(s, samples)
+		samples = samples[n:]
+		return n, n > 0
+	})
+}
+
+// drain streams s to its end
+func drain(s beep.Streamer) [][2]float64 {
+	var out [][2]float64
+	buffer := make([][2]float64, 1000)
+	for {
+		n, ok := s.Stream(buffer)
+		out = append(out, buffer[:n]...)
+		if !ok {
+			return out
+		}
+	}
+}
+
+// frequency estimates the frequency of a tone from its zero crossings,
+// leaving out the fades at both ends
+func frequency(samples [][2]float64) float64 {
+	samples = samples[len(samples)/10 : len(samples)*9/10]
+	crossings := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1][0] < 0) != (samples[i][0] < 0) {
+			crossings++
+		}
+	}
+	return float64(crossings) / 2 / SampleRate.D(len(samples)).Seconds()
+}
+
+func TestStretcher_KeepsPitch(t *testing.T) {
+	for _, speed := range []float64{0.5, 1.5, 2} {
+		s := newStretcher(sine(440, time.Second))
+		s.setSpeed(speed)
+		out := drain(s)
+
+		length := SampleRate.D(len(out)).Seconds()
+		if expected := 1 / speed; math.Abs(length-expected) > 0.05 {
+			t.Errorf("Expected %.2fs at speed %v, got %.2fs", expected, speed, length)
+		}
+		if f := frequency(out); math.Abs(f-440) > 10 {
+			t.Errorf("Expected the tone kept at 440 Hz at speed %v, got %.0f Hz", speed, f)
+		}
+	}
+}
+
+func TestStretcher_NormalSpeed(t *testing.T) {
+	source := sine(440, 100*time.Millisecond)
+	s := newStretcher(source)
+	out := drain(s)
+	if len(out) != SampleRate.N(100*time.Millisecond) {
+		t.Fatalf("Expected the tone passed through, got %d samples", len(out))
+	}
+
+	// Back to normal speed, the input read ahead is not lost
+	s = newStretcher(sine(440, time.Second))
+	s.setSpeed(2)
+	head := make([][2]float64, SampleRate.N(100*time.Millisecond))
+	s.Stream(head)
+	s.setSpeed(1)
+	rest := drain(s)
+	if length := SampleRate.D(len(rest)); length < 750*time.Millisecond || length > 820*time.Millisecond {
+		t.Errorf("Expected about 0.8s left at normal speed, got %v", length)
+	}
+}
@@ -0,0 +1,383 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/diff"
+	"github.com/ctrl-vfr/persona/internal/persona"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Statuses of a built-in persona in the user's directory
+const (
+	// BuiltinUpToDate matches the embedded version
+	BuiltinUpToDate = "up-to-date"
+	// BuiltinModified was edited by the user, the embedded version did not change
+	BuiltinModified = "modified"
+	// BuiltinOutdated was not edited and a newer version is embedded
+	BuiltinOutdated = "outdated"
+	// BuiltinDiverged was edited by the user and a newer version is embedded
+	BuiltinDiverged = "diverged"
+	// BuiltinRemoved was deleted by the user and is not reinstalled
+	BuiltinRemoved = "removed"
+	// BuiltinNotInstalled was never installed, or is provided by a catalog
+	BuiltinNotInstalled = "not-installed"
+)
+
+// BuiltinStatus describes a built-in persona compared to its embedded version
+type BuiltinStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Revision identifies the embedded version
+	Revision string `json:"revision"`
+	// InstalledRevision identifies the version last installed or upgraded,
+	// empty when unknown
+	InstalledRevision string `json:"installed_revision,omitempty"`
+}
+
+// BuiltinUpgrade reports the changes made by upgrading a built-in persona
+type BuiltinUpgrade struct {
+	Name string `json:"name"`
+	// Status is the status before the upgrade
+	Status string `json:"status"`
+	// Updated lists the fields taken from the embedded version
+	Updated []string `json:"updated,omitempty"`
+	// Conflicts lists the fields changed on both sides, which keep the local
+	// value unless forced
+	Conflicts []string `json:"conflicts,omitempty"`
+	// Installed is set when the persona was (re)installed from scratch
+	Installed bool `json:"installed,omitempty"`
+}
+
+// builtinRecord is what the state file remembers about a built-in persona
+type builtinRecord struct {
+	Revision string `yaml:"revision"`
+	// Base is the built-in as last installed or upgraded, the common ancestor
+	// of three-way merges. Empty when unknown.
+	Base string `yaml:"base,omitempty"`
+}
+
+// builtinField is a persona field merged by upgrades. Its values are comparable.
+type builtinField struct {
+	name string
+	get  func(p *persona.Persona) any
+	set  func(dst, src *persona.Persona)
+}
+
+var builtinFields = []builtinField{
+	{"version", func(p *persona.Persona) any { return p.Version }, func(dst, src *persona.Persona) { dst.Version = src.Version }},
+	{"extends", func(p *persona.Persona) any { return p.Extends }, func(dst, src *persona.Persona) { dst.Extends = src.Extends }},
+	{"voice.name", func(p *persona.Persona) any { return p.Voice.Name }, func(dst, src *persona.Persona) { dst.Voice.Name = src.Voice.Name }},
+	{"voice.instructions", func(p *persona.Persona) any { return p.Voice.Instructions }, func(dst, src *persona.Persona) { dst.Voice.Instructions = src.Voice.Instructions }},
+	{"prompt", func(p *persona.Persona) any { return p.Prompt }, func(dst, src *persona.Persona) { dst.Prompt = src.Prompt }},
+	{"includes", func(p *persona.Persona) any { return strings.Join(p.Includes, "\n") }, func(dst, src *persona.Persona) { dst.Includes = src.Includes }},
+	{"voice.speed", func(p *persona.Persona) any { return p.Voice.Speed }, func(dst, src *persona.Persona) { dst.Voice.Speed = src.Voice.Speed }},
+	{"models", func(p *persona.Persona) any { return p.Models }, func(dst, src *persona.Persona) { dst.Models = src.Models }},
+	{"generation", func(p *persona.Persona) any { return generationKey(p.Generation) }, func(dst, src *persona.Persona) { dst.Generation = src.Generation }},
+	{"transcription", func(p *persona.Persona) any { return p.Transcription }, func(dst, src *persona.Persona) { dst.Transcription = src.Transcription }},
+}
+
+// generationKey makes generation settings comparable, their numbers being pointers
+func generationKey(g persona.Generation) string {
+	number := func(value *float64) string {
+		if value == nil {
+			return "-"
+		}
+		return fmt.Sprint(*value)
+	}
+	return fmt.Sprintf("%s/%s/%d/%s", number(g.Temperature), number(g.TopP), g.MaxTokens, number(g.PresencePenalty))
+}
+
+// BuiltinRevision identifies a version of a built-in persona template
+func BuiltinRevision(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// getBuiltin returns the embedded template of a built-in persona
+func getBuiltin(name string) (BuiltinPersona, error) {
+	for _, builtin := range GetBuiltinPersonas() {
+		if builtin.Name == name {
+			return builtin, nil
+		}
+	}
+	return BuiltinPersona{}, fmt.Errorf("%s is not a built-in persona", name)
+}
+
+// getBuiltinsStatePath returns the file recording the installed built-in personas
+func (m *Manager) getBuiltinsStatePath() string {
+	return filepath.Join(m.BasePath, ".builtins.yaml")
+}
+
+func (m *Manager) loadBuiltinsState() (map[string]builtinRecord, error) {
+	state := map[string]builtinRecord{}
+	data, err := os.ReadFile(m.getBuiltinsStatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read built-in personas state: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse built-in personas state: %w", err)
+	}
+	return state, nil
+}
+
+func (m *Manager) saveBuiltinsState(state map[string]builtinRecord) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal built-in personas state: %w", err)
+	}
+	if err := os.WriteFile(m.getBuiltinsStatePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write built-in personas state: %w", err)
+	}
+	return nil
+}
+
+// installedRecord returns the state of a freshly installed built-in
+func installedRecord(builtin BuiltinPersona) builtinRecord {
+	return builtinRecord{
+		Revision: BuiltinRevision(builtin.Data),
+		Base:     string(withName(builtin.Data, builtin.Name)),
+	}
+}
+
+// adoptBuiltin records a built-in installed by a version without state file.
+// Its base is only known when the user did not edit it.
+func (m *Manager) adoptBuiltin(builtin BuiltinPersona) builtinRecord {
+	local, err := m.readBuiltinDefinition(builtin.Name)
+	if err == nil {
+		embedded, err := parseDefinition(withName(builtin.Data, builtin.Name))
+		if err == nil && len(changedFields(local, embedded)) == 0 {
+			return installedRecord(builtin)
+		}
+	}
+	return builtinRecord{}
+}
+
+// ListBuiltinStatuses compares every built-in persona to its embedded version
+func (m *Manager) ListBuiltinStatuses() ([]BuiltinStatus, error) {
+	state, err := m.loadBuiltinsState()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]BuiltinStatus, 0, len(GetBuiltinPersonas()))
+	for _, builtin := range GetBuiltinPersonas() {
+		statuses = append(statuses, m.builtinStatus(builtin, state))
+	}
+	return statuses, nil
+}
+
+func (m *Manager) builtinStatus(builtin BuiltinPersona, state map[string]builtinRecord) BuiltinStatus {
+	status := BuiltinStatus{Name: builtin.Name, Revision: BuiltinRevision(builtin.Data)}
+
+	record, recorded := state[builtin.Name]
+	status.InstalledRevision = record.Revision
+	if _, err := os.Stat(m.userPersonaPath(builtin.Name)); err != nil {
+		status.Status = BuiltinNotInstalled
+		if recorded {
+			status.Status = BuiltinRemoved
+		}
+		return status
+	}
+
+	local, err := m.readBuiltinDefinition(builtin.Name)
+	embedded, embeddedErr := parseDefinition(withName(builtin.Data, builtin.Name))
+	if err == nil && embeddedErr == nil && len(changedFields(local, embedded)) == 0 {
+		status.Status = BuiltinUpToDate
+		return status
+	}
+
+	edited := true
+	if base, err := parseDefinition([]byte(record.Base)); err == nil && record.Base != "" && local != nil {
+		edited = len(changedFields(local, base)) > 0
+	}
+	outdated := record.Revision != status.Revision
+
+	switch {
+	case edited && outdated:
+		status.Status = BuiltinDiverged
+	case edited:
+		status.Status = BuiltinModified
+	default:
+		status.Status = BuiltinOutdated
+	}
+	return status
+}
+
+// DiffBuiltin returns the unified diff from the user's version of a built-in
+// persona to the embedded one. With upstream, it shows the changes of the
+// embedded version since it was last installed or upgraded instead.
+func (m *Manager) DiffBuiltin(name string, upstream bool) (string, error) {
+	builtin, err := getBuiltin(name)
+	if err != nil {
+		return "", err
+	}
+	embedded := string(withName(builtin.Data, name))
+	embeddedName := fmt.Sprintf("builtin/%s (%s)", name, BuiltinRevision(builtin.Data))
+
+	if upstream {
+		state, err := m.loadBuiltinsState()
+		if err != nil {
+			return "", err
+		}
+		record, ok := state[name]
+		if !ok || record.Base == "" {
+			return "", fmt.Errorf("the installed version of %s is unknown", name)
+		}
+		return diff.Unified(fmt.Sprintf("builtin/%s (%s)", name, record.Revision), embeddedName, record.Base, embedded), nil
+	}
+
+	personaPath := m.userPersonaPath(name)
+	local, err := os.ReadFile(personaPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read persona %s: %w", name, err)
+	}
+	return diff.Unified(personaPath, embeddedName, string(local), embedded), nil
+}
+
+// UpgradeBuiltin brings a built-in persona to its embedded version with a
+// three-way merge of its fields: fields only changed by the user are kept,
+// fields only changed upstream are updated, and fields changed on both sides
+// keep the local value unless force is set. A removed built-in is reinstalled.
+func (m *Manager) UpgradeBuiltin(name string, force bool) (*BuiltinUpgrade, error) {
+	builtin, err := getBuiltin(name)
+	if err != nil {
+		return nil, err
+	}
+	state, err := m.loadBuiltinsState()
+	if err != nil {
+		return nil, err
+	}
+
+	status := m.builtinStatus(builtin, state)
+	result := &BuiltinUpgrade{Name: name, Status: status.Status}
+
+	switch status.Status {
+	case BuiltinUpToDate, BuiltinModified:
+		// Nothing new upstream, only record the revision
+		if state[name].Revision == status.Revision {
+			return result, nil
+		}
+		state[name] = installedRecord(builtin)
+		return result, m.saveBuiltinsState(state)
+
+	case BuiltinNotInstalled, BuiltinRemoved:
+		if m.PersonaExists(name) {
+			return nil, fmt.Errorf("persona %s is provided by another directory", name)
+		}
+		if err := m.CreatePersonaFromYAMLTemplate(name, builtin.Data); err != nil {
+			return nil, err
+		}
+		result.Installed = true
+		state[name] = installedRecord(builtin)
+		return result, m.saveBuiltinsState(state)
+	}
+
+	local, err := m.readBuiltinDefinition(name)
+	if err != nil {
+		return nil, err
+	}
+	embedded, err := parseDefinition(withName(builtin.Data, name))
+	if err != nil {
+		return nil, err
+	}
+	var base *persona.Persona
+	if record := state[name]; record.Base != "" {
+		if base, err = parseDefinition([]byte(record.Base)); err != nil {
+			return nil, err
+		}
+	}
+
+	merged := *local
+	for _, field := range builtinFields {
+		mine, theirs := field.get(local), field.get(embedded)
+		if mine == theirs {
+			continue
+		}
+
+		// Without base, every difference may be a local edit
+		switch {
+		case base != nil && mine == field.get(base):
+			field.set(&merged, embedded)
+			result.Updated = append(result.Updated, field.name)
+		case base != nil && theirs == field.get(base):
+			// Local edit only
+		default:
+			result.Conflicts = append(result.Conflicts, field.name)
+			if force {
+				field.set(&merged, embedded)
+				result.Updated = append(result.Updated, field.name)
+			}
+		}
+	}
+
+	if len(result.Updated) > 0 {
+		data, err := marshalDefinition(&merged)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(m.userPersonaPath(name), data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write persona file: %w", err)
+		}
+	}
+
+	// The embedded version becomes the base of the next merges, so resolved
+	// conflicts are not reported again
+	state[name] = installedRecord(builtin)
+	return result, m.saveBuiltinsState(state)
+}
+
+// readBuiltinDefinition reads a persona from the user's directory, as written
+func (m *Manager) readBuiltinDefinition(name string) (*persona.Persona, error) {
+	data, err := os.ReadFile(m.userPersonaPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona %s: %w", name, err)
+	}
+	p, err := parseDefinition(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse persona %s: %w", name, err)
+	}
+	return p, nil
+}
+
+func parseDefinition(data []byte) (*persona.Persona, error) {
+	p := &persona.Persona{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func marshalDefinition(p *persona.Persona) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(p); err != nil {
+		return nil, fmt.Errorf("failed to marshal persona: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal persona: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// changedFields returns the merged fields that differ between a and b
+func changedFields(a, b *persona.Persona) []string {
+	var changed []string
+	for _, field := range builtinFields {
+		if field.get(a) != field.get(b) {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
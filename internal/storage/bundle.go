@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/bundle"
+	"github.com/ctrl-vfr/persona/internal/schema"
+)
+
+// ErrPersonaExists is returned when importing over an existing persona
+var ErrPersonaExists = errors.New("persona already exists")
+
+// ExportPersona bundles a persona as written in its file, with its history
+// when withHistory is set. The manifest name, format, creation date and
+// checksum are filled in.
+func (m *Manager) ExportPersona(name string, manifest bundle.Manifest, withHistory bool) (*bundle.Bundle, error) {
+	personaPath, historyPath := m.GetPersonaPath(name)
+
+	data, err := os.ReadFile(personaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona %s: %w", name, err)
+	}
+
+	manifest.Name = name
+	if manifest.Created.IsZero() {
+		manifest.Created = time.Now().UTC().Truncate(time.Second)
+	}
+	b := &bundle.Bundle{
+		Manifest: manifest,
+		Files:    map[string][]byte{bundle.PersonaFile: data},
+	}
+
+	if withHistory {
+		history, err := os.ReadFile(historyPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read history for persona %s: %w", name, err)
+		}
+		if err == nil {
+			b.Files[bundle.HistoryFile] = history
+		}
+	}
+
+	return b, nil
+}
+
+// ImportPersona writes a bundled persona under name, after validating it.
+// An existing persona is replaced only when overwrite is set; its history is
+// kept unless the bundle carries one.
+func (m *Manager) ImportPersona(b *bundle.Bundle, name string, overwrite bool) error {
+	if err := ValidatePersonaName(name); err != nil {
+		return err
+	}
+	if m.PersonaExists(name) && !overwrite {
+		return fmt.Errorf("%w: %s", ErrPersonaExists, name)
+	}
+
+	// Imported personas always go to the user's directory
+	_, historyPath := m.GetPersonaPath(name)
+	personaPath := m.userPersonaPath(name)
+	data := withName(b.Files[bundle.PersonaFile], name)
+
+	opts := schema.PersonaOptions{Directory: name}
+	opts.SpeechModel = m.currentConfig().Models.Speech
+	if err := schema.Check(schema.ValidatePersona(personaPath, data, opts)); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(personaPath), 0755); err != nil {
+		return fmt.Errorf("failed to create persona directory: %w", err)
+	}
+	if err := os.WriteFile(personaPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write persona file: %w", err)
+	}
+
+	history, ok := b.Files[bundle.HistoryFile]
+	if !ok {
+		if _, err := os.Stat(historyPath); err == nil {
+			return nil
+		}
+		history = []byte("[]\n")
+	}
+	if err := m.createConversationDir(name); err != nil {
+		return err
+	}
+	if err := os.WriteFile(historyPath, history, 0644); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+
+	return nil
+}
+
+// AvailablePersonaName returns name, or name followed by the first free
+// number when a persona with that name already exists
+func (m *Manager) AvailablePersonaName(name string) string {
+	candidate := name
+	for i := 2; m.PersonaExists(candidate); i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	return candidate
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"path/filepath"
+
+	"github.com/ctrl-vfr/persona/internal/cache"
+	"github.com/ctrl-vfr/persona/internal/config"
+)
+
+// GetSpeechCachePath returns the directory of the speech cache
+func (m *Manager) GetSpeechCachePath() string {
+	return filepath.Join(m.BasePath, "cache", "speech")
+}
+
+// SpeechCache returns the cache of synthesized speech, limited to the size
+// configured in cfg
+func (m *Manager) SpeechCache(cfg *config.Config) *cache.Cache {
+	return cache.New(m.GetSpeechCachePath(), cfg.CacheLimit())
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PersonaSource tells which directory a persona is loaded from
+type PersonaSource struct {
+	Name string `json:"name"`
+	// Dir is the personas directory holding the persona
+	Dir string `json:"dir"`
+	// ReadOnly is set for personas outside the user's personas directory
+	ReadOnly bool `json:"read_only"`
+}
+
+// PersonaDirs returns the directories searched for personas, by precedence:
+// the user's personas directory, the persona_paths of the configuration and
+// $XDG_CONFIG_HOME/persona/personas. Only the first one is written to.
+func (m *Manager) PersonaDirs() []string {
+	dirs := []string{m.userPersonasDir()}
+
+	dirs = append(dirs, m.configPersonaPaths()...)
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dirs = append(dirs, filepath.Join(xdg, "persona", "personas"))
+	}
+
+	// A directory listed twice keeps its highest precedence
+	unique := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if !slices.Contains(unique, dir) {
+			unique = append(unique, dir)
+		}
+	}
+	return unique
+}
+
+// configPersonaPaths returns the expanded persona_paths of the
+// configuration. Every persona lookup goes through them, so they are only
+// loaded again when the configuration file or the profile changes.
+func (m *Manager) configPersonaPaths() []string {
+	key := m.Profile
+	if info, err := os.Stat(m.GetConfigPath()); err == nil {
+		key = fmt.Sprintf("%s:%d:%d", m.Profile, info.ModTime().UnixNano(), info.Size())
+	}
+
+	cache := &m.personaPaths
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.paths != nil && cache.key == key {
+		return cache.paths
+	}
+	paths := []string{}
+	for _, path := range m.currentConfig().PersonaPaths {
+		paths = append(paths, m.expandPath(path))
+	}
+	cache.key, cache.paths = key, paths
+	return paths
+}
+
+// GetPersonaSource returns where a persona is loaded from
+func (m *Manager) GetPersonaSource(name string) (PersonaSource, bool) {
+	for i, dir := range m.PersonaDirs() {
+		if _, err := os.Stat(filepath.Join(dir, name, "persona.yaml")); err == nil {
+			return PersonaSource{Name: name, Dir: dir, ReadOnly: i > 0}, true
+		}
+	}
+	return PersonaSource{}, false
+}
+
+// ListPersonaSources returns every available persona, sorted by name, with
+// the directory it is loaded from. A persona shadows the ones with the same
+// name in directories of lower precedence.
+func (m *Manager) ListPersonaSources() ([]PersonaSource, error) {
+	seen := map[string]bool{}
+	var sources []PersonaSource
+
+	for i, dir := range m.PersonaDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			// Missing or unreadable team catalogs should not hide the user's personas
+			if i > 0 {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || seen[entry.Name()] {
+				continue
+			}
+			// Skip other directories, such as shared fragments in catalogs or
+			// the history of a read-only persona in the user's directory
+			if _, err := os.Stat(filepath.Join(dir, entry.Name(), "persona.yaml")); err != nil {
+				continue
+			}
+			seen[entry.Name()] = true
+			sources = append(sources, PersonaSource{Name: entry.Name(), Dir: dir, ReadOnly: i > 0})
+		}
+	}
+
+	slices.SortFunc(sources, func(a, b PersonaSource) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return sources, nil
+}
+
+// userPersonasDir returns the user's own personas directory
+func (m *Manager) userPersonasDir() string {
+	return filepath.Join(m.BasePath, "personas")
+}
+
+// userPersonaPath returns the path a persona is written to, whatever the
+// directory it is currently loaded from
+func (m *Manager) userPersonaPath(name string) string {
+	return filepath.Join(m.userPersonasDir(), name, "persona.yaml")
+}
+
+// expandPath resolves ~/ and paths relative to the base directory
+func (m *Manager) expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(m.BasePath, path)
+}
@@ -0,0 +1,354 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// trashTimeFormat suffixes the directories of deleted personas
+const trashTimeFormat = "20060102-150405"
+
+// TrashedPersona is a deleted persona that can be restored
+type TrashedPersona struct {
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// Path is the directory holding the persona in the trash
+	Path string `json:"path"`
+}
+
+// GetArchivePath returns the directory holding archived personas
+func (m *Manager) GetArchivePath() string {
+	return filepath.Join(m.BasePath, "archive")
+}
+
+// GetTrashPath returns the directory holding deleted personas
+func (m *Manager) GetTrashPath() string {
+	return filepath.Join(m.BasePath, "trash")
+}
+
+// userPersona checks that a persona exists in the user's directory, where it
+// can be moved, and returns that directory
+func (m *Manager) userPersona(name string) (string, error) {
+	source, ok := m.GetPersonaSource(name)
+	if !ok {
+		return "", fmt.Errorf("persona %s does not exist", name)
+	}
+	if source.ReadOnly {
+		return "", fmt.Errorf("persona %s is read-only, it comes from %s", name, source.Dir)
+	}
+	return filepath.Join(m.userPersonasDir(), name), nil
+}
+
+// checkNewPersona checks that a persona can be created under name
+func (m *Manager) checkNewPersona(name string) error {
+	if err := ValidatePersonaName(name); err != nil {
+		return err
+	}
+	if m.PersonaExists(name) {
+		return fmt.Errorf("%w: %s", ErrPersonaExists, name)
+	}
+	// History files of read-only personas live in the user's directory too
+	if _, err := os.Stat(filepath.Join(m.userPersonasDir(), name)); err == nil {
+		return fmt.Errorf("%w: %s", ErrPersonaExists, name)
+	}
+	return nil
+}
+
+// RenamePersona renames a persona: its directory, its name field, the
+// personas extending it and the group sessions it takes part in
+func (m *Manager) RenamePersona(oldName, newName string) error {
+	if oldName == "persona" {
+		return fmt.Errorf("cannot rename the default persona persona")
+	}
+	oldDir, err := m.userPersona(oldName)
+	if err != nil {
+		return err
+	}
+	if err := m.checkNewPersona(newName); err != nil {
+		return err
+	}
+
+	newDir := filepath.Join(m.userPersonasDir(), newName)
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return fmt.Errorf("failed to rename persona directory: %w", err)
+	}
+
+	personaPath := m.userPersonaPath(newName)
+	data, err := os.ReadFile(personaPath)
+	if err != nil {
+		return fmt.Errorf("failed to read persona %s: %w", newName, err)
+	}
+	if err := os.WriteFile(personaPath, withName(data, newName), 0644); err != nil {
+		return fmt.Errorf("failed to write persona file: %w", err)
+	}
+
+	if err := m.renameExtends(oldName, newName); err != nil {
+		return err
+	}
+	if err := m.renameInGroupSessions(oldName, newName); err != nil {
+		return err
+	}
+
+	// Keep the renamed persona as default
+	if cfg, err := m.GetConfigFile(); err == nil && cfg.DefaultPersona == oldName {
+		cfg.DefaultPersona = newName
+		if err := m.SaveConfig(cfg); err != nil {
+			return fmt.Errorf("failed to update the default persona: %w", err)
+		}
+	}
+	return nil
+}
+
+// renameExtends updates the personas of the user's directory extending oldName
+func (m *Manager) renameExtends(oldName, newName string) error {
+	sources, err := m.ListPersonaSources()
+	if err != nil {
+		return fmt.Errorf("failed to list personas: %w", err)
+	}
+
+	extends := regexp.MustCompile(`(?m)^extends:[ \t]*["']?` + regexp.QuoteMeta(oldName) + `["']?[ \t]*$`)
+	for _, source := range sources {
+		if source.ReadOnly {
+			continue
+		}
+		personaPath := m.userPersonaPath(source.Name)
+		data, err := os.ReadFile(personaPath)
+		if err != nil || !extends.Match(data) {
+			continue
+		}
+		data = extends.ReplaceAllLiteral(data, []byte("extends: "+newName))
+		if err := os.WriteFile(personaPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to update persona %s: %w", source.Name, err)
+		}
+	}
+	return nil
+}
+
+// renameInGroupSessions updates the group sessions a persona takes part in
+func (m *Manager) renameInGroupSessions(oldName, newName string) error {
+	entries, err := os.ReadDir(filepath.Join(m.BasePath, "groups"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read group sessions: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		session, err := m.GetGroupSession(entry.Name())
+		if err != nil || !session.RenameParticipant(oldName, newName) {
+			continue
+		}
+
+		// Keep the session under its old directory if the new one is taken
+		if session.Name != entry.Name() {
+			sessionPath, _ := m.GetGroupPath(session.Name)
+			if _, err := os.Stat(filepath.Dir(sessionPath)); err == nil {
+				session.Name = entry.Name()
+			}
+		}
+		if err := m.SaveGroupSession(session); err != nil {
+			return err
+		}
+		if session.Name != entry.Name() {
+			if err := os.RemoveAll(filepath.Join(m.BasePath, "groups", entry.Name())); err != nil {
+				return fmt.Errorf("failed to remove group session %s: %w", entry.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// CopyPersona duplicates a persona, read-only ones included, with its
+// history, conversation branches and sessions when withHistory is set
+func (m *Manager) CopyPersona(src, dst string, withHistory bool) error {
+	srcPath, _ := m.GetPersonaPath(src)
+	if !m.PersonaExists(src) {
+		return fmt.Errorf("persona %s does not exist", src)
+	}
+	if err := m.checkNewPersona(dst); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read persona %s: %w", src, err)
+	}
+	if err := m.CreatePersonaFromYAMLTemplate(dst, data); err != nil {
+		return err
+	}
+	if !withHistory {
+		return nil
+	}
+
+	// Conversations are copied for every session, not only the current one
+	srcDir, dstDir := filepath.Join(m.userPersonasDir(), src), filepath.Join(m.userPersonasDir(), dst)
+	for _, file := range []string{"history.yaml", "branches.yaml"} {
+		data, err := os.ReadFile(filepath.Join(srcDir, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if err := os.WriteFile(filepath.Join(dstDir, file), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+
+	sessions := filepath.Join(srcDir, "sessions")
+	if _, err := os.Stat(sessions); err == nil {
+		// Only the empty history of the current session is there yet, which
+		// CopyFS would refuse to overwrite
+		if err := os.RemoveAll(filepath.Join(dstDir, "sessions")); err != nil {
+			return fmt.Errorf("failed to copy sessions: %w", err)
+		}
+		if err := os.CopyFS(filepath.Join(dstDir, "sessions"), os.DirFS(sessions)); err != nil {
+			return fmt.Errorf("failed to copy sessions: %w", err)
+		}
+	}
+	return nil
+}
+
+// ArchivePersona moves a persona and its history out of the personas
+// directory, so it no longer shows up, without deleting anything
+func (m *Manager) ArchivePersona(name string) error {
+	if name == "persona" {
+		return fmt.Errorf("cannot archive the default persona persona")
+	}
+	dir, err := m.userPersona(name)
+	if err != nil {
+		return err
+	}
+
+	archived := filepath.Join(m.GetArchivePath(), name)
+	if _, err := os.Stat(archived); err == nil {
+		return fmt.Errorf("an archived persona %s already exists", name)
+	}
+	if err := os.MkdirAll(m.GetArchivePath(), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := os.Rename(dir, archived); err != nil {
+		return fmt.Errorf("failed to archive persona %s: %w", name, err)
+	}
+	return nil
+}
+
+// UnarchivePersona brings an archived persona back
+func (m *Manager) UnarchivePersona(name string) error {
+	archived := filepath.Join(m.GetArchivePath(), name)
+	if _, err := os.Stat(archived); err != nil {
+		return fmt.Errorf("persona %s is not archived", name)
+	}
+	if err := m.checkNewPersona(name); err != nil {
+		return err
+	}
+	if err := os.Rename(archived, filepath.Join(m.userPersonasDir(), name)); err != nil {
+		return fmt.Errorf("failed to unarchive persona %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListArchivedPersonas returns the names of the archived personas
+func (m *Manager) ListArchivedPersonas() ([]string, error) {
+	entries, err := os.ReadDir(m.GetArchivePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// trashPersona moves a persona directory to the trash
+func (m *Manager) trashPersona(name, dir string) error {
+	if err := os.MkdirAll(m.GetTrashPath(), 0755); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	trashed := filepath.Join(m.GetTrashPath(), name+"."+time.Now().Format(trashTimeFormat))
+	// Deleting the same persona twice in a second keeps both copies
+	for i := 2; ; i++ {
+		if _, err := os.Stat(trashed); os.IsNotExist(err) {
+			break
+		}
+		trashed = filepath.Join(m.GetTrashPath(), fmt.Sprintf("%s.%s-%d", name, time.Now().Format(trashTimeFormat), i))
+	}
+
+	if err := os.Rename(dir, trashed); err != nil {
+		return fmt.Errorf("failed to move persona %s to the trash: %w", name, err)
+	}
+	return nil
+}
+
+// ListTrash returns the deleted personas, most recent first
+func (m *Manager) ListTrash() ([]TrashedPersona, error) {
+	entries, err := os.ReadDir(m.GetTrashPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashedPersona{}, nil
+		}
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	trashed := []TrashedPersona{}
+	for _, entry := range entries {
+		// Entries are named <persona>.<time>, with a -N suffix for duplicates
+		name, stamp, ok := strings.Cut(entry.Name(), ".")
+		if !entry.IsDir() || !ok || len(stamp) < len(trashTimeFormat) {
+			continue
+		}
+		deletedAt, err := time.ParseInLocation(trashTimeFormat, stamp[:len(trashTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		trashed = append(trashed, TrashedPersona{
+			Name:      name,
+			DeletedAt: deletedAt,
+			Path:      filepath.Join(m.GetTrashPath(), entry.Name()),
+		})
+	}
+
+	slices.SortStableFunc(trashed, func(a, b TrashedPersona) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return trashed, nil
+}
+
+// RestorePersona restores the most recently deleted persona with that name
+func (m *Manager) RestorePersona(name string) error {
+	trashed, err := m.ListTrash()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range trashed {
+		if t.Name != name {
+			continue
+		}
+		if err := m.checkNewPersona(name); err != nil {
+			return err
+		}
+		if err := os.Rename(t.Path, filepath.Join(m.userPersonasDir(), name)); err != nil {
+			return fmt.Errorf("failed to restore persona %s: %w", name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("persona %s is not in the trash", name)
+}
@@ -1,13 +1,21 @@
 package storage
 
 import (
+	"bytes"
+	"cmp"
 	_ "embed"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/ctrl-vfr/persona/internal/config"
+	"github.com/ctrl-vfr/persona/internal/group"
 	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/schema"
+	"github.com/ctrl-vfr/persona/internal/usage"
 
 	"gopkg.in/yaml.v3"
 )
@@ -38,6 +46,23 @@ var racoonYAML []byte
 
 type Manager struct {
 	BasePath string
+	// Session selects the conversation persona histories are read from and
+	// written to, to keep projects apart. Empty for the default conversation.
+	Session string
+	// Context is added to the system prompt of the personas loaded
+	Context string
+	// Profile selects a configuration profile instead of the active one
+	Profile string
+
+	// meter is shared by the API clients, see UsageMeter
+	meter *usage.Meter
+	// personaPaths caches the persona_paths of the configuration, see
+	// configPersonaPaths
+	personaPaths struct {
+		sync.Mutex
+		key   string
+		paths []string
+	}
 }
 
 // BuiltinPersona represents a built-in persona template
@@ -58,7 +83,12 @@ func GetBuiltinPersonas() []BuiltinPersona {
 	}
 }
 
+// NewManager returns a manager for ~/.persona, or for $PERSONA_HOME when set
 func NewManager() (*Manager, error) {
+	if home := os.Getenv("PERSONA_HOME"); home != "" {
+		return &Manager{BasePath: home}, nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
@@ -76,11 +106,15 @@ func (m *Manager) InitializeStructure() error {
 	}
 
 	// Create personas directory
-	personasDir := filepath.Join(m.BasePath, "personas")
-	if err := os.MkdirAll(personasDir, 0755); err != nil {
+	if err := os.MkdirAll(m.userPersonasDir(), 0755); err != nil {
 		return fmt.Errorf("failed to create personas directory: %w", err)
 	}
 
+	// Create shared prompt fragments directory
+	if err := os.MkdirAll(m.GetFragmentsPath(), 0755); err != nil {
+		return fmt.Errorf("failed to create fragments directory: %w", err)
+	}
+
 	// Create default config.yaml if it doesn't exist
 	configPath := m.GetConfigPath()
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -95,6 +129,11 @@ func (m *Manager) InitializeStructure() error {
 		return fmt.Errorf("failed to create persona persona: %w", err)
 	}
 
+	// Older versions created the default persona with the template's name
+	if err := m.repairDefaultPersonaName(); err != nil {
+		return fmt.Errorf("failed to repair persona persona: %w", err)
+	}
+
 	// Install built-in personas if they don't exist
 	if err := m.InstallBuiltinPersonas(); err != nil {
 		return fmt.Errorf("failed to install built-in personas: %w", err)
@@ -108,14 +147,174 @@ func (m *Manager) GetConfigPath() string {
 	return filepath.Join(m.BasePath, "config.yaml")
 }
 
-// GetPersonaPath returns the path to a persona's files
+// GetPersonaPath returns the path to a persona's files. The persona file is
+// looked up in every persona directory, the history always lives in the
+// user's own directory so read-only personas can still be chatted with.
 func (m *Manager) GetPersonaPath(name string) (personaPath, historyPath string) {
-	personaDir := filepath.Join(m.BasePath, "personas", name)
-	return filepath.Join(personaDir, "persona.yaml"), filepath.Join(personaDir, "history.yaml")
+	personaPath = m.userPersonaPath(name)
+	if source, ok := m.GetPersonaSource(name); ok {
+		personaPath = filepath.Join(source.Dir, name, "persona.yaml")
+	}
+	return personaPath, filepath.Join(m.conversationDir(name), "history.yaml")
+}
+
+// conversationDir returns the directory holding a persona's history for the
+// current session
+func (m *Manager) conversationDir(name string) string {
+	dir := filepath.Join(m.userPersonasDir(), name)
+	if m.Session != "" {
+		dir = filepath.Join(dir, "sessions", m.Session)
+	}
+	return dir
+}
+
+// createConversationDir creates the directory of a persona's history for the
+// current session. Read-only personas and new sessions have none yet.
+func (m *Manager) createConversationDir(name string) error {
+	if err := os.MkdirAll(m.conversationDir(name), 0755); err != nil {
+		return fmt.Errorf("failed to create persona directory: %w", err)
+	}
+	return nil
+}
+
+// GetFragmentsPath returns the directory holding shared prompt fragments
+func (m *Manager) GetFragmentsPath() string {
+	return filepath.Join(m.BasePath, "fragments")
+}
+
+// GetBranchesPath returns the path to a persona's conversation tree
+func (m *Manager) GetBranchesPath(name string) string {
+	return filepath.Join(m.conversationDir(name), "branches.yaml")
+}
+
+// GetAudioPath returns the directory keeping the voiced replies of a persona's
+// conversation, reused by the exports
+func (m *Manager) GetAudioPath(name string) string {
+	return filepath.Join(m.conversationDir(name), "audio")
+}
+
+// GetConversation loads a persona's conversation tree and reconciles it with
+// the given history, which may have been written by a command unaware of
+// branches
+func (m *Manager) GetConversation(name string, history []persona.Message) (*persona.Conversation, error) {
+	conversation := &persona.Conversation{}
+	if err := conversation.Load(m.GetBranchesPath(name)); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to load branches for persona %s: %w", name, err)
+		}
+		return persona.NewConversation(history), nil
+	}
+
+	conversation.Sync(history)
+	return conversation, nil
+}
+
+// SaveConversation saves a persona's conversation tree and its active path as history
+func (m *Manager) SaveConversation(name string, conversation *persona.Conversation) error {
+	if err := m.createConversationDir(name); err != nil {
+		return err
+	}
+
+	if err := conversation.Save(m.GetBranchesPath(name)); err != nil {
+		return fmt.Errorf("failed to save branches: %w", err)
+	}
+
+	return m.SaveHistory(name, conversation.Path())
+}
+
+// SaveHistory saves a persona's history in the user's directory
+func (m *Manager) SaveHistory(name string, history []persona.Message) error {
+	_, historyPath := m.GetPersonaPath(name)
+	if err := m.createConversationDir(name); err != nil {
+		return err
+	}
+
+	p := &persona.Persona{History: history}
+	if err := p.SaveHistory(historyPath); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	return nil
+}
+
+// GetGroupPath returns the paths to a group session's files
+func (m *Manager) GetGroupPath(name string) (sessionPath, historyPath string) {
+	groupDir := filepath.Join(m.BasePath, "groups", name)
+	return filepath.Join(groupDir, "session.yaml"), filepath.Join(groupDir, "history.yaml")
+}
+
+// GetGroupSession loads a group session and its transcript
+func (m *Manager) GetGroupSession(name string) (*group.Session, error) {
+	if err := ValidateGroupName(name); err != nil {
+		return nil, err
+	}
+	sessionPath, historyPath := m.GetGroupPath(name)
+
+	session := &group.Session{}
+	if err := session.Load(sessionPath, historyPath); err != nil {
+		return nil, fmt.Errorf("failed to load group session %s: %w", name, err)
+	}
+	return session, nil
+}
+
+// SaveGroupSession saves a group session and its transcript
+func (m *Manager) SaveGroupSession(session *group.Session) error {
+	if err := ValidateGroupName(session.Name); err != nil {
+		return err
+	}
+	sessionPath, historyPath := m.GetGroupPath(session.Name)
+	if err := os.MkdirAll(filepath.Dir(sessionPath), 0755); err != nil {
+		return fmt.Errorf("failed to create group directory: %w", err)
+	}
+
+	if err := session.Save(sessionPath, historyPath); err != nil {
+		return fmt.Errorf("failed to save group session: %w", err)
+	}
+	return nil
 }
 
-// GetConfig loads the configuration using the existing config module
+// GetConfig loads the configuration, overridden by the PERSONA_* environment
+// variables
 func (m *Manager) GetConfig() (*config.Config, error) {
+	cfg, err := m.GetConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.applyOverrides(cfg); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// applyOverrides applies the selected profile and the environment variables,
+// which also override the profile's settings
+func (m *Manager) applyOverrides(cfg *config.Config) error {
+	// PERSONA_PROFILE may select the profile
+	if _, err := cfg.ApplyEnv(); err != nil {
+		return err
+	}
+	profile := cmp.Or(m.Profile, cfg.Profile)
+	if profile == "" {
+		return nil
+	}
+	if err := cfg.ApplyProfile(profile); err != nil {
+		return err
+	}
+	_, err := cfg.ApplyEnv()
+	return err
+}
+
+// ActiveProfile returns the profile used by GetConfig, empty when none is
+func (m *Manager) ActiveProfile() string {
+	return m.currentConfig().Profile
+}
+
+// GetConfigFile loads the configuration file without the environment
+// overrides, to change it and save it back
+func (m *Manager) GetConfigFile() (*config.Config, error) {
+	if err := schema.Check(m.LintConfig()); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
 	cfg := config.NewConfig()
 	if err := cfg.Load(m.GetConfigPath()); err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
@@ -123,29 +322,57 @@ func (m *Manager) GetConfig() (*config.Config, error) {
 	return cfg, nil
 }
 
+// LintConfig validates the configuration file. A missing file has no diagnostics.
+func (m *Manager) LintConfig() []schema.Diagnostic {
+	data, err := os.ReadFile(m.GetConfigPath())
+	if err != nil {
+		return nil
+	}
+	return schema.ValidateConfig(m.GetConfigPath(), data)
+}
+
+// LintPersona validates a persona definition against the configured speech model
+func (m *Manager) LintPersona(name string) ([]schema.Diagnostic, error) {
+	personaPath, _ := m.GetPersonaPath(name)
+	data, err := os.ReadFile(personaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona %s: %w", name, err)
+	}
+
+	opts := schema.PersonaOptions{Directory: name}
+	opts.SpeechModel = m.currentConfig().Models.Speech
+	return schema.ValidatePersona(personaPath, data, opts), nil
+}
+
+// currentConfig loads the configuration without validating it, for the
+// settings that fall back to their defaults when it cannot be read
+func (m *Manager) currentConfig() *config.Config {
+	cfg := config.NewConfig()
+	if err := cfg.Load(m.GetConfigPath()); err != nil {
+		return config.NewConfig()
+	}
+	// An invalid override only leaves the settings after it unchanged
+	_ = m.applyOverrides(cfg)
+	return cfg
+}
+
 // SaveConfig saves the configuration using the existing config module
 func (m *Manager) SaveConfig(cfg *config.Config) error {
 	return cfg.Save(m.GetConfigPath())
 }
 
-// ListPersonas returns a list of all available persona names
+// ListPersonas returns a list of all available persona names, from every
+// persona directory
 func (m *Manager) ListPersonas() ([]string, error) {
-	personasDir := filepath.Join(m.BasePath, "personas")
-	entries, err := os.ReadDir(personasDir)
+	sources, err := m.ListPersonaSources()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
-		}
 		return nil, fmt.Errorf("failed to read personas directory: %w", err)
 	}
 
-	var personas []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			personas = append(personas, entry.Name())
-		}
+	personas := make([]string, 0, len(sources))
+	for _, source := range sources {
+		personas = append(personas, source.Name)
 	}
-
 	return personas, nil
 }
 
@@ -154,16 +381,20 @@ func (m *Manager) CreatePersona(name string) error {
 	return m.CreatePersonaFromYAMLTemplate(name, marcelineYAML)
 }
 
-// GetPersona loads a persona using the existing persona module
+// GetPersona loads a persona using the existing persona module, resolving
+// its parent, included fragments and template variables
 func (m *Manager) GetPersona(name string) (*persona.Persona, error) {
-	personaPath, historyPath := m.GetPersonaPath(name)
-
-	p := &persona.Persona{}
-	if err := p.LoadPersona(personaPath); err != nil {
-		return nil, fmt.Errorf("failed to load persona %s: %w", name, err)
+	p, err := m.loadPersona(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.Variables = m.templateVariables()
+	if m.Context != "" {
+		p.AddContext(m.Context)
 	}
 
 	// Load history if it exists
+	_, historyPath := m.GetPersonaPath(name)
 	if err := p.LoadHistory(historyPath); err != nil {
 		// If history file doesn't exist, that's okay - just start with empty history
 		if !os.IsNotExist(err) {
@@ -174,23 +405,175 @@ func (m *Manager) GetPersona(name string) (*persona.Persona, error) {
 	return p, nil
 }
 
-// SavePersona saves a persona using the existing persona module
+// loadPersona reads a persona definition and composes it with its parents.
+// chain holds the personas already being loaded, to detect cycles.
+func (m *Manager) loadPersona(name string, chain []string) (*persona.Persona, error) {
+	for _, loading := range chain {
+		if loading == name {
+			return nil, fmt.Errorf("persona inheritance cycle: %s", strings.Join(append(chain, name), " -> "))
+		}
+	}
+
+	diagnostics, err := m.LintPersona(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Check(diagnostics); err != nil {
+		return nil, fmt.Errorf("failed to load persona %s: %w", name, err)
+	}
+
+	personaPath, _ := m.GetPersonaPath(name)
+	p := &persona.Persona{}
+	if err := p.LoadPersona(personaPath); err != nil {
+		return nil, fmt.Errorf("failed to load persona %s: %w", name, err)
+	}
+	// The directory names the persona, whatever an older version wrote
+	p.Name = name
+
+	var parent *persona.Persona
+	if p.Extends != "" {
+		var err error
+		parent, err = m.loadPersona(p.Extends, append(chain, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to extend persona %s: %w", name, err)
+		}
+	}
+
+	fragments := make([]string, 0, len(p.Includes))
+	source, _ := m.GetPersonaSource(name)
+	for _, include := range p.Includes {
+		data, err := os.ReadFile(m.fragmentPath(include, source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to include fragment %s in persona %s: %w", include, name, err)
+		}
+		fragments = append(fragments, string(data))
+	}
+
+	p.Compose(parent, fragments)
+	return p, nil
+}
+
+// fragmentPath resolves an include: absolute and ~/ paths are used as is,
+// other paths are relative to the fragments directory. Personas from a
+// read-only directory first look in the fragments directory it holds.
+func (m *Manager) fragmentPath(include string, source PersonaSource) string {
+	if strings.HasPrefix(include, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, include[2:])
+		}
+	}
+	if filepath.IsAbs(include) {
+		return include
+	}
+	if source.ReadOnly {
+		shared := filepath.Join(source.Dir, "fragments", include)
+		if _, err := os.Stat(shared); err == nil {
+			return shared
+		}
+	}
+	return filepath.Join(m.GetFragmentsPath(), include)
+}
+
+// templateVariables returns the values of the variables available to persona prompts
+func (m *Manager) templateVariables() map[string]string {
+	userName := ""
+	if cfg, err := m.GetConfig(); err == nil {
+		userName = cfg.User.Name
+	}
+	// Fall back to the account name so prompts never show a raw placeholder
+	for _, env := range []string{"USERNAME", "USER"} {
+		if userName == "" {
+			userName = os.Getenv(env)
+		}
+	}
+	if userName == "" {
+		userName = "l'utilisateur"
+	}
+
+	return map[string]string{
+		"user.name": userName,
+	}
+}
+
+// GetPersonaDefinition loads a persona as written in its file, without
+// resolving its parent, fragments or variables, so it can be edited and saved
+func (m *Manager) GetPersonaDefinition(name string) (*persona.Persona, error) {
+	personaPath, historyPath := m.GetPersonaPath(name)
+
+	p := &persona.Persona{}
+	if err := p.LoadPersona(personaPath); err != nil {
+		return nil, fmt.Errorf("failed to load persona %s: %w", name, err)
+	}
+	if err := p.LoadHistory(historyPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load history for persona %s: %w", name, err)
+	}
+	// The directory names the persona, so that saving it writes it back there
+	p.Name = name
+	return p, nil
+}
+
+// ValidatePersonaName checks that a name can be used as a persona directory
+func ValidatePersonaName(name string) error {
+	if !personaName.MatchString(name) {
+		return fmt.Errorf("invalid persona name %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+var personaName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateGroupName checks that a name can be used as a group session
+// directory. Default names join the participants with '+'.
+func ValidateGroupName(name string) error {
+	if !groupName.MatchString(name) {
+		return fmt.Errorf("invalid group session name %q: use letters, digits, '-', '_' and '+'", name)
+	}
+	return nil
+}
+
+var groupName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_+-]*$`)
+
+// SavePersona validates a persona and saves it using the existing persona module
 func (m *Manager) SavePersona(name string, p *persona.Persona) error {
-	personaDir := filepath.Join(m.BasePath, "personas", name)
+	if err := ValidatePersonaName(name); err != nil {
+		return err
+	}
+
+	// Saving a read-only persona creates a copy in the user's directory,
+	// which takes precedence over it
+	personaPath, historyPath := m.GetPersonaPath(name)
+	personaPath = m.userPersonaPath(name)
+	personaDir := filepath.Dir(personaPath)
+
+	// The history lives in its own file
+	definition := *p
+	definition.History = nil
+
+	// Validate the file as it will be written
+	data, err := yaml.Marshal(&definition)
+	if err != nil {
+		return fmt.Errorf("failed to marshal persona: %w", err)
+	}
+	opts := schema.PersonaOptions{Directory: name}
+	opts.SpeechModel = m.currentConfig().Models.Speech
+	if err := schema.Check(schema.ValidatePersona(personaPath, data, opts)); err != nil {
+		return err
+	}
 
 	// Ensure directory exists
 	if err := os.MkdirAll(personaDir, 0755); err != nil {
 		return fmt.Errorf("failed to create persona directory: %w", err)
 	}
 
-	personaPath, historyPath := m.GetPersonaPath(name)
-
 	// Save persona using existing module
-	if err := p.SavePersona(personaPath); err != nil {
+	if err := definition.SavePersona(personaPath); err != nil {
 		return fmt.Errorf("failed to save persona: %w", err)
 	}
 
 	// Save history using existing module
+	if err := m.createConversationDir(name); err != nil {
+		return err
+	}
 	if err := p.SaveHistory(historyPath); err != nil {
 		return fmt.Errorf("failed to save history: %w", err)
 	}
@@ -198,15 +581,19 @@ func (m *Manager) SavePersona(name string, p *persona.Persona) error {
 	return nil
 }
 
-// DeletePersona removes a persona and all its files
+// DeletePersona moves a persona and all its files to the trash, from where
+// RestorePersona can bring it back
 func (m *Manager) DeletePersona(name string) error {
 	// Prevent deletion of the default persona persona
 	if name == "persona" {
 		return fmt.Errorf("cannot delete the default persona persona")
 	}
 
-	personaDir := filepath.Join(m.BasePath, "personas", name)
-	return os.RemoveAll(personaDir)
+	dir, err := m.userPersona(name)
+	if err != nil {
+		return err
+	}
+	return m.trashPersona(name, dir)
 }
 
 // PersonaExists checks if a persona exists
@@ -216,21 +603,70 @@ func (m *Manager) PersonaExists(name string) bool {
 	return !os.IsNotExist(err)
 }
 
-// GetDefaultPersona returns the default persona persona
+// GetDefaultPersona returns the default persona
 func (m *Manager) GetDefaultPersona() (*persona.Persona, error) {
-	return m.GetPersona("persona")
+	return m.GetPersona(m.DefaultPersonaName())
+}
+
+// DefaultPersonaName returns the configured default persona, or the persona
+// named "persona" when none is configured or it no longer exists
+func (m *Manager) DefaultPersonaName() string {
+	if name := m.currentConfig().DefaultPersona; name != "" && m.PersonaExists(name) {
+		return name
+	}
+	return "persona"
 }
 
-// InstallBuiltinPersonas installs all built-in personas if they don't exist
+// SetDefaultPersona makes name the default persona
+func (m *Manager) SetDefaultPersona(name string) error {
+	if !m.PersonaExists(name) {
+		return fmt.Errorf("persona %s does not exist", name)
+	}
+
+	cfg, err := m.GetConfigFile()
+	if err != nil {
+		return err
+	}
+	cfg.DefaultPersona = name
+	if name == "persona" {
+		cfg.DefaultPersona = ""
+	}
+	return m.SaveConfig(cfg)
+}
+
+// InstallBuiltinPersonas installs the built-in personas that were never
+// installed, so that deleted ones are not brought back
 func (m *Manager) InstallBuiltinPersonas() error {
+	state, err := m.loadBuiltinsState()
+	if err != nil {
+		return err
+	}
+
+	changed := false
 	for _, builtinPersona := range GetBuiltinPersonas() {
-		if !m.PersonaExists(builtinPersona.Name) {
+		if _, ok := state[builtinPersona.Name]; ok {
+			continue
+		}
+
+		if _, err := os.Stat(m.userPersonaPath(builtinPersona.Name)); err == nil {
+			// Installed by a version that did not record built-ins
+			state[builtinPersona.Name] = m.adoptBuiltin(builtinPersona)
+		} else if m.PersonaExists(builtinPersona.Name) {
+			// Provided by a catalog, which takes care of it
+			continue
+		} else {
 			if err := m.CreatePersonaFromYAMLTemplate(builtinPersona.Name, builtinPersona.Data); err != nil {
 				return fmt.Errorf("failed to install built-in persona '%s': %w", builtinPersona.Name, err)
 			}
+			state[builtinPersona.Name] = installedRecord(builtinPersona)
 		}
+		changed = true
 	}
-	return nil
+
+	if !changed {
+		return nil
+	}
+	return m.saveBuiltinsState(state)
 }
 
 // CreatePersonaFromYAMLTemplate creates a persona from a YAML template if it doesn't exist
@@ -239,7 +675,8 @@ func (m *Manager) CreatePersonaFromYAMLTemplate(name string, template []byte) er
 		return nil // Already exists
 	}
 
-	personaDir := filepath.Join(m.BasePath, "personas", name)
+	personaPath, historyPath := m.GetPersonaPath(name)
+	personaDir := filepath.Dir(personaPath)
 
 	// Create persona directory
 	if err := os.MkdirAll(personaDir, 0755); err != nil {
@@ -247,9 +684,8 @@ func (m *Manager) CreatePersonaFromYAMLTemplate(name string, template []byte) er
 	}
 
 	// Create persona.yaml from template
-	personaPath, historyPath := m.GetPersonaPath(name)
 
-	if err := os.WriteFile(personaPath, template, 0644); err != nil {
+	if err := os.WriteFile(personaPath, withName(template, name), 0644); err != nil {
 		return fmt.Errorf("failed to create persona file: %w", err)
 	}
 
@@ -259,9 +695,38 @@ func (m *Manager) CreatePersonaFromYAMLTemplate(name string, template []byte) er
 	if err != nil {
 		return fmt.Errorf("failed to marshal empty history: %w", err)
 	}
+	if err := m.createConversationDir(name); err != nil {
+		return err
+	}
 	if err := os.WriteFile(historyPath, yamlHistoryData, 0644); err != nil {
 		return fmt.Errorf("failed to create history file: %w", err)
 	}
 
 	return nil
 }
+
+// repairDefaultPersonaName renames the default persona created by older
+// versions as "marceline" so that it matches its directory
+func (m *Manager) repairDefaultPersonaName() error {
+	personaPath := m.userPersonaPath("persona")
+	data, err := os.ReadFile(personaPath)
+	if err != nil {
+		return nil
+	}
+	if !bytes.Contains(data, []byte("\nname: marceline\n")) && !bytes.HasPrefix(data, []byte("name: marceline\n")) {
+		return nil
+	}
+	return os.WriteFile(personaPath, withName(data, "persona"), 0644)
+}
+
+var templateName = regexp.MustCompile(`(?m)^name:.*$`)
+
+// withName sets the name field of a persona template, so that it matches the
+// directory the persona is created in
+func withName(template []byte, name string) []byte {
+	line := []byte("name: " + name)
+	if templateName.Match(template) {
+		return templateName.ReplaceAllLiteral(template, line)
+	}
+	return append(append(line, '\n'), template...)
+}
@@ -0,0 +1,622 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/bundle"
+	"github.com/ctrl-vfr/persona/internal/group"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/search"
+)
+
+func TestManager_GetPersonaComposesParentsAndFragments(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := os.MkdirAll(m.GetFragmentsPath(), 0755); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.GetFragmentsPath(), "team.md"), []byte("L'équipe de {{user.name}}."), 0644); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	base := []byte("name: base\nvoice:\n  name: nova\nprompt: Tu es un assistant.\n")
+	child := []byte("name: child\nextends: base\nprompt: Tu es drôle.\nincludes:\n  - team.md\n")
+	if err := m.CreatePersonaFromYAMLTemplate("base", base); err != nil {
+		t.Fatalf("Failed to create base: %v", err)
+	}
+	if err := m.CreatePersonaFromYAMLTemplate("child", child); err != nil {
+		t.Fatalf("Failed to create child: %v", err)
+	}
+	if err := os.WriteFile(m.GetConfigPath(), []byte("user:\n  name: Alice\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	p, err := m.GetPersona("child")
+	if err != nil {
+		t.Fatalf("Failed to load persona: %v", err)
+	}
+	if p.Voice.Name != "nova" {
+		t.Errorf("Expected inherited voice 'nova', got '%s'", p.Voice.Name)
+	}
+	expected := "Tu es un assistant.\n\nTu es drôle.\n\nL'équipe de Alice."
+	if got := p.SystemMessage().Content; got != expected {
+		t.Errorf("Expected system prompt %q, got %q", expected, got)
+	}
+}
+
+func TestManager_GetPersonaDetectsCycles(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.CreatePersonaFromYAMLTemplate("a", []byte("name: a\nextends: b\n")); err != nil {
+		t.Fatalf("Failed to create a: %v", err)
+	}
+	if err := m.CreatePersonaFromYAMLTemplate("b", []byte("name: b\nextends: a\n")); err != nil {
+		t.Fatalf("Failed to create b: %v", err)
+	}
+
+	_, err := m.GetPersona("a")
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("Expected an inheritance cycle error, got %v", err)
+	}
+}
+
+func TestManager_CreatePersonaMatchesDirectory(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.CreatePersona("sherlock"); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+
+	p, err := m.GetPersona("sherlock")
+	if err != nil {
+		t.Fatalf("Failed to load persona created from template: %v", err)
+	}
+	if p.Name != "sherlock" {
+		t.Errorf("Expected name 'sherlock', got '%s'", p.Name)
+	}
+}
+
+func TestManager_GetPersonaFromOlderVersion(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	personaPath, _ := m.GetPersonaPath("sherlock")
+	if err := os.MkdirAll(filepath.Dir(personaPath), 0755); err != nil {
+		t.Fatalf("Failed to create persona directory: %v", err)
+	}
+	// Older versions wrote the name of the template in every persona
+	data := []byte("name: marceline\nvoice:\n  name: nova\nprompt: Tu es Sherlock.\n")
+	if err := os.WriteFile(personaPath, data, 0644); err != nil {
+		t.Fatalf("Failed to write persona: %v", err)
+	}
+
+	p, err := m.GetPersona("sherlock")
+	if err != nil {
+		t.Fatalf("Failed to load persona in the older format: %v", err)
+	}
+	if p.Name != "sherlock" {
+		t.Errorf("Expected name 'sherlock', got '%s'", p.Name)
+	}
+}
+
+func TestManager_GetPersonaRejectsInvalidFiles(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.CreatePersonaFromYAMLTemplate("typo", []byte("name: typo\nvoise:\n  name: nova\nprompt: p\n")); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+
+	_, err := m.GetPersona("typo")
+	if err == nil || !strings.Contains(err.Error(), `persona.yaml:2:1: error: unknown field "voise"`) {
+		t.Errorf("Expected the diagnostic in the error, got %v", err)
+	}
+}
+
+func TestManager_ExportImportPersona(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.CreatePersonaFromYAMLTemplate("sherlock", []byte("name: sherlock\nvoice:\n  name: onyx\nprompt: Tu es Sherlock.\n")); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+
+	b, err := m.ExportPersona("sherlock", bundle.Manifest{Author: "Alice"}, false)
+	if err != nil {
+		t.Fatalf("Failed to export persona: %v", err)
+	}
+	if _, ok := b.Files[bundle.HistoryFile]; ok {
+		t.Error("History should only be exported with withHistory")
+	}
+
+	if err := m.ImportPersona(b, "sherlock", false); !errors.Is(err, ErrPersonaExists) {
+		t.Errorf("Expected ErrPersonaExists, got %v", err)
+	}
+
+	name := m.AvailablePersonaName("sherlock")
+	if name != "sherlock-2" {
+		t.Errorf("Expected 'sherlock-2', got '%s'", name)
+	}
+	if err := m.ImportPersona(b, name, false); err != nil {
+		t.Fatalf("Failed to import persona: %v", err)
+	}
+	p, err := m.GetPersona(name)
+	if err != nil {
+		t.Fatalf("Failed to load imported persona: %v", err)
+	}
+	if p.Name != "sherlock-2" || p.Voice.Name != "onyx" {
+		t.Errorf("Unexpected imported persona: %+v", p)
+	}
+
+	b.Files[bundle.PersonaFile] = []byte("name: sherlock\nvoice:\n  name: onyx\n")
+	if err := m.ImportPersona(b, "sherlock", true); err == nil {
+		t.Error("Expected invalid personas to be rejected")
+	}
+}
+
+func TestManager_PersonaPaths(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	team := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	writePersona := func(dir, name, prompt string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		data := "name: " + name + "\nvoice:\n  name: nova\nprompt: " + prompt + "\n"
+		if err := os.WriteFile(filepath.Join(dir, name, "persona.yaml"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePersona(team, "sherlock", "Équipe")
+	writePersona(team, "watson", "Équipe")
+	writePersona(filepath.Join(m.BasePath, "personas"), "watson", "Local")
+	if err := os.WriteFile(m.GetConfigPath(), []byte("persona_paths:\n  - "+team+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := m.ListPersonaSources()
+	if err != nil {
+		t.Fatalf("Failed to list personas: %v", err)
+	}
+	if len(sources) != 2 || sources[0].Name != "sherlock" || !sources[0].ReadOnly || sources[1].ReadOnly {
+		t.Errorf("Expected a read-only sherlock and a local watson, got %+v", sources)
+	}
+
+	p, err := m.GetPersona("watson")
+	if err != nil || p.Prompt != "Local" {
+		t.Errorf("Expected the user's watson to take precedence, got %v, %v", p, err)
+	}
+
+	if err := m.SaveHistory("sherlock", []persona.Message{{Role: "user", Content: "Bonjour"}}); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.BasePath, "personas", "sherlock", "history.yaml")); err != nil {
+		t.Errorf("History should be written to the user's directory: %v", err)
+	}
+	p, err = m.GetPersona("sherlock")
+	if err != nil || len(p.History) != 1 {
+		t.Errorf("Expected sherlock with its history, got %v, %v", p, err)
+	}
+	if source, _ := m.GetPersonaSource("sherlock"); source.Dir != team {
+		t.Errorf("A history file should not shadow the read-only persona, got %+v", source)
+	}
+
+	if err := m.DeletePersona("sherlock"); err == nil {
+		t.Error("Expected read-only personas not to be deleted")
+	}
+}
+
+func TestManager_PersonaDirsFollowConfig(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	t.Setenv("XDG_CONFIG_HOME", "")
+	team, other := t.TempDir(), t.TempDir()
+
+	if err := os.WriteFile(m.GetConfigPath(), []byte("persona_paths:\n  - "+team+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if dirs := m.PersonaDirs(); len(dirs) != 2 || dirs[1] != team {
+		t.Fatalf("Expected the team directory, got %v", dirs)
+	}
+
+	// The cached directories are dropped once the configuration changes
+	if err := os.WriteFile(m.GetConfigPath(), []byte("persona_paths:\n  - "+other+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(m.GetConfigPath(), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if dirs := m.PersonaDirs(); len(dirs) != 2 || dirs[1] != other {
+		t.Errorf("Expected the new directory, got %v", dirs)
+	}
+}
+
+func TestManager_Builtins(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.InstallBuiltinPersonas(); err != nil {
+		t.Fatalf("Failed to install built-ins: %v", err)
+	}
+
+	statuses, err := m.ListBuiltinStatuses()
+	if err != nil {
+		t.Fatalf("Failed to get statuses: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Status != BuiltinUpToDate {
+			t.Errorf("Expected %s to be up to date, got %s", status.Name, status.Status)
+		}
+	}
+
+	// Deleted built-ins stay deleted
+	if err := m.DeletePersona("freud"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.InstallBuiltinPersonas(); err != nil {
+		t.Fatal(err)
+	}
+	if m.PersonaExists("freud") {
+		t.Error("A deleted built-in should not be reinstalled")
+	}
+	if status := builtinStatusOf(t, m, "freud"); status != BuiltinRemoved {
+		t.Errorf("Expected freud to be removed, got %s", status)
+	}
+}
+
+// builtinStatusOf returns the status of a built-in persona
+func builtinStatusOf(t *testing.T, m *Manager, name string) string {
+	t.Helper()
+	statuses, err := m.ListBuiltinStatuses()
+	if err != nil {
+		t.Fatalf("Failed to get statuses: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Name == name {
+			return status.Status
+		}
+	}
+	t.Fatalf("No built-in named %s", name)
+	return ""
+}
+
+func TestManager_UpgradeBuiltinMergesFields(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.InstallBuiltinPersonas(); err != nil {
+		t.Fatal(err)
+	}
+
+	builtin, _ := getBuiltin("merlin")
+	embedded, _ := parseDefinition(builtin.Data)
+
+	// Pretend merlin was installed from an older release with another voice
+	// and prompt, and that the user then edited the instructions and prompt
+	old := *embedded
+	old.Voice.Name = "alloy"
+	old.Prompt = "Ancien prompt"
+	oldData, _ := marshalDefinition(&old)
+	state, _ := m.loadBuiltinsState()
+	state["merlin"] = builtinRecord{Revision: "old", Base: string(oldData)}
+	if err := m.saveBuiltinsState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	local := old
+	local.Voice.Instructions = "Mes instructions"
+	local.Prompt = "Mon prompt"
+	localData, _ := marshalDefinition(&local)
+	if err := os.WriteFile(m.userPersonaPath("merlin"), localData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := builtinStatusOf(t, m, "merlin"); status != BuiltinDiverged {
+		t.Errorf("Expected merlin to have diverged, got %s", status)
+	}
+	if d, err := m.DiffBuiltin("merlin", false); err != nil || !strings.Contains(d, "-  name: alloy") {
+		t.Errorf("Expected the diff to show the voice change, got %q, %v", d, err)
+	}
+
+	result, err := m.UpgradeBuiltin("merlin", false)
+	if err != nil {
+		t.Fatalf("Failed to upgrade: %v", err)
+	}
+	if strings.Join(result.Updated, ",") != "voice.name" || strings.Join(result.Conflicts, ",") != "prompt" {
+		t.Errorf("Expected voice.name updated and a prompt conflict, got %+v", result)
+	}
+
+	upgraded, err := m.readBuiltinDefinition("merlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upgraded.Voice.Name != embedded.Voice.Name || upgraded.Voice.Instructions != "Mes instructions" || upgraded.Prompt != "Mon prompt" {
+		t.Errorf("Unexpected merge result: %+v", upgraded)
+	}
+	if status := builtinStatusOf(t, m, "merlin"); status != BuiltinModified {
+		t.Errorf("Expected merlin to be modified after the upgrade, got %s", status)
+	}
+
+	// Forcing takes the embedded value for conflicting fields
+	state, _ = m.loadBuiltinsState()
+	state["merlin"] = builtinRecord{Revision: "old", Base: string(oldData)}
+	if err := m.saveBuiltinsState(state); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.UpgradeBuiltin("merlin", true); err != nil {
+		t.Fatal(err)
+	}
+	upgraded, _ = m.readBuiltinDefinition("merlin")
+	if upgraded.Prompt != embedded.Prompt || upgraded.Voice.Instructions != "Mes instructions" {
+		t.Errorf("Expected the forced upgrade to take the embedded prompt only, got %+v", upgraded)
+	}
+}
+
+func TestManager_RenamePersona(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.CreatePersonaFromYAMLTemplate("freud", []byte("name: freud\nvoice:\n  name: ballad\nprompt: Tu es Freud.\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CreatePersonaFromYAMLTemplate("jung", []byte("name: jung\nextends: freud\nprompt: Tu es Jung.\n")); err != nil {
+		t.Fatal(err)
+	}
+	session := group.New([]string{"freud", "jung"}, "")
+	session.AddReply("freud", "Parlez-moi de votre mère")
+	if err := m.SaveGroupSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.RenamePersona("freud", "jung"); !errors.Is(err, ErrPersonaExists) {
+		t.Errorf("Expected ErrPersonaExists, got %v", err)
+	}
+	if err := m.RenamePersona("freud", "sigmund"); err != nil {
+		t.Fatalf("Failed to rename persona: %v", err)
+	}
+
+	if m.PersonaExists("freud") {
+		t.Error("The old persona should be gone")
+	}
+	p, err := m.GetPersona("sigmund")
+	if err != nil || p.Name != "sigmund" {
+		t.Fatalf("Expected the renamed persona, got %v, %v", p, err)
+	}
+	if p, err := m.GetPersona("jung"); err != nil || p.Voice.Name != "ballad" {
+		t.Errorf("Expected jung to still extend the renamed persona, got %v, %v", p, err)
+	}
+
+	renamed, err := m.GetGroupSession("jung+sigmund")
+	if err != nil {
+		t.Fatalf("Expected the group session to be renamed: %v", err)
+	}
+	if renamed.Transcript[0].Name != "sigmund" {
+		t.Errorf("Expected the transcript to use the new name, got %+v", renamed.Transcript[0])
+	}
+	if _, err := m.GetGroupSession("freud+jung"); err == nil {
+		t.Error("The old group session should be gone")
+	}
+}
+
+func TestManager_CopyArchiveAndTrash(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.CreatePersonaFromYAMLTemplate("freud", []byte("name: freud\nvoice:\n  name: ballad\nprompt: Tu es Freud.\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveHistory("freud", []persona.Message{{Role: "user", Content: "Bonjour"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.CopyPersona("freud", "sigmund", true); err != nil {
+		t.Fatalf("Failed to copy persona: %v", err)
+	}
+	if err := m.CopyPersona("freud", "carl", false); err != nil {
+		t.Fatalf("Failed to copy persona: %v", err)
+	}
+	if p, _ := m.GetPersona("sigmund"); p == nil || p.Name != "sigmund" || len(p.History) != 1 {
+		t.Errorf("Expected a copy with history, got %+v", p)
+	}
+	if p, _ := m.GetPersona("carl"); p == nil || len(p.History) != 0 {
+		t.Errorf("Expected a copy without history, got %+v", p)
+	}
+
+	if err := m.ArchivePersona("sigmund"); err != nil {
+		t.Fatalf("Failed to archive persona: %v", err)
+	}
+	if names, _ := m.ListPersonas(); slices.Contains(names, "sigmund") {
+		t.Error("Archived personas should not be listed")
+	}
+	if archived, _ := m.ListArchivedPersonas(); !slices.Equal(archived, []string{"sigmund"}) {
+		t.Errorf("Expected sigmund to be archived, got %v", archived)
+	}
+	if err := m.UnarchivePersona("sigmund"); err != nil {
+		t.Fatalf("Failed to unarchive persona: %v", err)
+	}
+	if p, _ := m.GetPersona("sigmund"); p == nil || len(p.History) != 1 {
+		t.Errorf("Expected the history to survive archiving, got %+v", p)
+	}
+
+	if err := m.DeletePersona("freud"); err != nil {
+		t.Fatalf("Failed to delete persona: %v", err)
+	}
+	trashed, err := m.ListTrash()
+	if err != nil || len(trashed) != 1 || trashed[0].Name != "freud" {
+		t.Fatalf("Expected freud in the trash, got %v, %v", trashed, err)
+	}
+	if err := m.RestorePersona("freud"); err != nil {
+		t.Fatalf("Failed to restore persona: %v", err)
+	}
+	if p, _ := m.GetPersona("freud"); p == nil || len(p.History) != 1 {
+		t.Errorf("Expected freud restored with its history, got %+v", p)
+	}
+	if err := m.RestorePersona("freud"); err == nil {
+		t.Error("Expected an error when the trash has no such persona")
+	}
+}
+
+func TestManager_DefaultPersona(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := os.WriteFile(m.GetConfigPath(), []byte("version: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := m.CreatePersonaFromYAMLTemplate("coach", []byte("name: coach\nvoice:\n  name: echo\nprompt: Tu es un coach.\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := m.DefaultPersonaName(); name != "persona" {
+		t.Errorf("Expected 'persona' by default, got '%s'", name)
+	}
+	if err := m.SetDefaultPersona("ghost"); err == nil {
+		t.Error("Expected an error for an unknown persona")
+	}
+	if err := m.SetDefaultPersona("coach"); err != nil {
+		t.Fatalf("Failed to set default persona: %v", err)
+	}
+	if name := m.DefaultPersonaName(); name != "coach" {
+		t.Errorf("Expected 'coach', got '%s'", name)
+	}
+
+	if err := m.RenamePersona("coach", "mentor"); err != nil {
+		t.Fatalf("Failed to rename persona: %v", err)
+	}
+	if name := m.DefaultPersonaName(); name != "mentor" {
+		t.Errorf("Expected the default persona to follow the rename, got '%s'", name)
+	}
+}
+
+func TestManager_SessionAndContext(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	if err := m.CreatePersonaFromYAMLTemplate("coach", []byte("name: coach\nvoice:\n  name: echo\nprompt: Tu es un coach.\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveHistory("coach", []persona.Message{{Role: "user", Content: "Bonjour"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Session = "api"
+	m.Context = "Le projet est écrit en Go."
+	p, err := m.GetPersona("coach")
+	if err != nil {
+		t.Fatalf("Failed to load persona: %v", err)
+	}
+	if len(p.History) != 0 {
+		t.Errorf("Expected an empty history in a new session, got %d messages", len(p.History))
+	}
+	expected := "Tu es un coach.\n\nLe projet est écrit en Go."
+	if got := p.SystemMessage().Content; got != expected {
+		t.Errorf("Expected system prompt %q, got %q", expected, got)
+	}
+
+	if err := m.SaveHistory("coach", []persona.Message{{Role: "user", Content: "Salut"}}); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+	_, historyPath := m.GetPersonaPath("coach")
+	if !strings.Contains(historyPath, filepath.Join("sessions", "api")) {
+		t.Errorf("Expected the history in the session directory, got %s", historyPath)
+	}
+
+	m.Session, m.Context = "", ""
+	if p, _ := m.GetPersona("coach"); p == nil || len(p.History) != 1 || p.History[0].Content != "Bonjour" {
+		t.Errorf("Expected the base history to be kept, got %+v", p)
+	}
+}
+
+func TestManager_CreateInSession(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir(), Session: "work"}
+	template := []byte("name: coach\nvoice:\n  name: echo\nprompt: Tu es un coach.\n")
+
+	if err := m.CreatePersonaFromYAMLTemplate("coach", template); err != nil {
+		t.Fatalf("Failed to create persona in a session: %v", err)
+	}
+	if err := m.SaveHistory("coach", []persona.Message{{Role: "user", Content: "Bonjour"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CopyPersona("coach", "mentor", true); err != nil {
+		t.Fatalf("Failed to copy persona in a session: %v", err)
+	}
+	if p, err := m.GetPersona("mentor"); err != nil || len(p.History) != 1 {
+		t.Errorf("Expected the copy with the session history, got %v, %v", p, err)
+	}
+
+	b, err := m.ExportPersona("coach", bundle.Manifest{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ImportPersona(b, "imported", false); err != nil {
+		t.Fatalf("Failed to import persona in a session: %v", err)
+	}
+
+	created := &persona.Persona{Name: "created", Voice: persona.Voice{Name: "nova"}, Prompt: "Tu es nouveau."}
+	if err := m.SavePersona("created", created); err != nil {
+		t.Fatalf("Failed to save a new persona in a session: %v", err)
+	}
+
+	if _, err := m.UpgradeBuiltin("freud", false); err != nil {
+		t.Fatalf("Failed to install a built-in in a session: %v", err)
+	}
+
+	for _, name := range []string{"coach", "mentor", "imported", "created", "freud"} {
+		_, historyPath := m.GetPersonaPath(name)
+		if _, err := os.Stat(historyPath); err != nil {
+			t.Errorf("Expected the session history of %s: %v", name, err)
+		}
+	}
+}
+
+func TestManager_SearchIndex(t *testing.T) {
+	m := &Manager{BasePath: t.TempDir()}
+	template := []byte("name: devops\nvoice:\n  name: nova\nprompt: Tu es devops.\n")
+	if err := m.CreatePersonaFromYAMLTemplate("devops", template); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+	if err := m.SaveHistory("devops", []persona.Message{persona.NewMessage("user", "Bonjour Kubernetes")}); err != nil {
+		t.Fatalf("Failed to save history: %v", err)
+	}
+	m.Session = "k8s"
+	if err := m.SaveHistory("devops", []persona.Message{persona.NewMessage("user", "kubectl apply")}); err != nil {
+		t.Fatalf("Failed to save session history: %v", err)
+	}
+	m.Session = ""
+	session := &group.Session{Name: "devops+sre", Transcript: []persona.Message{{Role: "assistant", Name: "sre", Content: "kubectl rollout undo"}}}
+	if err := m.SaveGroupSession(session); err != nil {
+		t.Fatalf("Failed to save group session: %v", err)
+	}
+
+	sources, err := m.HistorySources()
+	if err != nil {
+		t.Fatalf("Failed to list histories: %v", err)
+	}
+	if len(sources) != 3 || sources[1].Session != "k8s" || sources[2].Persona != search.GroupPersona || sources[2].Session != "devops+sre" {
+		t.Errorf("Expected the persona, session and group histories, got %+v", sources)
+	}
+
+	index, err := m.SearchIndex()
+	if err != nil {
+		t.Fatalf("Failed to index histories: %v", err)
+	}
+	results := index.Search(search.Query{Terms: []string{"kubectl", "apply"}})
+	if len(results) != 1 || results[0].Session != "k8s" {
+		t.Errorf("Expected the session message, got %+v", results)
+	}
+	results = index.Search(search.Query{Terms: []string{"rollout"}})
+	if len(results) != 1 || results[0].Session != "devops+sre" || results[0].Name != "sre" {
+		t.Errorf("Expected the group message with its speaker, got %+v", results)
+	}
+	if _, err := os.Stat(m.GetSearchIndexPath()); err != nil {
+		t.Errorf("Expected the index to be saved: %v", err)
+	}
+}
+
+func TestManager_GroupSessionNames(t *testing.T) {
+	base := t.TempDir()
+	m := &Manager{BasePath: filepath.Join(base, "persona")}
+
+	session := group.New([]string{"freud", "jung"}, "")
+	if err := m.SaveGroupSession(session); err != nil {
+		t.Fatalf("Expected the default name to be valid: %v", err)
+	}
+
+	session.Name = "../../escaped"
+	if err := m.SaveGroupSession(session); err == nil {
+		t.Error("Expected a name leaving the groups directory to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(base, "escaped")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected nothing written outside of the groups directory")
+	}
+	if _, err := m.GetGroupSession("../freud+jung"); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected an invalid name error, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ctrl-vfr/persona/internal/search"
+)
+
+// GetSearchIndexPath returns the path of the search index
+func (m *Manager) GetSearchIndexPath() string {
+	return filepath.Join(m.BasePath, "cache", "search.json")
+}
+
+// HistorySources lists the history files of every persona and session, and
+// the transcripts of the group sessions under search.GroupPersona
+func (m *Manager) HistorySources() ([]search.Source, error) {
+	entries, err := os.ReadDir(m.userPersonasDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read personas directory: %w", err)
+	}
+
+	var sources []search.Source
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(m.userPersonasDir(), entry.Name())
+		sources = append(sources, search.Source{Persona: entry.Name(), Path: filepath.Join(dir, "history.yaml")})
+
+		sessions, err := os.ReadDir(filepath.Join(dir, "sessions"))
+		if err != nil {
+			continue
+		}
+		for _, session := range sessions {
+			if session.IsDir() {
+				sources = append(sources, search.Source{
+					Persona: entry.Name(),
+					Session: session.Name(),
+					Path:    filepath.Join(dir, "sessions", session.Name(), "history.yaml"),
+				})
+			}
+		}
+	}
+
+	groups, err := os.ReadDir(filepath.Join(m.BasePath, "groups"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read groups directory: %w", err)
+	}
+	for _, entry := range groups {
+		if entry.IsDir() {
+			_, historyPath := m.GetGroupPath(entry.Name())
+			sources = append(sources, search.Source{Persona: search.GroupPersona, Session: entry.Name(), Path: historyPath})
+		}
+	}
+	return sources, nil
+}
+
+// SearchIndex returns the search index, updated with the histories changed
+// since the last search
+func (m *Manager) SearchIndex() (*search.Index, error) {
+	index, err := search.Open(m.GetSearchIndexPath())
+	if err != nil {
+		return nil, err
+	}
+	sources, err := m.HistorySources()
+	if err != nil {
+		return nil, err
+	}
+
+	if index.Update(sources) {
+		if err := index.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
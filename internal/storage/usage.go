@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"path/filepath"
+
+	"github.com/ctrl-vfr/persona/internal/config"
+	"github.com/ctrl-vfr/persona/internal/usage"
+)
+
+// GetUsagePath returns the path of the usage ledger
+func (m *Manager) GetUsagePath() string {
+	return filepath.Join(m.BasePath, "usage.jsonl")
+}
+
+// UsageMeter returns the meter recording the API calls, created from the
+// prices and budget of cfg on first use
+func (m *Manager) UsageMeter(cfg *config.Config) *usage.Meter {
+	if m.meter == nil {
+		m.meter = usage.NewMeter(usage.NewLedger(m.GetUsagePath()), cfg.Prices(), cfg.Budget())
+	}
+	return m.meter
+}
+
+// OverBudget reports whether the monthly budget is spent, once the meter is
+// in use
+func (m *Manager) OverBudget() bool {
+	return m.meter != nil && m.meter.OverBudget()
+}
@@ -1,23 +1,27 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/ctrl-vfr/persona/internal/config"
 	"github.com/ctrl-vfr/persona/internal/ffmpeg"
 	"github.com/ctrl-vfr/persona/internal/openai"
 	"github.com/ctrl-vfr/persona/internal/persona"
-	"github.com/ctrl-vfr/persona/internal/speak"
+	"github.com/ctrl-vfr/persona/internal/search"
 	"github.com/ctrl-vfr/persona/internal/storage"
 	"github.com/ctrl-vfr/persona/internal/watcher"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -41,8 +45,26 @@ type AppMode int
 const (
 	ModePersonaSelector AppMode = iota
 	ModeChat
+	ModeEditor
 )
 
+// AIClient is the subset of the OpenAI client used by the chat pipeline
+type AIClient interface {
+	Transcribe(audioFile io.Reader) (string, error)
+	GenerateAudio(text string, instructions string) (io.Reader, error)
+	Chat(messages []openai.Message) (string, error)
+	// Complete is Chat with the token usage and latency of the reply
+	Complete(messages []openai.Message) (*openai.Completion, error)
+}
+
+// PersonaClientFactory returns an AI client speaking in the voice of p
+type PersonaClientFactory func(p *persona.Persona) AIClient
+
+// Recorder records a voice message and returns the path of the audio file
+type Recorder interface {
+	Record() (string, error)
+}
+
 // PersonaItem pour la liste des personas
 type PersonaItem struct {
 	name        string
@@ -62,13 +84,21 @@ type ChatModel struct {
 	textArea    textarea.Model
 	spinner     spinner.Model
 	personaList list.Model
+	editor      *EditorModel
 
 	// Application state
-	state   ChatState
-	persona *persona.Persona
-	ai      *openai.OpenAI
-	manager *storage.Manager
-	config  *config.Config
+	state        ChatState
+	persona      *persona.Persona
+	conversation *persona.Conversation
+	ai           AIClient
+	manager      *storage.Manager
+	config       *config.Config
+	recorder     Recorder
+	player       func(audio io.Reader) error
+	// playback controls the reply being played by player
+	playback Playback
+	// personaClient creates the clients of the other personas
+	personaClient PersonaClientFactory
 
 	// Configuration for multi-mode support
 	openaiAPIKey string
@@ -77,6 +107,8 @@ type ChatModel struct {
 	personaWatcher  *watcher.PersonaWatcher
 	instanceManager *watcher.InstanceManager
 	heartbeatStop   chan bool
+	updates         chan tea.Msg
+	updatesDone     chan struct{}
 
 	// Display state
 	messages  []string
@@ -85,10 +117,30 @@ type ChatModel struct {
 	width     int
 	height    int
 
+	// Message selection and editing, as indexes into the persona history
+	selecting bool
+	selected  int
+	editing   bool
+	editIndex int
+	// undoRewind restores the branch left by a regeneration, should the new
+	// reply fail
+	undoRewind func()
+
+	// Search in the conversation, the matches being history indexes
+	searching     bool
+	searchInput   textinput.Model
+	searchMatches []int
+	searchPos     int
+
+	// Voice picker, to speak the selected message in another persona's voice
+	picking bool
+	voices  []string
+	voice   int
+
 	// Configuration
 	inputDevice      string
 	silenceThreshold int
-	silenceDuration  int
+	silenceDuration  float64
 
 	// Audio settings
 	isMuted bool
@@ -106,13 +158,29 @@ type transcriptionFinishedMsg struct {
 }
 
 type chatFinishedMsg struct {
-	response string
-	err      error
+	completion *openai.Completion
+	err        error
 }
 
 type audioFinishedMsg struct {
-	audioData []byte
-	err       error
+	// audio is the speech, read as it downloads
+	audio io.Reader
+	// replay is set when the user asked to hear a reply again, muted or not
+	replay bool
+	// reply is the ID of the message spoken, empty in the voice of another
+	// persona
+	reply string
+	err   error
+}
+
+type playbackFinishedMsg struct {
+	reply string
+	err   error
+}
+
+// speechReadMsg tells that the speech of a reply, muted, was read to its end
+type speechReadMsg struct {
+	reply string
 }
 
 type historyUpdateMsg struct {
@@ -123,7 +191,9 @@ type personaUpdateMsg struct {
 	persona *persona.Persona
 }
 
-func NewChatModel(p *persona.Persona, ai *openai.OpenAI, manager *storage.Manager, inputDevice string, silenceThreshold int, silenceDuration int) *ChatModel {
+// NewChatModel creates a chat model talking to p through ai. personaClient
+// creates the clients of the personas switched to or lent their voice.
+func NewChatModel(p *persona.Persona, ai AIClient, personaClient PersonaClientFactory, manager *storage.Manager, player Player, inputDevice string, silenceThreshold int, silenceDuration float64) *ChatModel {
 	// Get terminal size with fallback to minimum dimensions
 	width, height, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil || width == 0 || height == 0 {
@@ -157,31 +227,30 @@ func NewChatModel(p *persona.Persona, ai *openai.OpenAI, manager *storage.Manage
 	model := &ChatModel{
 		viewport:         vp,
 		textArea:         ta,
+		searchInput:      newSearchInput(),
 		spinner:          s,
 		state:            StateIdle,
 		persona:          p,
 		ai:               ai,
+		personaClient:    personaClient,
 		manager:          manager,
 		messages:         []string{},
 		width:            width,
 		height:           height,
+		recorder:         ffmpeg.New(inputDevice, silenceThreshold, silenceDuration),
+		playback:         player,
+		player:           player.Play,
 		inputDevice:      inputDevice,
 		silenceThreshold: silenceThreshold,
 		silenceDuration:  silenceDuration,
 		isMuted:          false,
 	}
 
-	// Initialize file watcher
-	if personaWatcher, err := watcher.NewPersonaWatcher(manager, p.Name); err == nil {
-		model.personaWatcher = personaWatcher
-		personaWatcher.Start()
-	}
+	model.loadConversation()
 
-	// Initialize instance manager
-	model.instanceManager = watcher.NewInstanceManager(manager)
-	if err := model.instanceManager.RegisterInstance(); err == nil {
-		model.heartbeatStop = model.instanceManager.StartHeartbeat()
-	}
+	// Initialize file watcher and instance manager; a missing persona
+	// directory only disables live reload
+	_ = model.initializeWatchers()
 
 	// Add initial greeting with responsive styling
 	model.addWelcomeMessage()
@@ -194,6 +263,7 @@ func (m *ChatModel) Init() tea.Cmd {
 	return tea.Batch(
 		textarea.Blink,
 		m.spinner.Tick,
+		m.listenForUpdates(),
 	)
 }
 
@@ -202,9 +272,56 @@ func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "esc":
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			// The persona editor handles Esc itself
+			if m.mode == ModeEditor {
+				break
+			}
+			// Esc leaves the voice picker, search, message selection or
+			// editing before quitting
+			if m.mode == ModeChat && m.picking {
+				m.picking = false
+				return m, nil
+			}
+			if m.mode == ModeChat && m.searching {
+				m.cancelSearch()
+				return m, nil
+			}
+			if m.mode == ModeChat && (m.selecting || m.editing) {
+				m.cancelSelection()
+				return m, nil
+			}
 			return m, tea.Quit
 		}
+
+	case historyUpdateMsg:
+		// Real-time history updates from other instances. Updates arriving
+		// mid-exchange are dropped: our own save will supersede them, and
+		// our own saves come back as the path already shown.
+		if m.persona != nil && m.state == StateIdle && !samePath(msg.history, m.conversation.Path()) {
+			var selected string
+			if m.selecting {
+				selected = m.persona.History[m.selected].ID
+			}
+			m.persona.History = msg.history
+			m.searching = false
+			m.loadConversation()
+			m.keepSelection(selected)
+			m.reRenderMessages()
+		}
+		return m, m.listenForUpdates()
+
+	case personaUpdateMsg:
+		if m.persona != nil && m.state == StateIdle {
+			m.persona = msg.persona
+			m.selecting = false
+			m.searching = false
+			m.loadConversation()
+			m.reRenderMessages()
+		}
+		return m, m.listenForUpdates()
 	}
 
 	// Handle mode-specific updates
@@ -213,6 +330,8 @@ func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updatePersonaSelector(msg)
 	case ModeChat:
 		return m.updateChat(msg)
+	case ModeEditor:
+		return m.updateEditor(msg)
 	default:
 		return m, nil
 	}
@@ -227,24 +346,42 @@ func (m *ChatModel) updatePersonaSelector(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = max(msg.Height, MIN_TERMINAL_HEIGHT)
 
 		// Recalculate list dimensions to use full terminal width (only if initialized)
-		if m.personaList.Items != nil {
+		if m.personaList.Items() != nil {
 			m.personaList.SetSize(m.width-4, m.height-4)
 		}
 
 	case tea.KeyMsg:
+		// A load error stays visible until the next key
+		m.errorMsg = ""
+
 		switch msg.String() {
 		case "enter":
 			// Get selected persona (only if persona list is initialized)
-			if m.personaList.Items != nil {
+			if m.personaList.Items() != nil {
 				if selectedItem := m.personaList.SelectedItem(); selectedItem != nil {
 					if persona, ok := selectedItem.(PersonaItem); ok {
 						err := m.SwitchToPersona(persona.name)
 						if err != nil {
-							m.errorMsg = fmt.Sprintf("Error changing persona: %v", err)
+							m.errorMsg = RenderLoadError(fmt.Sprintf("Impossible de charger %s", persona.name), err)
 							return m, nil
 						}
+						return m, m.listenForUpdates()
+					}
+				}
+			}
+		case "ctrl+n":
+			if m.personaList.FilterState() != list.Filtering {
+				return m, m.openEditor(nil)
+			}
+		case "ctrl+e":
+			if selectedItem := m.personaList.SelectedItem(); selectedItem != nil && m.personaList.FilterState() != list.Filtering {
+				if item, ok := selectedItem.(PersonaItem); ok {
+					original, err := m.manager.GetPersonaDefinition(item.name)
+					if err != nil {
+						m.errorMsg = RenderLoadError(fmt.Sprintf("Impossible de charger %s", item.name), err)
 						return m, nil
 					}
+					return m, m.openEditor(original)
 				}
 			}
 		case "ctrl+s":
@@ -257,7 +394,7 @@ func (m *ChatModel) updatePersonaSelector(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Update persona list (only if initialized)
-	if m.personaList.Items != nil {
+	if m.personaList.Items() != nil {
 		m.personaList, cmd = m.personaList.Update(msg)
 	}
 	return m, cmd
@@ -299,19 +436,59 @@ func (m *ChatModel) updateChat(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.reRenderMessages()
 
 	case tea.KeyMsg:
+		// Any key acknowledges an error and returns to the input
+		if m.state == StateError {
+			m.state = StateIdle
+			m.errorMsg = ""
+			m.statusMsg = ""
+			return m, nil
+		}
+
+		// Playback keys apply unless typing the next message
+		if m.state == StatePlaying && m.textArea.Value() == "" && controlPlayback(m.playback, msg.String()) {
+			return m, nil
+		}
+		if m.picking && m.state == StateIdle {
+			return m, m.updateVoicePicker(msg)
+		}
+		if m.searching && m.state == StateIdle {
+			return m, m.updateSearch(msg)
+		}
+		if m.selecting && m.state == StateIdle {
+			return m, m.updateSelection(msg)
+		}
+
 		switch msg.String() {
+		case "/":
+			// Search the conversation, unless typing a message
+			if m.state == StateIdle && !m.editing && m.textArea.Value() == "" {
+				return m, m.startSearch()
+			}
+		case "ctrl+p":
+			// Select a previous message to edit, regenerate or delete it
+			if m.state == StateIdle {
+				m.startSelection()
+				return m, nil
+			}
+		case "ctrl+o":
+			// Replay the last reply, from the speech cache
+			if m.state == StateIdle {
+				return m, m.replayLastReply()
+			}
 		case "ctrl+l":
 			// Clear conversation
 			if m.state == StateIdle {
 				m.clearConversation()
 			}
 		case "ctrl+m":
-			// Toggle mute
+			// Toggle mute; takes effect from the next reply
 			m.isMuted = !m.isMuted
-			if m.isMuted {
-				m.statusMsg = RenderMutedStatus(m.width)
-			} else {
-				m.statusMsg = ""
+			if m.state == StateIdle {
+				if m.isMuted {
+					m.statusMsg = RenderMutedStatus(m.width)
+				} else {
+					m.statusMsg = ""
+				}
 			}
 		case "ctrl+s":
 			// Switch back to persona selector
@@ -319,20 +496,30 @@ func (m *ChatModel) updateChat(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case "ctrl+r":
 			if m.state == StateIdle {
+				m.state = StateRecording
+				m.statusMsg = RenderRecordingStatus(m.width)
 				return m, m.startRecording()
 			}
 		case "enter":
 			if m.state == StateIdle && m.textArea.Value() != "" {
 				userMessage := strings.TrimSpace(m.textArea.Value())
 				m.textArea.Reset()
+				if m.editing {
+					// Submitting an edit starts a new branch at the edited turn
+					m.editing = false
+					if err := m.conversation.Rewind(m.editIndex); err != nil {
+						m.fail("Edit error", err)
+						return m, nil
+					}
+					m.persona.History = m.conversation.Path()
+				}
 				return m, m.sendTextMessage(userMessage)
 			}
 		}
 
 	case recordingFinishedMsg:
 		if msg.err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ Recording error: %v", msg.err)
+			m.fail("Recording error", msg.err)
 			return m, nil
 		}
 		m.state = StateTranscribing
@@ -341,44 +528,71 @@ func (m *ChatModel) updateChat(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case transcriptionFinishedMsg:
 		if msg.err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ Transcription error: %v", msg.err)
+			m.fail("Transcription error", msg.err)
 			return m, nil
 		}
-		m.addUserMessage(msg.text)
-		m.state = StateChatting
-		m.statusMsg = RenderThinkingStatus(m.width)
-		return m, m.sendMessage(msg.text)
+		return m, m.submitUserMessage(msg.text, persona.ModalityVoice)
 
 	case chatFinishedMsg:
+		undo := m.undoRewind
+		m.undoRewind = nil
 		if msg.err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ Chat error: %v", msg.err)
+			if undo != nil {
+				undo()
+				m.persona.History = m.conversation.Path()
+				m.reRenderMessages()
+			}
+			m.fail("Chat error", msg.err)
 			return m, nil
 		}
-		m.addAssistantMessage(msg.response)
+		reply := persona.NewMessage("assistant", msg.completion.Content)
+		reply.Model = msg.completion.Model
+		reply.Usage = persona.Usage(msg.completion.Usage)
+		reply.Latency = msg.completion.Latency.Round(time.Millisecond)
+		reply.ID = m.conversation.Append(reply)
+		m.persona.History = m.conversation.Path()
+		m.reRenderMessages()
+
+		// Save history (this will trigger file watcher in other instances)
+		if err := m.manager.SaveConversation(m.persona.Name, m.conversation); err != nil {
+			m.fail("History save error", err)
+			return m, nil
+		}
+
 		m.state = StateGeneratingAudio
 		m.statusMsg = RenderGeneratingAudioStatus(m.width)
-		return m, m.generateAudio(msg.response)
+		return m, m.generateAudio(reply)
 
 	case audioFinishedMsg:
 		if msg.err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ Audio generation error: %v", msg.err)
+			m.fail("Audio generation error", msg.err)
 			return m, nil
 		}
+		if m.isMuted && !msg.replay {
+			m.finishExchange()
+			reply := msg.reply
+			return m, tea.Sequence(discardAudio(msg.audio), func() tea.Msg { return speechReadMsg{reply: reply} })
+		}
 		m.state = StatePlaying
 		m.statusMsg = RenderPlayingStatus(m.width)
-		return m, m.playAudio(msg.audioData)
+		return m, m.playAudio(msg.audio, msg.reply)
 
-	case historyUpdateMsg:
-		// Handle real-time history updates from other instances
-		m.persona.History = msg.history
-		m.reRenderMessages()
+	case playbackFinishedMsg:
+		if msg.err != nil {
+			m.fail("Audio read error", msg.err)
+			return m, nil
+		}
+		m.finishExchange()
+		if err := m.keepAudio(msg.reply); err != nil {
+			m.fail("History save error", err)
+		}
+		return m, nil
 
-	case personaUpdateMsg:
-		// Handle persona updates
-		m.persona = msg.persona
+	case speechReadMsg:
+		if err := m.keepAudio(msg.reply); err != nil {
+			m.fail("History save error", err)
+		}
+		return m, nil
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -402,6 +616,8 @@ func (m *ChatModel) View() string {
 		return m.viewPersonaSelector()
 	case ModeChat:
 		return m.viewChat()
+	case ModeEditor:
+		return m.editor.View()
 	default:
 		return "Mode inconnu"
 	}
@@ -414,7 +630,7 @@ func (m *ChatModel) viewPersonaSelector() string {
 	sections = append(sections, RenderChatBoxTitle("🎭 Sélection de Persona", m.width))
 
 	// Only show persona list if it's initialized
-	if m.personaList.Items != nil {
+	if m.personaList.Items() != nil {
 		sections = append(sections, RenderChatBoxBorder(m.personaList.View(), m.width, m.height-8))
 	} else {
 		sections = append(sections, RenderChatBoxBorder("No personas available", m.width, m.height-8))
@@ -422,21 +638,21 @@ func (m *ChatModel) viewPersonaSelector() string {
 
 	// Simple help without box, using full width
 	var helpLines []string
-	helpLines = append(helpLines, "💡 Ctrl+C: Quitter | ↑/↓: Naviguer | Enter: Sélectionner | /: Rechercher")
+	helpLines = append(helpLines, "💡 Ctrl+C: Quitter | ↑/↓: Naviguer | Enter: Sélectionner | /: Rechercher | Ctrl+N: Nouveau | Ctrl+E: Éditer")
 	if m.persona != nil {
 		helpLines = append(helpLines, "   Ctrl+S: Retourner au chat")
 	}
 
-	if m.errorMsg != "" {
-		helpLines = append(helpLines, RenderError(m.errorMsg))
-		m.errorMsg = "" // Clear after showing
-	}
-
 	// Center the help text within the full terminal width
 	helpText := strings.Join(helpLines, " | ")
 	centeredHelp := lipgloss.PlaceHorizontal(m.width, lipgloss.Center, helpText)
 	sections = append(sections, centeredHelp)
 
+	// Load errors are already rendered, with one line per diagnostic
+	if m.errorMsg != "" {
+		sections = append(sections, m.errorMsg)
+	}
+
 	return strings.Join(sections, "\n")
 }
 
@@ -445,12 +661,23 @@ func (m *ChatModel) viewChat() string {
 
 	// Chat box title with decorative border
 	title := fmt.Sprintf("Chat avec %s", m.persona.Name)
-	if instances, err := m.instanceManager.GetActiveInstances(); err == nil && len(instances) > 1 {
-		title += fmt.Sprintf(" 👥 (%d instances)", len(instances))
+	if m.manager != nil && m.manager.Session != "" {
+		title += fmt.Sprintf(" · %s", m.manager.Session)
+	}
+	if m.config != nil && m.config.Profile != "" {
+		title += fmt.Sprintf(" [%s]", m.config.Profile)
+	}
+	if m.instanceManager != nil {
+		if instances, err := m.instanceManager.GetActiveInstances(); err == nil && len(instances) > 1 {
+			title += fmt.Sprintf(" 👥 (%d instances)", len(instances))
+		}
 	}
 	if m.isMuted {
 		title += " 🔇"
 	}
+	if m.manager != nil && m.manager.OverBudget() {
+		title += " 💸 Budget dépassé"
+	}
 	sections = append(sections, RenderChatBoxTitle(title, m.width))
 
 	// Chat history viewport wrapped in border
@@ -459,17 +686,40 @@ func (m *ChatModel) viewChat() string {
 	sections = append(sections, RenderChatBoxBorder(m.viewport.View(), m.width, m.height))
 
 	// Input area or status message in a box
-	if m.state == StateIdle {
-		sections = append(sections, RenderInputBox(m.textArea.View(), m.width))
-		sections = append(sections, RenderMuted("💡 Ctrl+R: Enregistrer | Enter: Envoyer | Ctrl+L: Effacer | Ctrl+M: Mute | Ctrl+S: Changer persona | Ctrl+C: Quitter"))
-	} else {
-		if m.errorMsg != "" {
-			sections = append(sections, RenderInputBox(RenderError(m.errorMsg), m.width))
-			m.errorMsg = "" // Clear after showing
-		} else if m.statusMsg != "" {
-			statusLine := m.spinner.View() + " " + m.statusMsg
-			sections = append(sections, RenderInputBox(statusLine, m.width))
+	switch {
+	case m.state == StateIdle && m.picking:
+		picker := fmt.Sprintf("🗣️  Dire avec la voix de : ‹ %s › (%d/%d)", m.voices[m.voice], m.voice+1, len(m.voices))
+		sections = append(sections, RenderInputBox(picker, m.width))
+		sections = append(sections, RenderMuted("💡 ←/→: Choisir le persona | Enter: Écouter | Esc: Annuler"))
+	case m.state == StateIdle && m.searching:
+		box := m.searchInput.View()
+		if len(m.searchMatches) > 0 {
+			content := m.persona.History[m.selected].Content
+			spans := search.Match(content, search.ParseTerms(m.searchInput.Value()))
+			snippet, highlights := search.Snippet(content, spans, m.viewport.Width-4)
+			box += "\n" + search.Highlight(snippet, highlights, RenderMatch)
 		}
+		sections = append(sections, RenderInputBox(box, m.width))
+		sections = append(sections, RenderMuted(fmt.Sprintf("💡 %s | ↑/↓: Résultat précédent/suivant | Enter: Sélectionner | Esc: Annuler", m.searchStatus())))
+	case m.state == StateIdle && m.selecting:
+		sections = append(sections, RenderInputBox(m.textArea.View(), m.width))
+		sections = append(sections, RenderMuted("💡 ↑/↓: Sélectionner | /: Rechercher | ←/→: Branches | E: Éditer | R: Régénérer | D: Supprimer | P: Écouter | V: Autre voix | Esc: Retour"))
+	case m.state == StateIdle && m.editing:
+		sections = append(sections, RenderInputBox(m.textArea.View(), m.width))
+		sections = append(sections, RenderMuted("💡 ✏️  Édition du message | Enter: Envoyer comme nouvelle branche | Esc: Annuler"))
+	case m.state == StateIdle:
+		sections = append(sections, RenderInputBox(m.textArea.View(), m.width))
+		sections = append(sections, RenderMuted("💡 Ctrl+R: Enregistrer | Enter: Envoyer | Ctrl+P: Sélectionner | /: Rechercher | Ctrl+O: Réécouter | Ctrl+L: Effacer | Ctrl+M: Mute | Ctrl+S: Changer persona | Ctrl+C: Quitter"))
+	case m.state == StateError:
+		sections = append(sections, RenderInputBox(RenderError(m.errorMsg), m.width))
+		sections = append(sections, RenderMuted("💡 Appuyez sur une touche pour continuer"))
+	case m.state == StatePlaying:
+		statusLine := m.spinner.View() + " " + renderPlayback(m.playback, m.statusMsg, m.width)
+		sections = append(sections, RenderInputBox(statusLine, m.width))
+		sections = append(sections, RenderMuted(PlaybackHelp))
+	case m.statusMsg != "":
+		statusLine := m.spinner.View() + " " + m.statusMsg
+		sections = append(sections, RenderInputBox(statusLine, m.width))
 	}
 
 	return strings.Join(sections, "\n")
@@ -486,24 +736,6 @@ func (m *ChatModel) addMessage(message string) {
 	m.viewport.GotoBottom()
 }
 
-func (m *ChatModel) addUserMessage(message string) {
-	totalMessages := len(m.persona.History)
-	messageIndex := totalMessages - 1
-	isLatest := true
-
-	rendered := RenderUserMessage(message, m.width, messageIndex, isLatest)
-	m.addMessage(rendered)
-}
-
-func (m *ChatModel) addAssistantMessage(message string) {
-	totalMessages := len(m.persona.History)
-	messageIndex := totalMessages - 1
-	isLatest := true
-
-	rendered := RenderAssistantMessage(m.persona.Name, message, m.width, messageIndex, isLatest)
-	m.addMessage(rendered)
-}
-
 func (m *ChatModel) addWelcomeMessage() {
 	welcomeMessage := "Bonjour ! Je suis prêt à discuter avec vous. 🎤 Tapez votre message ou utilisez Ctrl+R pour enregistrer un message vocal."
 	rendered := RenderAssistantMessage(m.persona.Name, welcomeMessage, m.width, 0, false)
@@ -511,17 +743,43 @@ func (m *ChatModel) addWelcomeMessage() {
 }
 
 func (m *ChatModel) addHistoryMessages() {
+	now := time.Now()
 	for i, msg := range m.persona.History {
 		isLatest := i == len(m.persona.History)-1
 
+		var rendered string
 		switch msg.Role {
 		case "user":
-			rendered := RenderUserMessage(msg.Content, m.width, i, isLatest)
-			m.addMessage(rendered)
+			rendered = RenderUserMessage(msg.Content, m.width, i, isLatest)
 		case "assistant":
-			rendered := RenderAssistantMessage(m.persona.Name, msg.Content, m.width, i, isLatest)
-			m.addMessage(rendered)
+			rendered = RenderAssistantMessage(m.persona.Name, msg.Content, m.width, i, isLatest)
+		default:
+			continue
+		}
+
+		var info []string
+		if !msg.CreatedAt.IsZero() {
+			info = append(info, RelativeTime(msg.CreatedAt, now))
+		}
+		if msg.Modality == persona.ModalityVoice {
+			info = append(info, "🎤")
 		}
+		if m.conversation != nil {
+			if position, total := m.conversation.Siblings(i); total > 1 {
+				info = append(info, RenderBranchIndicator(position, total))
+			}
+		}
+		if len(info) > 0 {
+			align := lipgloss.Left
+			if msg.Role == "user" {
+				align = lipgloss.Right
+			}
+			rendered = lipgloss.JoinVertical(align, rendered, RenderMessageInfo(info))
+		}
+		if m.selecting && i == m.selected {
+			rendered = RenderSelectedMessage(rendered)
+		}
+		m.addMessage(rendered)
 	}
 }
 
@@ -529,15 +787,332 @@ func (m *ChatModel) reRenderMessages() {
 	m.messages = []string{}
 	m.addWelcomeMessage()
 	m.addHistoryMessages()
+	if m.selecting {
+		m.scrollToSelected()
+	}
+}
+
+// loadConversation loads the branch tree matching the persona history
+func (m *ChatModel) loadConversation() {
+	conversation, err := m.manager.GetConversation(m.persona.Name, m.persona.History)
+	if err != nil {
+		conversation = persona.NewConversation(m.persona.History)
+	}
+	m.conversation = conversation
+	m.persona.History = conversation.Path()
+}
+
+// samePath reports whether two histories hold the same messages
+func samePath(a, b []persona.Message) bool {
+	return slices.EqualFunc(a, b, func(x, y persona.Message) bool {
+		return x.ID == y.ID && x.Role == y.Role && x.Content == y.Content && x.Audio == y.Audio
+	})
+}
+
+// keepSelection selects the message of ID id again after the history was
+// reloaded, or ends the selection when the history no longer holds it
+func (m *ChatModel) keepSelection(id string) {
+	index := slices.IndexFunc(m.persona.History, func(message persona.Message) bool {
+		return message.ID == id
+	})
+	if !m.selecting || id == "" || index < 0 {
+		m.selecting = false
+		return
+	}
+	m.selected = index
+}
+
+func (m *ChatModel) saveConversation() error {
+	return m.manager.SaveConversation(m.persona.Name, m.conversation)
+}
+
+// scrollToSelected moves the viewport to the top of the selected message
+func (m *ChatModel) scrollToSelected() {
+	// The welcome message comes first, and messages are separated by a blank line
+	offset := 0
+	for i := 0; i <= m.selected && i < len(m.messages); i++ {
+		offset += lipgloss.Height(m.messages[i]) + 1
+	}
+	m.viewport.SetContent(strings.Join(m.messages, "\n\n"))
+	m.viewport.SetYOffset(offset)
+}
+
+func (m *ChatModel) startSelection() {
+	if len(m.persona.History) == 0 {
+		return
+	}
+	m.selecting = true
+	m.selected = len(m.persona.History) - 1
+	m.reRenderMessages()
+}
+
+func (m *ChatModel) cancelSelection() {
+	if m.editing {
+		m.textArea.Reset()
+	}
+	m.selecting = false
+	m.editing = false
+	m.reRenderMessages()
+	m.viewport.GotoBottom()
+}
+
+// updateSelection handles keys while a message is selected
+func (m *ChatModel) updateSelection(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		m.selected = max(m.selected-1, 0)
+		m.reRenderMessages()
+	case "down", "j":
+		if m.selected < len(m.persona.History)-1 {
+			m.selected++
+			m.reRenderMessages()
+		}
+	case "left", "h", "right", "l":
+		delta := 1
+		if msg.String() == "left" || msg.String() == "h" {
+			delta = -1
+		}
+		if err := m.conversation.SwitchBranch(m.selected, delta); err != nil {
+			return nil
+		}
+		m.persona.History = m.conversation.Path()
+		if err := m.saveConversation(); err != nil {
+			m.fail("History save error", err)
+		}
+		m.reRenderMessages()
+	case "e":
+		return m.editSelected()
+	case "r":
+		return m.regenerateSelected()
+	case "d":
+		m.deleteSelected()
+	case "p":
+		if m.persona.History[m.selected].Role == "assistant" {
+			selected := m.persona.History[m.selected]
+			return m.speak(m.ai, selected.ID, selected.Content, m.persona.Voice.Instructions)
+		}
+	case "v":
+		m.startVoicePicker()
+	case "/":
+		return m.startSearch()
+	case "ctrl+p":
+		m.cancelSelection()
+	}
+	return nil
+}
+
+// startVoicePicker lists the other personas, to speak the selected message
+// in the voice of one of them
+func (m *ChatModel) startVoicePicker() {
+	names, err := m.manager.ListPersonas()
+	if err != nil {
+		m.fail("Persona list error", err)
+		return
+	}
+	m.voices = slices.DeleteFunc(names, func(name string) bool { return name == m.persona.Name })
+	if len(m.voices) == 0 {
+		return
+	}
+	m.voice = 0
+	m.picking = true
+}
+
+// updateVoicePicker handles keys while choosing the voice of the selected
+// message
+func (m *ChatModel) updateVoicePicker(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "left", "h", "up", "k":
+		m.voice = (m.voice + len(m.voices) - 1) % len(m.voices)
+	case "right", "l", "down", "j", "tab":
+		m.voice = (m.voice + 1) % len(m.voices)
+	case "enter":
+		m.picking = false
+		speaker, err := m.manager.GetPersona(m.voices[m.voice])
+		if err != nil {
+			m.fail("Persona load error", err)
+			return nil
+		}
+		return m.speak(m.personaClient(speaker), "", m.persona.History[m.selected].Content, speaker.Voice.Instructions)
+	}
+	return nil
+}
+
+// startSearch opens the search box over the conversation
+func (m *ChatModel) startSearch() tea.Cmd {
+	if len(m.persona.History) == 0 {
+		return nil
+	}
+	m.searching = true
+	m.searchMatches = nil
+	m.searchPos = 0
+	m.searchInput.Reset()
+	m.textArea.Blur()
+	return m.searchInput.Focus()
+}
+
+// cancelSearch closes the search box and the selection it made
+func (m *ChatModel) cancelSearch() {
+	m.searching = false
+	m.searchInput.Blur()
+	m.textArea.Focus()
+	m.cancelSelection()
+}
+
+// updateSearch handles keys while the search box is open: typing searches
+// the history, and the arrows move between the matches
+func (m *ChatModel) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		if len(m.searchMatches) == 0 {
+			m.cancelSearch()
+			return nil
+		}
+		// Keep the match selected to edit, regenerate or delete it
+		m.searching = false
+		m.searchInput.Blur()
+		m.textArea.Focus()
+		return nil
+	case "up":
+		if m.searchPos > 0 {
+			m.searchPos--
+			m.selectMatch()
+		}
+		return nil
+	case "down":
+		if m.searchPos < len(m.searchMatches)-1 {
+			m.searchPos++
+			m.selectMatch()
+		}
+		return nil
+	}
+
+	query := m.searchInput.Value()
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() != query {
+		m.findMatches()
+	}
+	return cmd
+}
+
+// findMatches searches the history for the query and selects the most
+// recent match
+func (m *ChatModel) findMatches() {
+	terms := search.ParseTerms(m.searchInput.Value())
+	m.searchMatches = nil
+	for i, message := range m.persona.History {
+		if (message.Role == "user" || message.Role == "assistant") && search.Match(message.Content, terms) != nil {
+			m.searchMatches = append(m.searchMatches, i)
+		}
+	}
+
+	if len(m.searchMatches) == 0 {
+		m.selecting = false
+		m.reRenderMessages()
+		m.viewport.GotoBottom()
+		return
+	}
+	m.searchPos = len(m.searchMatches) - 1
+	m.selectMatch()
+}
+
+// selectMatch selects the current search match and scrolls to it
+func (m *ChatModel) selectMatch() {
+	m.selecting = true
+	m.selected = m.searchMatches[m.searchPos]
+	m.reRenderMessages()
+}
+
+// searchStatus describes the position among the search matches
+func (m *ChatModel) searchStatus() string {
+	switch {
+	case m.searchInput.Value() == "":
+		return "🔍 Tapez pour rechercher"
+	case len(m.searchMatches) == 0:
+		return "🔍 Aucun résultat"
+	}
+	return fmt.Sprintf("🔍 %d/%d résultats", m.searchPos+1, len(m.searchMatches))
+}
+
+// editSelected loads the selected user message into the input area
+func (m *ChatModel) editSelected() tea.Cmd {
+	message := m.persona.History[m.selected]
+	if message.Role != "user" {
+		return nil
+	}
+	m.selecting = false
+	m.editing = true
+	m.editIndex = m.selected
+	m.textArea.SetValue(message.Content)
+	m.textArea.Focus()
+	m.reRenderMessages()
+	return nil
+}
+
+// regenerateSelected asks for a new reply to the selected turn, keeping the
+// previous reply as an alternative branch
+func (m *ChatModel) regenerateSelected() tea.Cmd {
+	index := m.selected
+	if m.persona.History[index].Role == "user" {
+		index++
+	}
+	if index < len(m.persona.History) {
+		active := m.conversation.Active
+		if err := m.conversation.Rewind(index); err != nil {
+			m.fail("Regeneration error", err)
+			return nil
+		}
+		m.undoRewind = func() { m.conversation.Active = active }
+	}
+
+	m.selecting = false
+	m.persona.History = m.conversation.Path()
+	m.reRenderMessages()
+	m.state = StateChatting
+	m.statusMsg = RenderThinkingStatus(m.width)
+	return m.sendMessage()
+}
+
+// deleteSelected removes the selected message together with the other half
+// of its question/answer pair
+func (m *ChatModel) deleteSelected() {
+	history := m.persona.History
+	start, count := m.selected, 1
+	switch history[m.selected].Role {
+	case "user":
+		if m.selected+1 < len(history) && history[m.selected+1].Role == "assistant" {
+			count = 2
+		}
+	case "assistant":
+		if m.selected > 0 && history[m.selected-1].Role == "user" {
+			start = m.selected - 1
+			count = 2
+		}
+	}
+
+	if err := m.conversation.Delete(start, count); err != nil {
+		m.fail("Delete error", err)
+		return
+	}
+	m.persona.History = m.conversation.Path()
+	if err := m.saveConversation(); err != nil {
+		m.fail("History save error", err)
+		return
+	}
+
+	m.selected = min(start, len(m.persona.History)-1)
+	if m.selected < 0 {
+		m.selecting = false
+	}
+	m.reRenderMessages()
 }
 
 func (m *ChatModel) clearConversation() {
+	m.conversation = persona.NewConversation(nil)
 	m.persona.History = []persona.Message{}
-	_, historyPath := m.manager.GetPersonaPath(m.persona.Name)
-	err := m.persona.SaveHistory(historyPath)
+	err := m.saveConversation()
 	if err != nil {
-		m.state = StateError
-		m.errorMsg = fmt.Sprintf("❌ History save error: %v", err)
+		m.fail("History save error", err)
 		return
 	}
 	m.messages = []string{}
@@ -545,26 +1120,43 @@ func (m *ChatModel) clearConversation() {
 	m.viewport.GotoTop()
 }
 
+// fail moves the chat into the error state; the next key press returns to idle
+func (m *ChatModel) fail(context string, err error) {
+	m.state = StateError
+	m.statusMsg = ""
+	m.errorMsg = fmt.Sprintf("%s: %v", context, err)
+}
+
+// finishExchange returns the chat to idle once a reply has been handled
+func (m *ChatModel) finishExchange() {
+	m.state = StateIdle
+	m.statusMsg = ""
+	if m.isMuted {
+		m.statusMsg = RenderMutedStatus(m.width)
+	}
+}
+
+// The commands below run on their own goroutines: they only read values
+// captured when they are created and report back through a message, so
+// every state transition happens in Update.
+
 func (m *ChatModel) startRecording() tea.Cmd {
+	recorder := m.recorder
 	return func() tea.Msg {
-		m.state = StateRecording
-		m.statusMsg = RenderRecordingStatus(m.width)
-
-		recorder := ffmpeg.New(m.inputDevice, m.silenceThreshold, m.silenceDuration)
 		filename, err := recorder.Record()
-
 		return recordingFinishedMsg{filename: filename, err: err}
 	}
 }
 
 func (m *ChatModel) transcribeAudio(filename string) tea.Cmd {
+	ai := m.ai
 	return func() tea.Msg {
 		dataToTranscribe, err := os.Open(filename)
 		if err != nil {
 			return transcriptionFinishedMsg{err: err}
 		}
 
-		transcript, err := m.ai.Transcribe(dataToTranscribe)
+		transcript, err := ai.Transcribe(dataToTranscribe)
 		if err != nil {
 			return transcriptionFinishedMsg{err: err}
 		}
@@ -581,126 +1173,162 @@ func (m *ChatModel) transcribeAudio(filename string) tea.Cmd {
 }
 
 func (m *ChatModel) sendTextMessage(message string) tea.Cmd {
-	m.addUserMessage(message)
-	m.state = StateChatting
-	m.statusMsg = RenderThinkingStatus(m.width)
-
 	// Réinitialiser complètement la zone de saisie
 	m.textArea.Reset()
 	m.textArea.Blur()
 	m.textArea.Focus()
 
-	return m.sendMessage(message)
+	return m.submitUserMessage(message, persona.ModalityTyped)
 }
 
-func (m *ChatModel) sendMessage(message string) tea.Cmd {
-	return func() tea.Msg {
-		// Add to persona history
-		m.persona.History = append(m.persona.History, persona.Message{
-			Role:    "user",
-			Content: message,
-		})
-
-		// Prepare messages for AI
-		messages := m.persona.GetMessages()
-		aiMessages := []openai.Message{}
-		for _, msg := range messages {
-			aiMessages = append(aiMessages, openai.Message{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
-		}
+// submitUserMessage records the user turn, typed or spoken as given by
+// modality, and asks the AI for a reply
+func (m *ChatModel) submitUserMessage(message, modality string) tea.Cmd {
+	userMessage := persona.NewMessage("user", message)
+	userMessage.Modality = modality
+	m.conversation.Append(userMessage)
+	m.persona.History = m.conversation.Path()
+	m.reRenderMessages()
+	m.state = StateChatting
+	m.statusMsg = RenderThinkingStatus(m.width)
 
-		// Get AI response
-		response, err := m.ai.Chat(aiMessages)
-		if err != nil {
-			return chatFinishedMsg{err: err}
-		}
+	return m.sendMessage()
+}
 
-		// Add to persona history
-		m.persona.History = append(m.persona.History, persona.Message{
-			Role:    "assistant",
-			Content: response,
+// sendMessage snapshots the conversation and requests the next assistant reply
+func (m *ChatModel) sendMessage() tea.Cmd {
+	ai := m.ai
+
+	// Prepare messages for AI
+	messages := m.persona.GetMessages()
+	aiMessages := make([]openai.Message, 0, len(messages))
+	for _, msg := range messages {
+		aiMessages = append(aiMessages, openai.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
 		})
+	}
 
-		// Save history (this will trigger file watcher in other instances)
-		_, historyPath := m.manager.GetPersonaPath(m.persona.Name)
-		err = m.persona.SaveHistory(historyPath)
+	return func() tea.Msg {
+		completion, err := ai.Complete(aiMessages)
 		if err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ History save error: %v", err)
 			return chatFinishedMsg{err: err}
 		}
-
-		return chatFinishedMsg{response: response, err: nil}
+		return chatFinishedMsg{completion: completion, err: nil}
 	}
 }
 
-func (m *ChatModel) generateAudio(text string) tea.Cmd {
+func (m *ChatModel) generateAudio(reply persona.Message) tea.Cmd {
+	return synthesize(m.ai, reply.ID, reply.Content, m.persona.Voice.Instructions, false)
+}
+
+// synthesize asks ai for the speech of text, the reply with the given ID. A
+// replay is played even when muted.
+func synthesize(ai AIClient, reply, text, instructions string, replay bool) tea.Cmd {
 	return func() tea.Msg {
-		data, err := m.ai.GenerateAudio(text, m.persona.Voice.Instructions)
+		audio, err := ai.GenerateAudio(text, instructions)
 		if err != nil {
 			return audioFinishedMsg{err: err}
 		}
+		return audioFinishedMsg{audio: audio, replay: replay, reply: reply, err: nil}
+	}
+}
 
-		audio, err := io.ReadAll(data)
-		if err != nil {
-			return audioFinishedMsg{err: err}
+// replayLastReply speaks the last reply again. Its speech is cached, so it
+// plays without waiting for the API.
+func (m *ChatModel) replayLastReply() tea.Cmd {
+	for i := len(m.persona.History) - 1; i >= 0; i-- {
+		if m.persona.History[i].Role == "assistant" {
+			reply := m.persona.History[i]
+			return m.speak(m.ai, reply.ID, reply.Content, m.persona.Voice.Instructions)
 		}
-
-		return audioFinishedMsg{audioData: audio, err: nil}
 	}
+	return nil
 }
 
-func (m *ChatModel) playAudio(audioData []byte) tea.Cmd {
+// speak plays text, the reply with the given ID, in the voice of ai, from
+// the speech cache when it was spoken before in that voice, or synthesized
+// again otherwise
+func (m *ChatModel) speak(ai AIClient, reply, text, instructions string) tea.Cmd {
+	m.state = StateGeneratingAudio
+	m.statusMsg = RenderGeneratingAudioStatus(m.width)
+	return synthesize(ai, reply, text, instructions, true)
+}
+
+// playAudio plays the speech of a reply, streamed as it downloads
+func (m *ChatModel) playAudio(audio io.Reader, reply string) tea.Cmd {
+	play := m.player
 	return func() tea.Msg {
-		// Skip audio playback if muted
-		if m.isMuted {
-			m.state = StateIdle
-			m.statusMsg = ""
-			return nil
-		}
+		return playbackFinishedMsg{reply: reply, err: play(audio)}
+	}
+}
 
-		// Create temporary file
-		tempFile, err := os.CreateTemp("", "persona-*.mp3")
-		if err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ Temporary file creation error: %v", err)
-			return nil
-		}
-		defer os.Remove(tempFile.Name())
+// speechLocator is implemented by the clients keeping their speech in files
+type speechLocator interface {
+	SpeechPath(text, instructions string) (string, bool)
+}
 
-		// Write audio data
-		if err := os.WriteFile(tempFile.Name(), audioData, 0o644); err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ Audio write error: %v", err)
-			return nil
+// keepAudio records on a reply the file caching its speech, once read to
+// the end, so that the audio can be found again from the history
+func (m *ChatModel) keepAudio(reply string) error {
+	locator, ok := m.ai.(speechLocator)
+	if reply == "" || !ok {
+		return nil
+	}
+	for _, message := range m.conversation.Path() {
+		if message.ID != reply {
+			continue
 		}
-
-		// Play audio
-		err = speak.Play(tempFile.Name())
-		if err != nil {
-			m.state = StateError
-			m.errorMsg = fmt.Sprintf("❌ Audio read error: %v", err)
+		path, ok := locator.SpeechPath(message.Content, m.persona.Voice.Instructions)
+		if !ok || path == message.Audio {
 			return nil
 		}
+		message.Audio = path
+		m.conversation.Update(message)
+		m.persona.History = m.conversation.Path()
+		return m.saveConversation()
+	}
+	return nil
+}
 
-		// Reset to idle state
-		m.state = StateIdle
-		m.statusMsg = ""
-
+// listenForUpdates waits for the next watcher event and hands it to Update
+func (m *ChatModel) listenForUpdates() tea.Cmd {
+	updates, done := m.updates, m.updatesDone
+	if updates == nil {
 		return nil
 	}
+	return func() tea.Msg {
+		select {
+		case msg := <-updates:
+			return msg
+		case <-done:
+			return nil
+		}
+	}
 }
 
 // Cleanup cleans up resources when the chat is closed
 func (m *ChatModel) Cleanup() {
+	m.playback.Stop()
+	m.stopWatchers()
+}
+
+// stopWatchers releases the file watcher, heartbeat and instance registration
+func (m *ChatModel) stopWatchers() {
 	if m.personaWatcher != nil {
 		m.personaWatcher.Stop()
+		m.personaWatcher = nil
+	}
+
+	if m.updatesDone != nil {
+		close(m.updatesDone)
+		m.updates = nil
+		m.updatesDone = nil
 	}
 
 	if m.heartbeatStop != nil {
 		close(m.heartbeatStop)
+		m.heartbeatStop = nil
 	}
 
 	if m.instanceManager != nil {
@@ -708,11 +1336,12 @@ func (m *ChatModel) Cleanup() {
 		if err != nil {
 			log.Printf("Error unsubscribing instance: %v", err)
 		}
+		m.instanceManager = nil
 	}
 }
 
 // NewChatModelWithSelector creates a new chat model that starts with persona selection
-func NewChatModelWithSelector(manager *storage.Manager, config *config.Config, openaiAPIKey string) *ChatModel {
+func NewChatModelWithSelector(manager *storage.Manager, config *config.Config, openaiAPIKey string, player Player) *ChatModel {
 	// Get terminal size with fallback to minimum dimensions
 	width, height, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil || width == 0 || height == 0 {
@@ -723,29 +1352,7 @@ func NewChatModelWithSelector(manager *storage.Manager, config *config.Config, o
 	width = max(width, MIN_TERMINAL_WIDTH)
 	height = max(height, MIN_TERMINAL_HEIGHT)
 
-	// Get available personas
-	personas, err := manager.ListPersonas()
-	if err != nil {
-		personas = []string{}
-	}
-
-	// Create persona items for the list
-	items := make([]list.Item, 0, len(personas))
-	for _, p := range personas {
-		// Try to load persona to get description from prompt
-		personaData, err := manager.GetPersona(p)
-		description := "AI Persona"
-		if err == nil && len(personaData.Prompt) > 50 {
-			description = personaData.Prompt[:50] + "..."
-		} else if err == nil {
-			description = personaData.Prompt
-		}
-
-		items = append(items, PersonaItem{
-			name:        p,
-			description: description,
-		})
-	}
+	items := personaItems(manager)
 
 	// Initialize persona list
 	delegate := itemDelegate{
@@ -761,6 +1368,15 @@ func NewChatModelWithSelector(manager *storage.Manager, config *config.Config, o
 		Background(lipgloss.Color("#7D56F4")).
 		Padding(0, 1)
 
+	// Start on the default persona
+	defaultPersona := manager.DefaultPersonaName()
+	for i, item := range items {
+		if item.(PersonaItem).name == defaultPersona {
+			l.Select(i)
+			break
+		}
+	}
+
 	// Initialize other components
 	vp := viewport.New(width-4, height-10)
 	ta := textarea.New()
@@ -772,6 +1388,7 @@ func NewChatModelWithSelector(manager *storage.Manager, config *config.Config, o
 		mode:             ModePersonaSelector,
 		viewport:         vp,
 		textArea:         ta,
+		searchInput:      newSearchInput(),
 		spinner:          s,
 		personaList:      l,
 		state:            StateIdle,
@@ -784,11 +1401,102 @@ func NewChatModelWithSelector(manager *storage.Manager, config *config.Config, o
 		inputDevice:      config.Audio.InputDevice,
 		silenceThreshold: config.Audio.SilenceThreshold,
 		silenceDuration:  config.Audio.SilenceDuration,
+		recorder:         ffmpeg.New(config.Audio.InputDevice, config.Audio.SilenceThreshold, config.Audio.SilenceDuration),
+		playback:         player,
+		player:           player.Play,
+	}
+	model.personaClient = func(p *persona.Persona) AIClient {
+		return config.NewClient(openaiAPIKey, p.Voice.Name).
+			WithOptions(p.ClientOptions()).
+			WithMeter(manager.UsageMeter(config), p.Name).
+			WithSpeechCache(manager.SpeechCache(config))
 	}
 
 	return model
 }
 
+// newSearchInput creates the input of the conversation search
+func newSearchInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "🔍 "
+	input.Placeholder = "Rechercher dans la conversation..."
+	return input
+}
+
+// personaItems lists the personas for the selector, described by the start of their prompt
+func personaItems(manager *storage.Manager) []list.Item {
+	personas, err := manager.ListPersonas()
+	if err != nil {
+		personas = []string{}
+	}
+
+	items := make([]list.Item, 0, len(personas))
+	for _, p := range personas {
+		// Try to load persona to get description from prompt
+		personaData, err := manager.GetPersona(p)
+		description := "AI Persona"
+		if err != nil {
+			description = fmt.Sprintf("⚠️  Persona invalide, voir 'persona lint %s'", p)
+		} else if len(personaData.Prompt) > 50 {
+			description = personaData.Prompt[:50] + "..."
+		} else {
+			description = personaData.Prompt
+		}
+
+		items = append(items, PersonaItem{
+			name:        p,
+			description: description,
+		})
+	}
+	return items
+}
+
+// openEditor shows the persona editor, for original or for a new persona when nil
+func (m *ChatModel) openEditor(original *persona.Persona) tea.Cmd {
+	var newClient ClientFactory
+	speechModel := ""
+	if m.config != nil {
+		speechModel = m.config.Models.Speech
+		if m.openaiAPIKey != "" {
+			newClient = func(voice string) AIClient {
+				return m.config.NewClient(m.openaiAPIKey, voice).
+					WithMeter(m.manager.UsageMeter(m.config), "").
+					WithSpeechCache(m.manager.SpeechCache(m.config))
+			}
+		}
+	}
+
+	m.editor = NewEditorModel(m.manager, original, speechModel, newClient, m.player)
+	m.editor.embedded = true
+	m.editor.width, m.editor.height = m.width, m.height
+	m.editor.resize()
+	m.mode = ModeEditor
+	return m.editor.Init()
+}
+
+func (m *ChatModel) updateEditor(msg tea.Msg) (tea.Model, tea.Cmd) {
+	closed, ok := msg.(editorClosedMsg)
+	if !ok {
+		_, cmd := m.editor.Update(msg)
+		return m, cmd
+	}
+
+	m.editor = nil
+	m.mode = ModePersonaSelector
+	if closed.saved {
+		// Refresh the list and move to the saved persona
+		items := personaItems(m.manager)
+		cmd := m.personaList.SetItems(items)
+		for i, item := range items {
+			if item.(PersonaItem).name == closed.name {
+				m.personaList.Select(i)
+			}
+		}
+		return m, cmd
+	}
+	return m, nil
+}
+
 // SwitchToPersona switches the chat model to a specific persona
 func (m *ChatModel) SwitchToPersona(personaName string) error {
 	// Load the persona
@@ -798,13 +1506,7 @@ func (m *ChatModel) SwitchToPersona(personaName string) error {
 	}
 
 	// Create new OpenAI client for this persona
-	ai := openai.New(
-		m.openaiAPIKey,
-		m.config.Models.Transcription,
-		m.config.Models.Speech,
-		m.config.Models.Chat,
-		persona.Voice.Name,
-	)
+	ai := m.personaClient(persona)
 
 	// Update model state
 	m.persona = persona
@@ -828,26 +1530,17 @@ func (m *ChatModel) SwitchToPersona(personaName string) error {
 	m.textArea.SetHeight(inputHeight)
 
 	// Initialize file watchers and instance management
+	m.stopWatchers()
 	err = m.initializeWatchers()
 	if err != nil {
 		log.Printf("Error initializing watchers: %v", err)
 	}
 
-	// Load and display persona history
-	_, historyPath := m.manager.GetPersonaPath(m.persona.Name)
-	err = m.persona.LoadHistory(historyPath)
-	if err != nil && !os.IsNotExist(err) {
-		log.Printf("Error loading history: %v", err)
-	}
-
-	// Clear current messages and load history
-	m.messages = []string{}
-	m.loadHistoryToMessages()
-
-	// Update viewport content
-	chatContent := strings.Join(m.messages, "\n\n")
-	m.viewport.SetContent(chatContent)
-	m.viewport.GotoBottom()
+	// Load and display persona history with its branches
+	m.selecting = false
+	m.editing = false
+	m.loadConversation()
+	m.reRenderMessages()
 
 	return nil
 }
@@ -858,41 +1551,40 @@ func (m *ChatModel) initializeWatchers() error {
 		return fmt.Errorf("aucun persona chargé")
 	}
 
-	// Initialize file watcher
+	var errs []error
+
+	// Initialize file watcher; callbacks run on the watcher goroutine so
+	// they only forward messages to the Bubble Tea loop
 	if personaWatcher, err := watcher.NewPersonaWatcher(m.manager, m.persona.Name); err == nil {
+		updates := make(chan tea.Msg)
+		done := make(chan struct{})
+		personaWatcher.SetOnHistoryUpdate(func(history []persona.Message) {
+			select {
+			case updates <- historyUpdateMsg{history: history}:
+			case <-done:
+			}
+		})
+		personaWatcher.SetOnUpdate(func(p *persona.Persona) {
+			select {
+			case updates <- personaUpdateMsg{persona: p}:
+			case <-done:
+			}
+		})
 		m.personaWatcher = personaWatcher
+		m.updates = updates
+		m.updatesDone = done
 		personaWatcher.Start()
 	} else {
-		return fmt.Errorf("unable to initialize persona watcher: %w", err)
+		errs = append(errs, fmt.Errorf("unable to initialize persona watcher: %w", err))
 	}
 
 	// Initialize instance manager
-	m.instanceManager = watcher.NewInstanceManager(m.manager)
+	m.instanceManager = watcher.NewInstanceManager(m.manager, m.persona.Name)
 	if err := m.instanceManager.RegisterInstance(); err == nil {
 		m.heartbeatStop = m.instanceManager.StartHeartbeat()
 	} else {
-		return fmt.Errorf("unable to initialize instance manager: %w", err)
+		errs = append(errs, fmt.Errorf("unable to initialize instance manager: %w", err))
 	}
 
-	return nil
-}
-
-// loadHistoryToMessages loads the persona history into the messages display
-func (m *ChatModel) loadHistoryToMessages() {
-	if m.persona == nil {
-		return
-	}
-
-	for i, msg := range m.persona.History {
-		totalMessages := len(m.persona.History)
-		isLatest := i == totalMessages-1
-
-		var rendered string
-		if msg.Role == "user" {
-			rendered = RenderUserMessage(msg.Content, m.width, i, isLatest)
-		} else {
-			rendered = RenderAssistantMessage(m.persona.Name, msg.Content, m.width, i, isLatest)
-		}
-		m.messages = append(m.messages, rendered)
-	}
+	return errors.Join(errs...)
 }
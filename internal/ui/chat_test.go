@@ -0,0 +1,709 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/speak"
+	"github.com/ctrl-vfr/persona/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeAI is an AIClient that answers from canned values
+type fakeAI struct {
+	mu            sync.Mutex
+	transcription string
+	response      string
+	chatErr       error
+	chatCalls     [][]openai.Message
+	spoken        []string
+}
+
+func (f *fakeAI) Transcribe(audioFile io.Reader) (string, error) {
+	return f.transcription, nil
+}
+
+func (f *fakeAI) GenerateAudio(text string, instructions string) (io.Reader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.spoken = append(f.spoken, text)
+	return bytes.NewReader([]byte("ID3 fake mp3")), nil
+}
+
+func (f *fakeAI) Chat(messages []openai.Message) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chatCalls = append(f.chatCalls, messages)
+	if f.chatErr != nil {
+		return "", f.chatErr
+	}
+	return f.response, nil
+}
+
+func (f *fakeAI) Complete(messages []openai.Message) (*openai.Completion, error) {
+	response, err := f.Chat(messages)
+	if err != nil {
+		return nil, err
+	}
+	return &openai.Completion{
+		Content: response,
+		Model:   "gpt-test",
+		Usage:   openai.Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17},
+	}, nil
+}
+
+type fakeRecorder struct {
+	path string
+	err  error
+}
+
+func (r fakeRecorder) Record() (string, error) {
+	return r.path, r.err
+}
+
+func newTestChatModel(t *testing.T, ai AIClient) (*ChatModel, *storage.Manager) {
+	t.Helper()
+
+	manager := &storage.Manager{BasePath: t.TempDir()}
+	template := []byte("name: tester\nvoice:\n  name: nova\nprompt: Tu es un testeur.\n")
+	if err := manager.CreatePersonaFromYAMLTemplate("tester", template); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+	p, err := manager.GetPersona("tester")
+	if err != nil {
+		t.Fatalf("Failed to load persona: %v", err)
+	}
+
+	m := NewChatModel(p, ai, func(*persona.Persona) AIClient { return ai }, manager, speak.NewPlayer(), "mic", -50, 2)
+	m.mode = ModeChat
+	m.player = func(io.Reader) error { return nil }
+	t.Cleanup(m.Cleanup)
+	return m, manager
+}
+
+// runCmd executes a command on its own goroutine, like the Bubble Tea
+// runtime does, while the test goroutine keeps rendering the model.
+func runCmd(t *testing.T, m tea.Model, cmd tea.Cmd) tea.Msg {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("Expected a command, got nil")
+	}
+
+	result := make(chan tea.Msg, 1)
+	go func() { result <- cmd() }()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-result:
+			return msg
+		case <-timeout:
+			t.Fatal("Command did not complete")
+			return nil
+		default:
+			_ = m.View()
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// drive feeds command results back into Update until the pipeline settles
+func drive(t *testing.T, m tea.Model, cmd tea.Cmd) {
+	t.Helper()
+	for cmd != nil {
+		msg := runCmd(t, m, cmd)
+		_, cmd = m.Update(msg)
+	}
+}
+
+func sendText(t *testing.T, m *ChatModel, text string) tea.Cmd {
+	t.Helper()
+	m.textArea.SetValue(text)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.state != StateChatting {
+		t.Fatalf("Expected state Chatting after enter, got %d", m.state)
+	}
+	return cmd
+}
+
+func TestChatModel_TextMessageFlow(t *testing.T) {
+	ai := &fakeAI{response: "Salut"}
+	m, manager := newTestChatModel(t, ai)
+
+	played := 0
+	m.player = func(audio io.Reader) error {
+		played++
+		if data, _ := io.ReadAll(audio); string(data) != "ID3 fake mp3" {
+			t.Errorf("Expected the speech to be streamed to the player, got %q", data)
+		}
+		return nil
+	}
+
+	drive(t, m, sendText(t, m, "Bonjour"))
+
+	if m.state != StateIdle {
+		t.Errorf("Expected state Idle, got %d (error: %q)", m.state, m.errorMsg)
+	}
+	if played != 1 {
+		t.Errorf("Expected 1 playback, got %d", played)
+	}
+	if len(m.persona.History) != 2 {
+		t.Fatalf("Expected 2 messages in history, got %d", len(m.persona.History))
+	}
+	if m.persona.History[1].Role != "assistant" || m.persona.History[1].Content != "Salut" {
+		t.Errorf("Assistant message incorrect: %+v", m.persona.History[1])
+	}
+
+	if len(ai.chatCalls) != 1 {
+		t.Fatalf("Expected 1 chat call, got %d", len(ai.chatCalls))
+	}
+	sent := ai.chatCalls[0]
+	if len(sent) != 2 || sent[0].Role != "system" || sent[1].Content != "Bonjour" {
+		t.Errorf("Unexpected messages sent to AI: %+v", sent)
+	}
+
+	saved := &persona.Persona{}
+	_, historyPath := manager.GetPersonaPath("tester")
+	if err := saved.LoadHistory(historyPath); err != nil {
+		t.Fatalf("Failed to load saved history: %v", err)
+	}
+	if len(saved.History) != 2 {
+		t.Fatalf("Expected 2 saved messages, got %d", len(saved.History))
+	}
+	if question := saved.History[0]; question.Modality != persona.ModalityTyped || question.ID == "" || question.CreatedAt.IsZero() {
+		t.Errorf("Expected a typed question with an ID and a time, got %+v", question)
+	}
+	if reply := saved.History[1]; reply.Model != "gpt-test" || reply.Usage.TotalTokens != 17 {
+		t.Errorf("Expected the model and usage of the reply, got %+v", reply)
+	}
+}
+
+// cachingAI is a fakeAI keeping its speech in files
+type cachingAI struct {
+	*fakeAI
+}
+
+func (c cachingAI) SpeechPath(text, instructions string) (string, bool) {
+	return "cache/" + text + ".mp3", true
+}
+
+func TestChatModel_KeepsReplyAudio(t *testing.T) {
+	m, manager := newTestChatModel(t, cachingAI{&fakeAI{response: "Salut"}})
+
+	drive(t, m, sendText(t, m, "Bonjour"))
+
+	if audio := m.persona.History[1].Audio; audio != "cache/Salut.mp3" {
+		t.Errorf("Expected the reply to keep its audio, got %q", audio)
+	}
+	saved := &persona.Persona{}
+	_, historyPath := manager.GetPersonaPath("tester")
+	if err := saved.LoadHistory(historyPath); err != nil {
+		t.Fatalf("Failed to load saved history: %v", err)
+	}
+	if len(saved.History) != 2 || saved.History[1].Audio != "cache/Salut.mp3" {
+		t.Errorf("Expected the audio in the saved history, got %+v", saved.History)
+	}
+}
+
+func TestChatModel_SwitchToPersona(t *testing.T) {
+	m, manager := newTestChatModel(t, &fakeAI{})
+	template := []byte("name: echo\nvoice:\n  name: onyx\nprompt: Tu répètes.\n")
+	if err := manager.CreatePersonaFromYAMLTemplate("echo", template); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+
+	// The model creates the client of echo with the factory it was given
+	if err := m.SwitchToPersona("echo"); err != nil {
+		t.Fatalf("Failed to switch persona: %v", err)
+	}
+	if m.persona.Name != "echo" || m.ai == nil {
+		t.Errorf("Expected to talk to echo, got %s", m.persona.Name)
+	}
+}
+
+func TestChatModel_MutedSkipsPlayback(t *testing.T) {
+	m, _ := newTestChatModel(t, &fakeAI{response: "Chut"})
+	m.player = func(io.Reader) error {
+		t.Error("Player should not be called when muted")
+		return nil
+	}
+
+	// Ctrl+M arrives as Enter in most terminals, so toggle the flag directly
+	m.isMuted = true
+
+	drive(t, m, sendText(t, m, "Bonjour"))
+
+	if m.state != StateIdle {
+		t.Errorf("Expected state Idle, got %d", m.state)
+	}
+}
+
+func TestChatModel_ChatErrorRecovers(t *testing.T) {
+	m, _ := newTestChatModel(t, &fakeAI{chatErr: errors.New("boom")})
+
+	drive(t, m, sendText(t, m, "Bonjour"))
+
+	if m.state != StateError {
+		t.Fatalf("Expected state Error, got %d", m.state)
+	}
+	if !strings.Contains(m.errorMsg, "boom") {
+		t.Errorf("Error message should mention the cause, got %q", m.errorMsg)
+	}
+	if view := m.View(); !strings.Contains(view, "boom") {
+		t.Error("Error should be rendered in the view")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m.state != StateIdle {
+		t.Errorf("Expected any key to return to Idle, got %d", m.state)
+	}
+	if m.errorMsg != "" {
+		t.Errorf("Expected error message to be cleared, got %q", m.errorMsg)
+	}
+}
+
+func TestChatModel_VoiceFlow(t *testing.T) {
+	recording := filepath.Join(t.TempDir(), "recording.wav")
+	if err := os.WriteFile(recording, []byte("RIFF"), 0644); err != nil {
+		t.Fatalf("Failed to write recording: %v", err)
+	}
+
+	ai := &fakeAI{transcription: "Une question", response: "Une réponse"}
+	m, _ := newTestChatModel(t, ai)
+	m.recorder = fakeRecorder{path: recording}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if m.state != StateRecording {
+		t.Fatalf("Expected state Recording, got %d", m.state)
+	}
+
+	drive(t, m, cmd)
+
+	if m.state != StateIdle {
+		t.Errorf("Expected state Idle, got %d (error: %q)", m.state, m.errorMsg)
+	}
+	if len(m.persona.History) != 2 || m.persona.History[0].Content != "Une question" {
+		t.Errorf("Unexpected history: %+v", m.persona.History)
+	}
+	if m.persona.History[0].Modality != persona.ModalityVoice {
+		t.Errorf("Expected a voice question, got %q", m.persona.History[0].Modality)
+	}
+	if _, err := os.Stat(recording); !os.IsNotExist(err) {
+		t.Error("Recording should be removed after transcription")
+	}
+}
+
+func TestChatModel_RecordingError(t *testing.T) {
+	m, _ := newTestChatModel(t, &fakeAI{})
+	m.recorder = fakeRecorder{err: errors.New("no microphone")}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	drive(t, m, cmd)
+
+	if m.state != StateError {
+		t.Fatalf("Expected state Error, got %d", m.state)
+	}
+	if len(m.persona.History) != 0 {
+		t.Errorf("History should be untouched, got %d messages", len(m.persona.History))
+	}
+}
+
+func TestChatModel_IgnoresInputWhileBusy(t *testing.T) {
+	m, _ := newTestChatModel(t, &fakeAI{response: "ok"})
+
+	cmd := sendText(t, m, "Bonjour")
+
+	// A second recording request while chatting must not start anything
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if m.state != StateChatting {
+		t.Errorf("Expected state to stay Chatting, got %d", m.state)
+	}
+
+	drive(t, m, cmd)
+	if len(m.persona.History) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(m.persona.History))
+	}
+}
+
+func TestChatModel_RegenerateAndSwitchBranch(t *testing.T) {
+	ai := &fakeAI{response: "Première"}
+	m, manager := newTestChatModel(t, ai)
+	m.isMuted = true
+
+	drive(t, m, sendText(t, m, "Bonjour"))
+
+	// Select the reply and regenerate it
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if !m.selecting || m.selected != 1 {
+		t.Fatalf("Expected the last message to be selected, got selecting=%v selected=%d", m.selecting, m.selected)
+	}
+	ai.response = "Seconde"
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	drive(t, m, cmd)
+
+	if got := m.persona.History[1].Content; got != "Seconde" {
+		t.Errorf("Expected regenerated reply, got %q", got)
+	}
+	if position, total := m.conversation.Siblings(1); position != 2 || total != 2 {
+		t.Errorf("Expected branch 2/2, got %d/%d", position, total)
+	}
+
+	// Flip back to the first reply
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if got := m.persona.History[1].Content; got != "Première" {
+		t.Errorf("Expected first reply after switching branch, got %q", got)
+	}
+
+	// The branch survives a reload from disk
+	p, err := manager.GetPersona("tester")
+	if err != nil {
+		t.Fatalf("Failed to reload persona: %v", err)
+	}
+	conversation, err := manager.GetConversation("tester", p.History)
+	if err != nil {
+		t.Fatalf("Failed to reload conversation: %v", err)
+	}
+	if _, total := conversation.Siblings(1); total != 2 {
+		t.Errorf("Expected 2 saved branches, got %d", total)
+	}
+}
+
+func TestChatModel_HistoryUpdateKeepsSelection(t *testing.T) {
+	ai := &fakeAI{response: "Réponse"}
+	m, manager := newTestChatModel(t, ai)
+	m.isMuted = true
+	drive(t, m, sendText(t, m, "Bonjour"))
+	drive(t, m, sendText(t, m, "Encore"))
+
+	// Our own save coming back from the watcher changes nothing
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	p, err := manager.GetPersona("tester")
+	if err != nil {
+		t.Fatalf("Failed to reload persona: %v", err)
+	}
+	m.Update(historyUpdateMsg{history: p.History})
+	if !m.selecting || m.selected != 2 {
+		t.Fatalf("Expected the selection kept on our own save, got selecting=%v selected=%d", m.selecting, m.selected)
+	}
+
+	// Another instance deleting the first turn keeps the selected message
+	other, err := manager.GetConversation("tester", p.History)
+	if err != nil {
+		t.Fatalf("Failed to load conversation: %v", err)
+	}
+	update := func(start int) {
+		t.Helper()
+		if err := other.Delete(start, 2); err != nil {
+			t.Fatalf("Failed to delete turn: %v", err)
+		}
+		if err := manager.SaveConversation("tester", other); err != nil {
+			t.Fatalf("Failed to save conversation: %v", err)
+		}
+		m.Update(historyUpdateMsg{history: other.Path()})
+	}
+	update(0)
+	if !m.selecting || m.selected != 0 || m.persona.History[0].Content != "Encore" {
+		t.Errorf("Expected the selection to follow its message, got selecting=%v selected=%d", m.selecting, m.selected)
+	}
+
+	// Or ends the selection once the message is gone
+	update(0)
+	if m.selecting {
+		t.Error("Selection should end when the selected message is gone")
+	}
+}
+
+func TestChatModel_RegenerateErrorKeepsReply(t *testing.T) {
+	ai := &fakeAI{response: "Première"}
+	m, _ := newTestChatModel(t, ai)
+	m.isMuted = true
+	drive(t, m, sendText(t, m, "Bonjour"))
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	ai.chatErr = errors.New("boom")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	drive(t, m, cmd)
+
+	if m.state != StateError {
+		t.Fatalf("Expected state Error, got %d", m.state)
+	}
+	if len(m.persona.History) != 2 || m.persona.History[1].Content != "Première" {
+		t.Errorf("Expected the previous reply back after a failed regeneration, got %+v", m.persona.History)
+	}
+	if _, total := m.conversation.Siblings(1); total != 1 {
+		t.Errorf("Expected no new branch, got %d", total)
+	}
+}
+
+func TestChatModel_EditAndDelete(t *testing.T) {
+	ai := &fakeAI{response: "Réponse"}
+	m, _ := newTestChatModel(t, ai)
+	m.isMuted = true
+
+	drive(t, m, sendText(t, m, "Bonjour"))
+
+	// Edit the user turn
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if !m.editing || m.textArea.Value() != "Bonjour" {
+		t.Fatalf("Expected the user message to be loaded for editing, got %q", m.textArea.Value())
+	}
+	drive(t, m, sendText(t, m, "Bonsoir"))
+
+	if got := contentsOf(m.persona.History); strings.Join(got, "|") != "Bonsoir|Réponse" {
+		t.Errorf("Unexpected history after edit: %v", got)
+	}
+	if _, total := m.conversation.Siblings(0); total != 2 {
+		t.Errorf("Expected the edit to create a branch, got %d branches", total)
+	}
+
+	// Delete the turn pair from the assistant side
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if len(m.persona.History) != 0 {
+		t.Errorf("Expected the turn pair to be deleted, got %v", contentsOf(m.persona.History))
+	}
+	if m.selecting {
+		t.Error("Selection should end when the history is empty")
+	}
+}
+
+func contentsOf(messages []persona.Message) []string {
+	var result []string
+	for _, message := range messages {
+		result = append(result, message.Content)
+	}
+	return result
+}
+
+func TestChatModel_Search(t *testing.T) {
+	ai := &fakeAI{response: "Réponse"}
+	m, _ := newTestChatModel(t, ai)
+	m.isMuted = true
+
+	drive(t, m, sendText(t, m, "Parle-moi de l'été"))
+	drive(t, m, sendText(t, m, "Et de l'hiver"))
+	drive(t, m, sendText(t, m, "Encore l'Été"))
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	if !m.searching || m.textArea.Value() != "" {
+		t.Fatalf("Expected / to open the search, got searching=%v input=%q", m.searching, m.textArea.Value())
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ete")})
+	if len(m.searchMatches) != 2 || !m.selecting || m.selected != 4 {
+		t.Fatalf("Expected the latest of 2 matches to be selected, got %v selected %d", m.searchMatches, m.selected)
+	}
+	if !strings.Contains(m.View(), "2/2") {
+		t.Error("Expected the view to show the match position")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if m.selected != 0 {
+		t.Errorf("Expected up to select the previous match, got %d", m.selected)
+	}
+
+	// Enter keeps the match selected, to act on it
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.searching || !m.selecting || m.selected != 0 {
+		t.Errorf("Expected enter to keep the match selected, got searching=%v selected %d", m.searching, m.selected)
+	}
+
+	// Esc cancels the search and its selection
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("hiver")})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.searching || m.selecting {
+		t.Error("Expected esc to close the search and the selection")
+	}
+}
+
+func TestChatModel_ReplayLastReply(t *testing.T) {
+	ai := &fakeAI{response: "Salut"}
+	m, _ := newTestChatModel(t, ai)
+	m.isMuted = true
+	var played []io.Reader
+	m.player = func(audio io.Reader) error {
+		played = append(played, audio)
+		return nil
+	}
+
+	// Nothing to replay yet
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO}); cmd != nil || m.state != StateIdle {
+		t.Error("Expected no replay without a reply")
+	}
+
+	drive(t, m, sendText(t, m, "Bonjour"))
+	if len(played) != 0 {
+		t.Fatal("Expected a muted reply not to be played")
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	drive(t, m, cmd)
+	if len(played) != 1 {
+		t.Errorf("Expected the replay to play even when muted, got %d playbacks", len(played))
+	}
+	if m.state != StateIdle {
+		t.Errorf("Expected state Idle after the replay, got %d", m.state)
+	}
+}
+
+func TestChatModel_SpeakSelectedMessage(t *testing.T) {
+	ai := &fakeAI{response: "Première"}
+	m, manager := newTestChatModel(t, ai)
+	m.isMuted = true
+	drive(t, m, sendText(t, m, "Bonjour"))
+	ai.response = "Seconde"
+	drive(t, m, sendText(t, m, "Encore"))
+
+	template := []byte("name: echo\nvoice:\n  name: onyx\nprompt: Tu répètes.\n")
+	if err := manager.CreatePersonaFromYAMLTemplate("echo", template); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+	echo := &fakeAI{}
+	var voices []string
+	m.personaClient = func(p *persona.Persona) AIClient {
+		voices = append(voices, p.Voice.Name)
+		return echo
+	}
+	var played int
+	m.player = func(io.Reader) error {
+		played++
+		return nil
+	}
+
+	// Select the first reply and replay it in the current voice
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	drive(t, m, cmd)
+	if n := len(ai.spoken); n == 0 || ai.spoken[n-1] != "Première" {
+		t.Errorf("Expected the selected reply to be spoken, got %v", ai.spoken)
+	}
+	if played != 1 {
+		t.Errorf("Expected the replay to play even when muted, got %d playbacks", played)
+	}
+
+	// Speak it in the voice of another persona
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if !m.picking || len(m.voices) != 1 || m.voices[0] != "echo" {
+		t.Fatalf("Expected a picker of the other personas, got %v", m.voices)
+	}
+	if !strings.Contains(m.View(), "echo") {
+		t.Error("Expected the picker to show the persona")
+	}
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drive(t, m, cmd)
+	if m.picking {
+		t.Error("Expected enter to close the picker")
+	}
+	if len(voices) != 1 || voices[0] != "onyx" || len(echo.spoken) != 1 || echo.spoken[0] != "Première" {
+		t.Errorf("Expected the reply spoken by echo, got %v with voices %v", echo.spoken, voices)
+	}
+	if played != 2 || !m.selecting {
+		t.Errorf("Expected a second playback in selection mode, got %d", played)
+	}
+
+	// Esc closes the picker only
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.picking || !m.selecting {
+		t.Error("Expected esc to close the picker and keep the selection")
+	}
+
+	// Questions are not spoken
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")}); cmd != nil {
+		t.Error("Expected no speech for a question")
+	}
+}
+
+// fakePlayback records the playback controls
+type fakePlayback struct {
+	status speak.Status
+	seeks  []time.Duration
+}
+
+func (f *fakePlayback) TogglePause() bool {
+	f.status.Paused = !f.status.Paused
+	return f.status.Paused
+}
+
+func (f *fakePlayback) Seek(offset time.Duration) error {
+	f.seeks = append(f.seeks, offset)
+	return nil
+}
+
+func (f *fakePlayback) SetSpeed(speed float64) float64 {
+	f.status.Speed = speed
+	return speed
+}
+
+func (f *fakePlayback) SetVolume(volume float64) float64 {
+	f.status.Volume = volume
+	return volume
+}
+
+func (f *fakePlayback) Status() speak.Status {
+	return f.status
+}
+
+func (f *fakePlayback) Stop() {
+	f.status.Playing = false
+}
+
+func TestChatModel_PlaybackControls(t *testing.T) {
+	m, _ := newTestChatModel(t, &fakeAI{})
+	playback := &fakePlayback{status: speak.Status{Playing: true, Position: 15 * time.Second, Duration: time.Minute, Speed: 1, Volume: 1}}
+	m.playback = playback
+	m.state = StatePlaying
+
+	keys := []tea.KeyMsg{
+		{Type: tea.KeySpace, Runes: []rune(" ")},
+		{Type: tea.KeyRight},
+		{Type: tea.KeyLeft},
+		{Type: tea.KeyRunes, Runes: []rune("]")},
+		{Type: tea.KeyRunes, Runes: []rune("-")},
+	}
+	for _, key := range keys {
+		m.Update(key)
+	}
+	if !playback.status.Paused {
+		t.Error("Expected space to pause the playback")
+	}
+	if len(playback.seeks) != 2 || playback.seeks[0] != SeekStep || playback.seeks[1] != -SeekStep {
+		t.Errorf("Expected to seek forward then back, got %v", playback.seeks)
+	}
+	if playback.status.Speed != 1.25 || playback.status.Volume != 0.9 {
+		t.Errorf("Expected speed 1.25 and volume 0.9, got %+v", playback.status)
+	}
+	if m.textArea.Value() != "" {
+		t.Errorf("Expected the playback keys not to be typed, got %q", m.textArea.Value())
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "0:15") || !strings.Contains(view, "1:00") || !strings.Contains(view, "×1.25") {
+		t.Error("Expected the progress of the playback in the status line")
+	}
+
+	// Typing the next message keeps the keys for the text
+	m.textArea.SetValue("Et")
+	m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if !playback.status.Paused || m.textArea.Value() != "Et " {
+		t.Errorf("Expected space to be typed, got %q", m.textArea.Value())
+	}
+}
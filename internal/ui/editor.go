@@ -0,0 +1,372 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/storage"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PreviewSentence is read aloud when previewing a voice
+const PreviewSentence = "Bonjour ! Voici un aperçu de ma voix. Qu'est-ce que je peux faire pour vous ?"
+
+// Editor fields, in focus order
+const (
+	fieldName = iota
+	fieldVoice
+	fieldInstructions
+	fieldPrompt
+	fieldCount
+)
+
+// ClientFactory returns an AI client speaking with the given voice
+type ClientFactory func(voice string) AIClient
+
+// EditorModel is a form to create or edit a persona
+type EditorModel struct {
+	name         textinput.Model
+	instructions textarea.Model
+	prompt       textarea.Model
+	voices       []string
+	voice        int
+	focus        int
+
+	// original is the persona being edited, nil when creating one
+	original  *persona.Persona
+	manager   *storage.Manager
+	newClient ClientFactory
+	player    func(audio io.Reader) error
+
+	previewing bool
+	saved      bool
+	statusMsg  string
+	errorMsg   string
+	width      int
+	height     int
+
+	// embedded editors report to their parent instead of quitting
+	embedded bool
+}
+
+type previewFinishedMsg struct {
+	err error
+}
+
+// editorClosedMsg tells the parent model that the editor was closed
+type editorClosedMsg struct {
+	name  string
+	saved bool
+}
+
+// NewEditorModel creates a persona editor. original is the persona to edit,
+// as returned by Manager.GetPersonaDefinition, or nil to create a new one.
+// newClient may be nil, in which case voice previews are disabled. play plays
+// the previews.
+func NewEditorModel(manager *storage.Manager, original *persona.Persona, speechModel string, newClient ClientFactory, play func(audio io.Reader) error) *EditorModel {
+	width, height := InitTerminalSize()
+
+	name := textinput.New()
+	name.Placeholder = "mon-persona"
+	name.CharLimit = 64
+
+	instructions := textarea.New()
+	instructions.Placeholder = "Voice: ...\nTone: ...\nPersonality: ..."
+	instructions.ShowLineNumbers = false
+
+	prompt := textarea.New()
+	prompt.Placeholder = "Tu es ..."
+	prompt.ShowLineNumbers = false
+	prompt.CharLimit = 0
+
+	voices := openai.Voices(speechModel)
+	if voices == nil {
+		voices = openai.Voices("gpt-4o-mini-tts")
+	}
+	voices = slices.Clone(voices)
+
+	m := &EditorModel{
+		name:         name,
+		instructions: instructions,
+		prompt:       prompt,
+		voices:       voices,
+		original:     original,
+		manager:      manager,
+		newClient:    newClient,
+		player:       play,
+		width:        width,
+		height:       height,
+	}
+
+	if original != nil {
+		m.name.SetValue(original.Name)
+		m.instructions.SetValue(original.Voice.Instructions)
+		m.prompt.SetValue(original.Prompt)
+		m.voice = slices.Index(m.voices, original.Voice.Name)
+		if m.voice < 0 && original.Voice.Name != "" {
+			// Keep a voice unknown to this model rather than silently replacing it
+			m.voices = append([]string{original.Voice.Name}, m.voices...)
+			m.voice = 0
+		}
+		m.voice = max(m.voice, 0)
+		// The name is the persona directory, it cannot be changed here
+		m.focus = fieldVoice
+	} else {
+		m.voice = max(slices.Index(m.voices, "nova"), 0)
+	}
+
+	m.resize()
+	m.applyFocus()
+	return m
+}
+
+// SetName prefills the name of a new persona
+func (m *EditorModel) SetName(name string) {
+	m.name.SetValue(name)
+}
+
+// Name returns the name of the persona being edited
+func (m *EditorModel) Name() string {
+	name, _ := m.persona()
+	return name
+}
+
+// Saved reports whether the persona was saved before the editor closed
+func (m *EditorModel) Saved() bool {
+	return m.saved
+}
+
+func (m *EditorModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m *EditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = max(msg.Width, MIN_TERMINAL_WIDTH)
+		m.height = max(msg.Height, MIN_TERMINAL_HEIGHT)
+		m.resize()
+		return m, nil
+
+	case previewFinishedMsg:
+		m.previewing = false
+		m.statusMsg = ""
+		if msg.err != nil {
+			m.errorMsg = RenderError(fmt.Sprintf("Aperçu impossible : %v", msg.err))
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		m.errorMsg = ""
+
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			return m, m.close()
+		case "ctrl+s":
+			return m, m.save()
+		case "ctrl+p":
+			return m, m.preview()
+		case "tab":
+			m.moveFocus(1)
+			return m, nil
+		case "shift+tab":
+			m.moveFocus(-1)
+			return m, nil
+		}
+
+		if m.focus == fieldVoice {
+			switch msg.String() {
+			case "left", "h":
+				m.voice = (m.voice + len(m.voices) - 1) % len(m.voices)
+			case "right", "l", " ":
+				m.voice = (m.voice + 1) % len(m.voices)
+			case "enter", "down":
+				m.moveFocus(1)
+			case "up":
+				m.moveFocus(-1)
+			}
+			return m, nil
+		}
+		if m.focus == fieldName && msg.String() == "enter" {
+			m.moveFocus(1)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.focus {
+	case fieldName:
+		m.name, cmd = m.name.Update(msg)
+	case fieldInstructions:
+		m.instructions, cmd = m.instructions.Update(msg)
+	case fieldPrompt:
+		m.prompt, cmd = m.prompt.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m *EditorModel) View() string {
+	title := "✨ Nouveau persona"
+	if m.original != nil {
+		title = fmt.Sprintf("✏️  Édition de %s", m.original.Name)
+	}
+
+	sections := []string{RenderChatBoxTitle(title, m.width)}
+
+	name := m.name.View()
+	if m.original != nil {
+		name = SubtitleStyle.Render(m.original.Name)
+	}
+	sections = append(sections, m.renderField(fieldName, "Nom", name))
+
+	voices := make([]string, 0, len(m.voices))
+	for i, voice := range m.voices {
+		if i == m.voice {
+			voices = append(voices, EditorSelectedVoiceStyle.Render(voice))
+		} else {
+			voices = append(voices, MutedStyle.Render(voice))
+		}
+	}
+	sections = append(sections, m.renderField(fieldVoice, "Voix (←/→)", strings.Join(voices, " ")))
+	sections = append(sections, m.renderField(fieldInstructions, "Instructions de voix", m.instructions.View()))
+	sections = append(sections, m.renderField(fieldPrompt, "Prompt", m.prompt.View()))
+
+	help := "💡 Tab: Champ suivant | Ctrl+S: Enregistrer | Esc: Annuler"
+	if m.newClient != nil {
+		help = "💡 Tab: Champ suivant | Ctrl+P: Écouter la voix | Ctrl+S: Enregistrer | Esc: Annuler"
+	}
+	sections = append(sections, RenderMuted(help))
+
+	if m.statusMsg != "" {
+		sections = append(sections, m.statusMsg)
+	}
+	if m.errorMsg != "" {
+		sections = append(sections, m.errorMsg)
+	}
+
+	return strings.Join(sections, "\n")
+}
+
+func (m *EditorModel) renderField(field int, label, content string) string {
+	labelStyle := MutedStyle
+	if field == m.focus {
+		labelStyle = EditorFocusedLabelStyle
+	}
+	return lipgloss.NewStyle().MarginLeft(HORIZONTAL_MARGIN).Render(labelStyle.Render(label) + "\n" + content)
+}
+
+func (m *EditorModel) resize() {
+	fieldWidth := m.width - 2*HORIZONTAL_MARGIN - 2
+	m.name.Width = fieldWidth
+	m.instructions.SetWidth(fieldWidth)
+	m.instructions.SetHeight(4)
+
+	// The prompt takes the remaining height
+	m.prompt.SetWidth(fieldWidth)
+	m.prompt.SetHeight(max(m.height-20, 4))
+}
+
+func (m *EditorModel) moveFocus(delta int) {
+	m.focus = (m.focus + delta + fieldCount) % fieldCount
+	if m.focus == fieldName && m.original != nil {
+		m.focus = (m.focus + delta + fieldCount) % fieldCount
+	}
+	m.applyFocus()
+}
+
+func (m *EditorModel) applyFocus() {
+	m.name.Blur()
+	m.instructions.Blur()
+	m.prompt.Blur()
+
+	switch m.focus {
+	case fieldName:
+		m.name.Focus()
+	case fieldInstructions:
+		m.instructions.Focus()
+	case fieldPrompt:
+		m.prompt.Focus()
+	}
+}
+
+// persona builds the persona described by the form
+func (m *EditorModel) persona() (string, *persona.Persona) {
+	p := &persona.Persona{History: []persona.Message{}}
+	if m.original != nil {
+		// Keep the fields the form does not edit
+		copied := *m.original
+		p = &copied
+	}
+	// The name field holds the name the persona was opened with, locked when
+	// editing, so an edited persona is saved back to its own directory
+	p.Name = strings.TrimSpace(m.name.Value())
+
+	p.Voice.Name = m.voices[m.voice]
+	p.Voice.Instructions = strings.TrimSpace(m.instructions.Value())
+	p.Prompt = strings.TrimSpace(m.prompt.Value())
+	return p.Name, p
+}
+
+func (m *EditorModel) save() tea.Cmd {
+	name, p := m.persona()
+
+	if m.original == nil {
+		if err := storage.ValidatePersonaName(name); err != nil {
+			m.errorMsg = RenderError(err.Error())
+			return nil
+		}
+		if m.manager.PersonaExists(name) {
+			m.errorMsg = RenderError(fmt.Sprintf("Le persona '%s' existe déjà", name))
+			return nil
+		}
+	}
+
+	if err := m.manager.SavePersona(name, p); err != nil {
+		m.errorMsg = RenderLoadError("Enregistrement impossible", err)
+		return nil
+	}
+
+	m.saved = true
+	return m.close()
+}
+
+func (m *EditorModel) close() tea.Cmd {
+	if !m.embedded {
+		return tea.Quit
+	}
+	name, _ := m.persona()
+	saved := m.saved
+	return func() tea.Msg {
+		return editorClosedMsg{name: name, saved: saved}
+	}
+}
+
+// preview reads a sample sentence with the selected voice and instructions
+func (m *EditorModel) preview() tea.Cmd {
+	if m.newClient == nil || m.previewing {
+		return nil
+	}
+	m.previewing = true
+	m.statusMsg = GetStatusStyle(m.width).Render("🔈 Aperçu de la voix...")
+
+	ai := m.newClient(m.voices[m.voice])
+	instructions := strings.TrimSpace(m.instructions.Value())
+	play := m.player
+	return func() tea.Msg {
+		audio, err := ai.GenerateAudio(PreviewSentence, instructions)
+		if err != nil {
+			return previewFinishedMsg{err: err}
+		}
+		return previewFinishedMsg{err: play(audio)}
+	}
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrl-vfr/persona/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeText(m tea.Model, text string) {
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+}
+
+func TestEditorModel_CreatePersona(t *testing.T) {
+	manager := &storage.Manager{BasePath: t.TempDir()}
+	m := NewEditorModel(manager, nil, "gpt-4o-mini-tts", nil, nil)
+
+	typeText(m, "sherlock")
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	voice := m.voices[m.voice]
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	typeText(m, "Accent britannique")
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+	// An empty prompt is refused by validation
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd != nil || m.Saved() {
+		t.Fatal("Expected the save to be refused")
+	}
+	if !strings.Contains(m.View(), "prompt must not be empty") {
+		t.Error("Expected the validation error to be shown")
+	}
+
+	typeText(m, "Tu es Sherlock Holmes.")
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if !m.Saved() || cmd == nil {
+		t.Fatalf("Expected the persona to be saved, view:\n%s", m.View())
+	}
+	if msg := cmd(); msg != tea.Quit() {
+		t.Errorf("Expected a standalone editor to quit, got %#v", msg)
+	}
+
+	p, err := manager.GetPersona("sherlock")
+	if err != nil {
+		t.Fatalf("Failed to load saved persona: %v", err)
+	}
+	if p.Voice.Name != voice || p.Voice.Instructions != "Accent britannique" || p.Prompt != "Tu es Sherlock Holmes." {
+		t.Errorf("Unexpected saved persona: %+v", p)
+	}
+}
+
+func TestEditorModel_EditKeepsOtherFields(t *testing.T) {
+	manager := &storage.Manager{BasePath: t.TempDir()}
+	template := []byte("name: merlin\nextends: base\nincludes:\n  - lore.md\nvoice:\n  name: verse\nprompt: Tu es Merlin.\n")
+	if err := manager.CreatePersonaFromYAMLTemplate("merlin", template); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+	original, err := manager.GetPersonaDefinition("merlin")
+	if err != nil {
+		t.Fatalf("Failed to load persona: %v", err)
+	}
+
+	m := NewEditorModel(manager, original, "gpt-4o-mini-tts", nil, nil)
+	m.embedded = true
+	if m.focus != fieldVoice || m.voices[m.voice] != "verse" {
+		t.Fatalf("Expected the voice to be focused on 'verse', got focus %d voice %s", m.focus, m.voices[m.voice])
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if m.focus != fieldPrompt {
+		t.Errorf("Expected the name field to be skipped, got focus %d", m.focus)
+	}
+	typeText(m, " Grand enchanteur.")
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd == nil {
+		t.Fatalf("Expected the persona to be saved, view:\n%s", m.View())
+	}
+	closed, ok := cmd().(editorClosedMsg)
+	if !ok || !closed.saved || closed.name != "merlin" {
+		t.Errorf("Expected an editorClosedMsg for merlin, got %#v", closed)
+	}
+
+	saved, err := manager.GetPersonaDefinition("merlin")
+	if err != nil {
+		t.Fatalf("Failed to reload persona: %v", err)
+	}
+	if saved.Extends != "base" || len(saved.Includes) != 1 {
+		t.Errorf("Fields outside the form should be kept, got %+v", saved)
+	}
+	if !strings.HasSuffix(saved.Prompt, "Grand enchanteur.") {
+		t.Errorf("Expected the edited prompt, got %q", saved.Prompt)
+	}
+}
+
+func TestEditorModel_EditSavesUnderDirectoryName(t *testing.T) {
+	manager := &storage.Manager{BasePath: t.TempDir()}
+	if err := manager.CreatePersonaFromYAMLTemplate("marceline", []byte("name: marceline\nprompt: Tu es Marceline.\n")); err != nil {
+		t.Fatalf("Failed to create persona: %v", err)
+	}
+	// An older version wrote the name of its template in the file
+	personaPath, _ := manager.GetPersonaPath("persona")
+	if err := os.MkdirAll(filepath.Dir(personaPath), 0755); err != nil {
+		t.Fatalf("Failed to create persona directory: %v", err)
+	}
+	if err := os.WriteFile(personaPath, []byte("name: marceline\nprompt: Tu es un persona.\n"), 0644); err != nil {
+		t.Fatalf("Failed to write persona: %v", err)
+	}
+
+	original, err := manager.GetPersonaDefinition("persona")
+	if err != nil {
+		t.Fatalf("Failed to load persona: %v", err)
+	}
+	m := NewEditorModel(manager, original, "gpt-4o-mini-tts", nil, nil)
+	m.embedded = true
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	typeText(m, " Modifié.")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd == nil {
+		t.Fatalf("Expected the persona to be saved, view:\n%s", m.View())
+	}
+	if closed, ok := cmd().(editorClosedMsg); !ok || closed.name != "persona" {
+		t.Errorf("Expected an editorClosedMsg for persona, got %#v", closed)
+	}
+
+	saved, err := manager.GetPersonaDefinition("persona")
+	if err != nil {
+		t.Fatalf("Failed to reload persona: %v", err)
+	}
+	if saved.Prompt != "Tu es un persona. Modifié." {
+		t.Errorf("Expected the edited prompt, got %q", saved.Prompt)
+	}
+	marceline, err := manager.GetPersonaDefinition("marceline")
+	if err != nil {
+		t.Fatalf("Failed to reload marceline: %v", err)
+	}
+	if marceline.Prompt != "Tu es Marceline." {
+		t.Errorf("Expected marceline untouched, got %q", marceline.Prompt)
+	}
+}
+
+func TestEditorModel_Preview(t *testing.T) {
+	manager := &storage.Manager{BasePath: t.TempDir()}
+	var voice string
+	played := 0
+	m := NewEditorModel(manager, nil, "gpt-4o-mini-tts", func(v string) AIClient {
+		voice = v
+		return &fakeAI{}
+	}, func(io.Reader) error {
+		played++
+		return nil
+	})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if !m.previewing {
+		t.Fatal("Expected a preview to start")
+	}
+	if _, again := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP}); again != nil {
+		t.Error("A second preview should not start while one is playing")
+	}
+
+	drive(t, m, cmd)
+	if played != 1 || voice != "nova" {
+		t.Errorf("Expected one preview with nova, got %d with %q", played, voice)
+	}
+	if m.previewing {
+		t.Error("Preview should be finished")
+	}
+}
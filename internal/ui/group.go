@@ -0,0 +1,411 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ctrl-vfr/persona/internal/group"
+	"github.com/ctrl-vfr/persona/internal/openai"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/storage"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// GroupChatModel is a roundtable conversation between the user and several
+// personas, each answering with its own prompt and voice
+type GroupChatModel struct {
+	// UI components
+	viewport viewport.Model
+	textArea textarea.Model
+	spinner  spinner.Model
+
+	// Application state
+	state    ChatState
+	session  *group.Session
+	personas map[string]*persona.Persona
+	clients  map[string]AIClient
+	manager  *storage.Manager
+	recorder Recorder
+	player   func(audio io.Reader) error
+	playback Playback
+	// profile is the active configuration profile, shown in the title
+	profile string
+
+	// Participants still expected to answer the current user message
+	pending []string
+	speaker string
+
+	// Display state
+	messages  []string
+	statusMsg string
+	errorMsg  string
+	width     int
+	height    int
+
+	// Audio settings
+	isMuted bool
+}
+
+type moderatorFinishedMsg struct {
+	speaker string
+	err     error
+}
+
+type groupReplyMsg struct {
+	speaker  string
+	response string
+	err      error
+}
+
+// NewGroupChatModel creates a group chat. Every participant needs a persona
+// and an AI client configured with its voice.
+func NewGroupChatModel(session *group.Session, personas map[string]*persona.Persona, clients map[string]AIClient, manager *storage.Manager, recorder Recorder, player Player) *GroupChatModel {
+	width, height := InitTerminalSize()
+	viewportWidth, _, inputHeight := GetChatLayoutDimensions(width, height)
+
+	model := &GroupChatModel{
+		viewport: InitViewport(width, height),
+		textArea: InitTextArea(viewportWidth, inputHeight),
+		spinner:  InitSpinner(),
+		state:    StateIdle,
+		session:  session,
+		personas: personas,
+		clients:  clients,
+		manager:  manager,
+		recorder: recorder,
+		player:   player.Play,
+		playback: player,
+		width:    width,
+		height:   height,
+	}
+	if manager != nil {
+		model.profile = manager.ActiveProfile()
+	}
+	model.reRenderMessages()
+
+	return model
+}
+
+func (m *GroupChatModel) Init() tea.Cmd {
+	return tea.Batch(
+		textarea.Blink,
+		m.spinner.Tick,
+	)
+}
+
+func (m *GroupChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = max(msg.Width, MIN_TERMINAL_WIDTH)
+		m.height = max(msg.Height, MIN_TERMINAL_HEIGHT)
+
+		viewportWidth, viewportHeight, inputHeight := GetChatLayoutDimensions(m.width, m.height)
+		m.viewport.Width = viewportWidth
+		m.viewport.Height = viewportHeight
+		m.textArea.SetWidth(viewportWidth)
+		m.textArea.SetHeight(inputHeight)
+		m.reRenderMessages()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		}
+
+		// Any key acknowledges an error and returns to the input
+		if m.state == StateError {
+			m.state = StateIdle
+			m.errorMsg = ""
+			return m, nil
+		}
+
+		// Playback keys apply unless typing the next message
+		if m.state == StatePlaying && m.textArea.Value() == "" && controlPlayback(m.playback, msg.String()) {
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "ctrl+l":
+			if m.state == StateIdle {
+				m.session.Transcript = []persona.Message{}
+				if err := m.manager.SaveGroupSession(m.session); err != nil {
+					m.fail("History save error", err)
+				}
+				m.reRenderMessages()
+			}
+		case "ctrl+m":
+			m.isMuted = !m.isMuted
+		case "ctrl+r":
+			if m.state == StateIdle {
+				m.state = StateRecording
+				m.statusMsg = RenderRecordingStatus(m.width)
+				return m, m.startRecording()
+			}
+		case "enter":
+			if m.state == StateIdle && strings.TrimSpace(m.textArea.Value()) != "" {
+				userMessage := strings.TrimSpace(m.textArea.Value())
+				m.textArea.Reset()
+				return m, m.submitUserMessage(userMessage)
+			}
+		}
+
+	case recordingFinishedMsg:
+		if msg.err != nil {
+			m.fail("Recording error", msg.err)
+			return m, nil
+		}
+		m.state = StateTranscribing
+		m.statusMsg = RenderTranscribingStatus(m.width)
+		return m, m.transcribeAudio(msg.filename)
+
+	case transcriptionFinishedMsg:
+		if msg.err != nil {
+			m.fail("Transcription error", msg.err)
+			return m, nil
+		}
+		return m, m.submitUserMessage(msg.text)
+
+	case moderatorFinishedMsg:
+		if msg.err != nil {
+			m.fail("Moderator error", msg.err)
+			return m, nil
+		}
+		m.pending = []string{msg.speaker}
+		return m, m.nextSpeaker()
+
+	case groupReplyMsg:
+		if msg.err != nil {
+			m.fail(fmt.Sprintf("Chat error (%s)", msg.speaker), msg.err)
+			return m, nil
+		}
+		m.session.AddReply(msg.speaker, msg.response)
+		m.reRenderMessages()
+		if err := m.manager.SaveGroupSession(m.session); err != nil {
+			m.fail("History save error", err)
+			return m, nil
+		}
+
+		m.state = StateGeneratingAudio
+		m.statusMsg = RenderGeneratingAudioStatus(m.width)
+		return m, m.generateAudio(msg.speaker, msg.response)
+
+	case audioFinishedMsg:
+		if msg.err != nil {
+			m.fail("Audio generation error", msg.err)
+			return m, nil
+		}
+		if m.isMuted {
+			return m, tea.Batch(discardAudio(msg.audio), m.nextSpeaker())
+		}
+		m.state = StatePlaying
+		m.statusMsg = RenderPlayingStatus(m.width)
+		return m, m.playAudio(msg.audio)
+
+	case playbackFinishedMsg:
+		if msg.err != nil {
+			m.fail("Audio read error", msg.err)
+			return m, nil
+		}
+		return m, m.nextSpeaker()
+
+	case spinner.TickMsg:
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	m.textArea, cmd = m.textArea.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m *GroupChatModel) View() string {
+	var sections []string
+
+	title := fmt.Sprintf("Table ronde : %s", strings.Join(m.session.Participants, ", "))
+	if m.session.Mode == group.ModeModerator {
+		title += " 🎙️"
+	}
+	if m.profile != "" {
+		title += fmt.Sprintf(" [%s]", m.profile)
+	}
+	if m.isMuted {
+		title += " 🔇"
+	}
+	if m.manager != nil && m.manager.OverBudget() {
+		title += " 💸 Budget dépassé"
+	}
+	sections = append(sections, RenderChatBoxTitle(title, m.width))
+
+	m.viewport.SetContent(strings.Join(m.messages, "\n\n"))
+	sections = append(sections, RenderChatBoxBorder(m.viewport.View(), m.width, m.height))
+
+	switch {
+	case m.state == StateIdle:
+		sections = append(sections, RenderInputBox(m.textArea.View(), m.width))
+		sections = append(sections, RenderMuted("💡 Ctrl+R: Enregistrer | Enter: Envoyer | Ctrl+L: Effacer | Ctrl+M: Mute | Ctrl+C: Quitter"))
+	case m.state == StateError:
+		sections = append(sections, RenderInputBox(RenderError(m.errorMsg), m.width))
+		sections = append(sections, RenderMuted("💡 Appuyez sur une touche pour continuer"))
+	case m.state == StatePlaying:
+		sections = append(sections, RenderInputBox(m.spinner.View()+" "+renderPlayback(m.playback, m.statusMsg, m.width), m.width))
+		sections = append(sections, RenderMuted(PlaybackHelp))
+	case m.statusMsg != "":
+		sections = append(sections, RenderInputBox(m.spinner.View()+" "+m.statusMsg, m.width))
+	}
+
+	return strings.Join(sections, "\n")
+}
+
+func (m *GroupChatModel) reRenderMessages() {
+	welcome := fmt.Sprintf("Bienvenue autour de la table ! %s vous écoutent. 🎤 Tapez votre message ou utilisez Ctrl+R pour enregistrer un message vocal.", strings.Join(m.session.Participants, ", "))
+	m.messages = []string{RenderAssistantMessage("", welcome, m.width, 0, false)}
+
+	for i, message := range m.session.Transcript {
+		isLatest := i == len(m.session.Transcript)-1
+		if message.Role == "user" {
+			m.messages = append(m.messages, RenderUserMessage(message.Content, m.width, i, isLatest))
+		} else {
+			m.messages = append(m.messages, RenderGroupMessage(message.Name, message.Content, m.width))
+		}
+	}
+
+	m.viewport.SetContent(strings.Join(m.messages, "\n\n"))
+	m.viewport.GotoBottom()
+}
+
+func (m *GroupChatModel) fail(context string, err error) {
+	m.state = StateError
+	m.statusMsg = ""
+	m.errorMsg = fmt.Sprintf("%s: %v", context, err)
+	m.pending = nil
+}
+
+// submitUserMessage records the user turn and decides who answers it
+func (m *GroupChatModel) submitUserMessage(message string) tea.Cmd {
+	m.session.AddUserMessage(message)
+	m.reRenderMessages()
+	if err := m.manager.SaveGroupSession(m.session); err != nil {
+		m.fail("History save error", err)
+		return nil
+	}
+
+	if m.session.Mode == group.ModeModerator {
+		m.state = StateChatting
+		m.statusMsg = GetStatusStyle(m.width).Render("🎙️ Le modérateur donne la parole...")
+		return m.askModerator()
+	}
+
+	m.pending = append([]string{}, m.session.Participants...)
+	return m.nextSpeaker()
+}
+
+// nextSpeaker asks the next pending participant to answer, or returns to idle
+func (m *GroupChatModel) nextSpeaker() tea.Cmd {
+	if len(m.pending) == 0 {
+		m.state = StateIdle
+		m.statusMsg = ""
+		m.speaker = ""
+		return nil
+	}
+
+	m.speaker, m.pending = m.pending[0], m.pending[1:]
+	m.state = StateChatting
+	m.statusMsg = RenderSpeakerThinkingStatus(m.speaker, m.width)
+	return m.requestReply(m.speaker)
+}
+
+// The commands below only read values captured when they are created and
+// report back through a message, like the single persona chat.
+
+func (m *GroupChatModel) askModerator() tea.Cmd {
+	ai := m.clients[m.session.Participants[0]]
+	session := m.session
+	messages := toOpenAIMessages(session.ModeratorMessages())
+	return func() tea.Msg {
+		response, err := ai.Chat(messages)
+		if err != nil {
+			return moderatorFinishedMsg{err: err}
+		}
+		speaker, ok := session.ParseSpeaker(response)
+		if !ok {
+			// Fall back to the first participant rather than leaving the user unanswered
+			speaker = session.Participants[0]
+		}
+		return moderatorFinishedMsg{speaker: speaker}
+	}
+}
+
+func (m *GroupChatModel) requestReply(speaker string) tea.Cmd {
+	ai := m.clients[speaker]
+	messages := toOpenAIMessages(m.session.MessagesFor(m.personas[speaker]))
+	return func() tea.Msg {
+		response, err := ai.Chat(messages)
+		return groupReplyMsg{speaker: speaker, response: response, err: err}
+	}
+}
+
+func (m *GroupChatModel) generateAudio(speaker, text string) tea.Cmd {
+	ai := m.clients[speaker]
+	instructions := m.personas[speaker].Voice.Instructions
+	return func() tea.Msg {
+		audio, err := ai.GenerateAudio(text, instructions)
+		return audioFinishedMsg{audio: audio, err: err}
+	}
+}
+
+func (m *GroupChatModel) startRecording() tea.Cmd {
+	recorder := m.recorder
+	return func() tea.Msg {
+		filename, err := recorder.Record()
+		return recordingFinishedMsg{filename: filename, err: err}
+	}
+}
+
+func (m *GroupChatModel) transcribeAudio(filename string) tea.Cmd {
+	ai := m.clients[m.session.Participants[0]]
+	return func() tea.Msg {
+		defer os.Remove(filename)
+		file, err := os.Open(filename)
+		if err != nil {
+			return transcriptionFinishedMsg{err: err}
+		}
+		defer file.Close()
+
+		transcript, err := ai.Transcribe(file)
+		return transcriptionFinishedMsg{text: transcript, err: err}
+	}
+}
+
+// playAudio plays the speech of a reply, streamed as it downloads
+func (m *GroupChatModel) playAudio(audio io.Reader) tea.Cmd {
+	play := m.player
+	return func() tea.Msg {
+		return playbackFinishedMsg{err: play(audio)}
+	}
+}
+
+func toOpenAIMessages(messages []persona.Message) []openai.Message {
+	aiMessages := make([]openai.Message, 0, len(messages))
+	for _, message := range messages {
+		aiMessages = append(aiMessages, openai.Message{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+	return aiMessages
+}
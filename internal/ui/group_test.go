@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ctrl-vfr/persona/internal/group"
+	"github.com/ctrl-vfr/persona/internal/persona"
+	"github.com/ctrl-vfr/persona/internal/speak"
+	"github.com/ctrl-vfr/persona/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestGroupChatModel(t *testing.T, mode group.Mode, clients map[string]AIClient) (*GroupChatModel, *storage.Manager) {
+	t.Helper()
+
+	manager := &storage.Manager{BasePath: t.TempDir()}
+	personas := map[string]*persona.Persona{}
+	var names []string
+	for _, name := range []string{"freud", "merlin"} {
+		personas[name] = persona.New(name, persona.Voice{Name: "nova"}, "Tu es "+name+".")
+		names = append(names, name)
+	}
+
+	m := NewGroupChatModel(group.New(names, mode), personas, clients, manager, fakeRecorder{}, speak.NewPlayer())
+	m.player = func(io.Reader) error { return nil }
+	return m, manager
+}
+
+func TestGroupChatModel_Roundtable(t *testing.T) {
+	freud := &fakeAI{response: "Intéressant"}
+	merlin := &fakeAI{response: "Abracadabra"}
+	m, manager := newTestGroupChatModel(t, group.ModeRoundtable, map[string]AIClient{"freud": freud, "merlin": merlin})
+
+	m.textArea.SetValue("Bonjour")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drive(t, m, cmd)
+
+	if m.state != StateIdle {
+		t.Fatalf("Expected state Idle, got %d (error: %q)", m.state, m.errorMsg)
+	}
+
+	transcript := m.session.Transcript
+	if len(transcript) != 3 || transcript[1].Name != "freud" || transcript[2].Name != "merlin" {
+		t.Fatalf("Expected every participant to answer in turn, got %+v", transcript)
+	}
+
+	// Merlin sees Freud's reply attributed by name
+	sent := merlin.chatCalls[0]
+	if last := sent[len(sent)-1]; last.Role != "user" || last.Content != "[freud] Intéressant" {
+		t.Errorf("Unexpected last message sent to merlin: %+v", last)
+	}
+
+	saved, err := manager.GetGroupSession("freud+merlin")
+	if err != nil {
+		t.Fatalf("Failed to load saved session: %v", err)
+	}
+	if len(saved.Transcript) != 3 {
+		t.Errorf("Expected 3 saved messages, got %d", len(saved.Transcript))
+	}
+}
+
+func TestGroupChatModel_Moderator(t *testing.T) {
+	// The moderator is asked through the first participant's client
+	freud := &fakeAI{response: "merlin"}
+	merlin := &fakeAI{response: "Me voici"}
+	m, _ := newTestGroupChatModel(t, group.ModeModerator, map[string]AIClient{"freud": freud, "merlin": merlin})
+	m.isMuted = true
+
+	m.textArea.SetValue("Qui veut parler ?")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drive(t, m, cmd)
+
+	transcript := m.session.Transcript
+	if len(transcript) != 2 || transcript[1].Name != "merlin" || transcript[1].Content != "Me voici" {
+		t.Errorf("Expected only merlin to answer, got %+v", transcript)
+	}
+	if len(freud.chatCalls) != 1 {
+		t.Errorf("Expected a single moderator call, got %d", len(freud.chatCalls))
+	}
+}
+
+func TestGroupChatModel_ErrorStopsTheRound(t *testing.T) {
+	freud := &fakeAI{chatErr: errors.New("boom")}
+	merlin := &fakeAI{response: "Abracadabra"}
+	m, _ := newTestGroupChatModel(t, group.ModeRoundtable, map[string]AIClient{"freud": freud, "merlin": merlin})
+
+	m.textArea.SetValue("Bonjour")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	drive(t, m, cmd)
+
+	if m.state != StateError || !strings.Contains(m.errorMsg, "freud") {
+		t.Fatalf("Expected an error naming freud, got state %d (%q)", m.state, m.errorMsg)
+	}
+	if len(merlin.chatCalls) != 0 {
+		t.Error("Remaining participants should not answer after an error")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m.state != StateIdle {
+		t.Errorf("Expected any key to return to Idle, got %d", m.state)
+	}
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"io"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/speak"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SeekStep is how far ←/→ move in the reply being played
+const SeekStep = 5 * time.Second
+
+// Playback controls the reply being played
+type Playback interface {
+	TogglePause() bool
+	Seek(offset time.Duration) error
+	SetSpeed(speed float64) float64
+	SetVolume(volume float64) float64
+	Status() speak.Status
+	Stop()
+}
+
+// Player plays the replies and controls the one being played. A process
+// shares one between its models, so that a single player stays on the
+// speaker.
+type Player interface {
+	Playback
+	Play(audio io.Reader) error
+}
+
+// PlaybackHelp lists the keys controlling the reply being played
+const PlaybackHelp = "💡 Espace: Pause | ←/→: ±5s | [/]: Vitesse | -/+: Volume"
+
+// controlPlayback applies a key to the playback, and reports whether it was
+// a playback key
+func controlPlayback(playback Playback, key string) bool {
+	status := playback.Status()
+	switch key {
+	case " ":
+		playback.TogglePause()
+	case "left":
+		// A failed seek keeps playing from where it was
+		_ = playback.Seek(-SeekStep)
+	case "right":
+		_ = playback.Seek(SeekStep)
+	case "[":
+		playback.SetSpeed(status.Speed - speak.SpeedStep)
+	case "]":
+		playback.SetSpeed(status.Speed + speak.SpeedStep)
+	case "-":
+		playback.SetVolume(status.Volume - speak.VolumeStep)
+	case "+", "=":
+		playback.SetVolume(status.Volume + speak.VolumeStep)
+	default:
+		return false
+	}
+	return true
+}
+
+// renderPlayback shows the progress of the reply being played, or the
+// playing status between two clips
+func renderPlayback(playback Playback, statusMsg string, terminalWidth int) string {
+	status := playback.Status()
+	if !status.Playing {
+		return statusMsg
+	}
+	return RenderPlaybackStatus(status, terminalWidth)
+}
+
+// discardAudio reads unplayed speech to its end, so that it is cached, then
+// releases it
+func discardAudio(audio io.Reader) tea.Cmd {
+	return func() tea.Msg {
+		_, _ = io.Copy(io.Discard, audio)
+		if closer, ok := audio.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil
+	}
+}
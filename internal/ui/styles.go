@@ -1,8 +1,14 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/schema"
+	"github.com/ctrl-vfr/persona/internal/speak"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -90,6 +96,21 @@ var (
 	SystemMessageStyle = lipgloss.NewStyle().
 				Foreground(MutedColor).
 				Italic(true)
+
+	SelectedMessageStyle = lipgloss.NewStyle().
+				Border(lipgloss.ThickBorder(), false, false, false, true).
+				BorderForeground(AccentColor)
+
+	// Editor styles
+	EditorFocusedLabelStyle = lipgloss.NewStyle().
+				Foreground(PrimaryColor).
+				Bold(true)
+
+	EditorSelectedVoiceStyle = lipgloss.NewStyle().
+					Foreground(BackgroundColor).
+					Background(AccentColor).
+					Bold(true).
+					Padding(0, 1)
 	// Status styles
 	SuccessStyle = lipgloss.NewStyle().
 			Foreground(SuccessColor).
@@ -262,6 +283,34 @@ func RenderInfo(message string) string {
 	return InfoStyle.Render("ℹ️  " + message)
 }
 
+// RenderDiagnostics renders schema diagnostics one per line, errors first
+func RenderDiagnostics(diagnostics []schema.Diagnostic) string {
+	var lines []string
+	for _, severity := range []schema.Severity{schema.SeverityError, schema.SeverityWarning} {
+		for _, diagnostic := range diagnostics {
+			if diagnostic.Severity != severity {
+				continue
+			}
+			message := fmt.Sprintf("%d:%d %s", diagnostic.Line, diagnostic.Column, diagnostic.Message)
+			if severity == schema.SeverityError {
+				lines = append(lines, RenderError(message))
+			} else {
+				lines = append(lines, RenderWarning(message))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderLoadError renders an error, listing its diagnostics when a file failed validation
+func RenderLoadError(context string, err error) string {
+	var invalid *schema.Error
+	if errors.As(err, &invalid) {
+		return RenderError(context+":") + "\n" + RenderDiagnostics(invalid.Diagnostics)
+	}
+	return RenderError(fmt.Sprintf("%s: %v", context, err))
+}
+
 // RenderUserMessage message renderers with PlaceHorizontal:
 func RenderUserMessage(message string, terminalWidth int, messageIndex int, isLatest bool) string {
 	// Add subtle time indicator for older messages
@@ -287,6 +336,52 @@ func RenderAssistantMessage(personaName, message string, terminalWidth int, mess
 	return lipgloss.PlaceHorizontal(terminalWidth-(2*HORIZONTAL_MARGIN), lipgloss.Left, styledMessage)
 }
 
+// RenderGroupMessage renders a participant's reply in a group chat, headed by its name
+func RenderGroupMessage(speaker, message string, terminalWidth int) string {
+	name := lipgloss.NewStyle().Bold(true).Foreground(AccentColor).Render("🎭 " + speaker)
+	styledMessage := GetAssistantMessageStyle(terminalWidth).Render(name + "\n" + message)
+	return lipgloss.PlaceHorizontal(terminalWidth-(2*HORIZONTAL_MARGIN), lipgloss.Left, styledMessage)
+}
+
+// RenderMessageInfo renders the details shown under a message, such as its
+// time and branches
+func RenderMessageInfo(parts []string) string {
+	return MutedStyle.Render(strings.Join(parts, " · "))
+}
+
+// RelativeTime describes t relatively to now, e.g. "il y a 5 min"
+func RelativeTime(t, now time.Time) string {
+	elapsed := now.Sub(t)
+	switch {
+	case elapsed < time.Minute:
+		return "à l'instant"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("il y a %d min", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("il y a %d h", int(elapsed.Hours()))
+	case elapsed < 48*time.Hour:
+		return "hier"
+	case elapsed < 7*24*time.Hour:
+		return fmt.Sprintf("il y a %d jours", int(elapsed.Hours()/24))
+	}
+	return t.Format("02/01/2006")
+}
+
+// RenderMatch highlights a search match in a message
+func RenderMatch(text string) string {
+	return lipgloss.NewStyle().Bold(true).Foreground(AccentColor).Render(text)
+}
+
+// RenderBranchIndicator shows which alternative of a message is displayed
+func RenderBranchIndicator(position, total int) string {
+	return fmt.Sprintf("‹ %d/%d ›", position, total)
+}
+
+// RenderSelectedMessage highlights the message selected in the chat history
+func RenderSelectedMessage(rendered string) string {
+	return SelectedMessageStyle.Render(rendered)
+}
+
 // RenderRecordingStatus Status messages with animated emojis
 func RenderRecordingStatus(terminalWidth int) string {
 	return GetStatusStyle(terminalWidth).Render("🎤 🔴 Enregistrement en cours... Parlez maintenant!")
@@ -302,6 +397,11 @@ func RenderThinkingStatus(terminalWidth int) string {
 	return GetStatusStyle(terminalWidth).Render("🤔 💭 Réflexion en cours...")
 }
 
+// RenderSpeakerThinkingStatus shows which group participant is preparing an answer
+func RenderSpeakerThinkingStatus(speaker string, terminalWidth int) string {
+	return GetStatusStyle(terminalWidth).Render(fmt.Sprintf("🤔 💭 %s réfléchit...", speaker))
+}
+
 // RenderGeneratingAudioStatus Status messages with animated emojis
 func RenderGeneratingAudioStatus(terminalWidth int) string {
 	return GetStatusStyle(terminalWidth).Render("🎵 🔊 Génération audio en cours...")
@@ -312,6 +412,34 @@ func RenderPlayingStatus(terminalWidth int) string {
 	return GetStatusStyle(terminalWidth).Render("🔈 🎶 Lecture en cours...")
 }
 
+// RenderPlaybackStatus shows the progress, speed and volume of the reply
+// being played
+func RenderPlaybackStatus(status speak.Status, terminalWidth int) string {
+	icon := "▶️ "
+	if status.Paused {
+		icon = "⏸️ "
+	}
+	// The length of a reply streamed as it downloads is unknown
+	progress := formatClock(status.Position)
+	if status.Duration > 0 {
+		const barWidth = 20
+		filled := min(int(barWidth*status.Position/status.Duration), barWidth)
+		bar := strings.Repeat("━", filled) + strings.Repeat("─", barWidth-filled)
+		progress = fmt.Sprintf("%s %s %s", progress, bar, formatClock(status.Duration))
+	}
+	line := fmt.Sprintf("🔈 %s %s · ×%s · 🔉 %.0f%%", icon, progress, strconv.FormatFloat(status.Speed, 'f', -1, 64), status.Volume*100)
+	if status.Queued > 0 {
+		line += fmt.Sprintf(" · +%d en attente", status.Queued)
+	}
+	return GetStatusStyle(terminalWidth).Render(line)
+}
+
+// formatClock formats a playback position as minutes and seconds
+func formatClock(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
 // RenderMutedStatus Status messages with animated emojis
 func RenderMutedStatus(terminalWidth int) string {
 	return GetStatusStyle(terminalWidth).Render("🔇 Mode silencieux activé")
@@ -0,0 +1,122 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+)
+
+// Budget actions
+const (
+	ActionWarn  = "warn"
+	ActionBlock = "block"
+)
+
+// ErrBudgetExceeded is returned by the meter when the monthly budget is spent
+// and the budget action is block
+var ErrBudgetExceeded = errors.New("monthly budget exceeded")
+
+// Budget caps the estimated cost of a calendar month
+type Budget struct {
+	// Monthly is in US dollars, no limit when zero
+	Monthly float64
+	// Action is warn or block
+	Action string
+}
+
+// Meter records the API calls of the clients in a ledger and enforces the
+// budget. It implements openai.Meter and is shared by the clients.
+type Meter struct {
+	ledger *Ledger
+	prices Prices
+	budget Budget
+	now    func() time.Time
+
+	mu sync.Mutex
+	// month is the start of the month spent was computed for, zero until
+	// the ledger is read
+	month time.Time
+	spent float64
+	// offset is how far the ledger was read, the calls of every instance
+	// being appended to it
+	offset int64
+}
+
+// NewMeter returns a meter appending to ledger
+func NewMeter(ledger *Ledger, prices Prices, budget Budget) *Meter {
+	return &Meter{ledger: ledger, prices: prices, budget: budget, now: time.Now}
+}
+
+// Allow refuses the calls once the monthly budget is spent, when its action
+// is block
+func (m *Meter) Allow() error {
+	if m.budget.Monthly <= 0 || m.budget.Action != ActionBlock {
+		return nil
+	}
+	spent, err := m.Spent()
+	if err != nil {
+		// An unreadable ledger does not stop the conversation
+		return nil
+	}
+	if spent >= m.budget.Monthly {
+		return fmt.Errorf("%w: $%.2f spent of $%.2f, see persona usage", ErrBudgetExceeded, spent, m.budget.Monthly)
+	}
+	return nil
+}
+
+// Record appends the call to the ledger. Failing to record never fails the
+// call itself.
+func (m *Meter) Record(call openai.Call) {
+	_ = m.ledger.Append(NewRecord(call, m.now()))
+}
+
+// Spent returns the estimated cost of the current month. Other instances
+// append to the same ledger, so every call reads the records added since the
+// last one, and the whole ledger again when the month changes or the ledger
+// shrinks.
+func (m *Meter) Spent() (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	size, err := m.ledger.Size()
+	if err != nil {
+		return 0, err
+	}
+	month := MonthStart(m.now())
+	if !m.month.Equal(month) || size < m.offset {
+		m.month, m.spent, m.offset = month, 0, 0
+	}
+	if size == m.offset {
+		return m.spent, nil
+	}
+
+	records, offset, err := m.ledger.ReadFrom(m.offset, month)
+	if err != nil {
+		return 0, err
+	}
+	m.offset = offset
+	m.spent += Total(records, m.prices).Cost
+	return m.spent, nil
+}
+
+// OverBudget reports whether the monthly budget is spent
+func (m *Meter) OverBudget() bool {
+	if m.budget.Monthly <= 0 {
+		return false
+	}
+	spent, err := m.Spent()
+	return err == nil && spent >= m.budget.Monthly
+}
+
+// Budget returns the budget enforced by the meter
+func (m *Meter) Budget() Budget {
+	return m.budget
+}
+
+// MonthStart returns the first instant of the month of t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
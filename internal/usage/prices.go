@@ -0,0 +1,83 @@
+package usage
+
+import (
+	"maps"
+	"strings"
+)
+
+// Price is what a model costs, in US dollars. Chat models are billed by
+// token, speech models by character and transcription models by minute.
+type Price struct {
+	// Input is the price of a million prompt tokens
+	Input float64 `yaml:"input,omitempty" json:"input,omitempty"`
+	// Output is the price of a million completion tokens
+	Output float64 `yaml:"output,omitempty" json:"output,omitempty"`
+	// Characters is the price of a million spoken characters
+	Characters float64 `yaml:"characters,omitempty" json:"characters,omitempty"`
+	// Minute is the price of a minute of transcribed audio
+	Minute float64 `yaml:"minute,omitempty" json:"minute,omitempty"`
+}
+
+// Prices maps model names to their price
+type Prices map[string]Price
+
+// defaultPrices are the public OpenAI prices of the known models. The speech
+// price of gpt-4o-mini-tts is an estimate, the model being billed by token.
+var defaultPrices = Prices{
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4o":        {Input: 2.50, Output: 10},
+	"gpt-4.1":       {Input: 2, Output: 8},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":  {Input: 0.10, Output: 0.40},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4":         {Input: 30, Output: 60},
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+	"o3-mini":       {Input: 1.10, Output: 4.40},
+	"o4-mini":       {Input: 1.10, Output: 4.40},
+
+	"tts-1":           {Characters: 15},
+	"tts-1-hd":        {Characters: 30},
+	"gpt-4o-mini-tts": {Characters: 15},
+
+	"whisper-1":              {Minute: 0.006},
+	"gpt-4o-transcribe":      {Minute: 0.006},
+	"gpt-4o-mini-transcribe": {Minute: 0.003},
+}
+
+// DefaultPrices returns the built-in price table, with overrides replacing
+// or adding models
+func DefaultPrices(overrides Prices) Prices {
+	prices := maps.Clone(defaultPrices)
+	maps.Copy(prices, overrides)
+	return prices
+}
+
+// Lookup returns the price of a model. The API answers with dated names such
+// as gpt-4o-mini-2024-07-18, so the longest model name prefixing it wins.
+func (p Prices) Lookup(model string) (Price, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+	best := ""
+	for name := range p {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return p[best], true
+}
+
+// Cost estimates the cost of a record, reporting false for unknown models
+func (p Prices) Cost(record Record) (float64, bool) {
+	price, ok := p.Lookup(record.Model)
+	if !ok {
+		return 0, false
+	}
+	return float64(record.PromptTokens)/1e6*price.Input +
+		float64(record.CompletionTokens)/1e6*price.Output +
+		float64(record.Characters)/1e6*price.Characters +
+		record.AudioSeconds/60*price.Minute, true
+}
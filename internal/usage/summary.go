@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Groupings of a summary
+var Groupings = []string{"persona", "model", "day", "kind"}
+
+// Summary totals the records sharing a key
+type Summary struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Characters       int     `json:"characters"`
+	AudioSeconds     float64 `json:"audio_seconds"`
+	Cost             float64 `json:"cost"`
+	// Unpriced counts the calls to models missing from the price table,
+	// left out of Cost
+	Unpriced int `json:"unpriced,omitempty"`
+}
+
+// add counts a record in the summary
+func (s *Summary) add(record Record, prices Prices) {
+	s.Calls++
+	s.PromptTokens += record.PromptTokens
+	s.CompletionTokens += record.CompletionTokens
+	s.Characters += record.Characters
+	s.AudioSeconds += record.AudioSeconds
+	if cost, ok := prices.Cost(record); ok {
+		s.Cost += cost
+	} else {
+		s.Unpriced++
+	}
+}
+
+// Summarize groups the records by persona, model, day or kind. Days are in
+// chronological order, the other groups by decreasing cost.
+func Summarize(records []Record, by string, prices Prices) ([]Summary, error) {
+	var key func(Record) string
+	switch by {
+	case "persona":
+		key = func(r Record) string { return cmp.Or(r.Persona, "-") }
+	case "model":
+		key = func(r Record) string { return r.Model }
+	case "day":
+		key = func(r Record) string { return r.Time.Local().Format(time.DateOnly) }
+	case "kind":
+		key = func(r Record) string { return r.Kind }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (groupings: %s)", by, strings.Join(Groupings, ", "))
+	}
+
+	index := map[string]int{}
+	var summaries []Summary
+	for _, record := range records {
+		k := key(record)
+		i, ok := index[k]
+		if !ok {
+			i = len(summaries)
+			index[k] = i
+			summaries = append(summaries, Summary{Key: k})
+		}
+		summaries[i].add(record, prices)
+	}
+
+	slices.SortFunc(summaries, func(a, b Summary) int {
+		if by == "day" {
+			return strings.Compare(a.Key, b.Key)
+		}
+		if c := cmp.Compare(b.Cost, a.Cost); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return summaries, nil
+}
+
+// Total sums the records
+func Total(records []Record, prices Prices) Summary {
+	total := Summary{Key: "total"}
+	for _, record := range records {
+		total.add(record, prices)
+	}
+	return total
+}
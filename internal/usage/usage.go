@@ -0,0 +1,130 @@
+// Package usage records the API calls in a local ledger and estimates what
+// they cost.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+)
+
+// Record is an API call in the ledger
+type Record struct {
+	Time             time.Time `json:"time"`
+	Kind             string    `json:"kind"`
+	Persona          string    `json:"persona,omitempty"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	Characters       int       `json:"characters,omitempty"`
+	AudioSeconds     float64   `json:"audio_seconds,omitempty"`
+}
+
+// NewRecord records a call made at t
+func NewRecord(call openai.Call, t time.Time) Record {
+	return Record{
+		Time:             t,
+		Kind:             call.Kind,
+		Persona:          call.Persona,
+		Model:            call.Model,
+		PromptTokens:     call.PromptTokens,
+		CompletionTokens: call.CompletionTokens,
+		Characters:       call.Characters,
+		AudioSeconds:     call.AudioSeconds,
+	}
+}
+
+// Ledger is a file of records, one JSON object per line, only ever appended
+// to so that concurrent instances do not lose calls
+type Ledger struct {
+	path string
+}
+
+// NewLedger returns the ledger stored at path
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Append adds a record at the end of the ledger
+func (l *Ledger) Append(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// Read returns the records made since the given time, all of them when it is
+// zero. Damaged lines, such as a write cut short, are skipped.
+func (l *Ledger) Read(since time.Time) ([]Record, error) {
+	records, _, err := l.ReadFrom(0, since)
+	return records, err
+}
+
+// ReadFrom is Read starting offset bytes into the ledger. It also returns the
+// offset following the last complete line, to read the records appended
+// since on the next call; a line still being written is left for then.
+func (l *Ledger) ReadFrom(offset int64, since time.Time) ([]Record, int64, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read usage: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	var records []Record
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read usage: %w", err)
+		}
+		offset += int64(len(line))
+
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			continue
+		}
+		if !record.Time.Before(since) {
+			records = append(records, record)
+		}
+	}
+	return records, offset, nil
+}
+
+// Size returns the size of the ledger in bytes, zero when it does not exist
+func (l *Ledger) Size() (int64, error) {
+	info, err := os.Stat(l.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read usage: %w", err)
+	}
+	return info.Size(), nil
+}
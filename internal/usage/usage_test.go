@@ -0,0 +1,194 @@
+package usage
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ctrl-vfr/persona/internal/openai"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLedger_AppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	ledger := NewLedger(path)
+
+	records, err := ledger.Read(time.Time{})
+	if err != nil || records != nil {
+		t.Fatalf("Expected an empty ledger, got %v, %v", records, err)
+	}
+
+	old := time.Date(2025, 8, 31, 23, 0, 0, 0, time.UTC)
+	recent := time.Date(2025, 9, 2, 10, 0, 0, 0, time.UTC)
+	if err := ledger.Append(Record{Time: old, Kind: openai.CallChat, Model: "gpt-4o-mini", PromptTokens: 10}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	// A write cut short does not hide the following records
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open ledger: %v", err)
+	}
+	f.WriteString(`{"time":"2025-09-01`)
+	f.WriteString("\n")
+	f.Close()
+	if err := ledger.Append(Record{Time: recent, Kind: openai.CallSpeech, Persona: "coach", Model: "tts-1", Characters: 42}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+
+	records, err = ledger.Read(time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	records, _ = ledger.Read(MonthStart(recent))
+	if len(records) != 1 || records[0].Characters != 42 || records[0].Persona != "coach" {
+		t.Errorf("Expected the records of September only, got %+v", records)
+	}
+}
+
+func TestPrices_LookupAndCost(t *testing.T) {
+	prices := DefaultPrices(Prices{"local-llama": {Input: 1, Output: 2}, "tts-1": {Characters: 10}})
+
+	// Dated names match the longest known prefix
+	price, ok := prices.Lookup("gpt-4o-mini-2024-07-18")
+	if !ok || price != defaultPrices["gpt-4o-mini"] {
+		t.Errorf("Expected the gpt-4o-mini price, got %+v", price)
+	}
+	if _, ok := prices.Lookup("gpt-4ox"); ok {
+		t.Error("Expected no price for a name merely starting like a model")
+	}
+
+	tests := []struct {
+		record Record
+		cost   float64
+	}{
+		{Record{Model: "local-llama", PromptTokens: 1_000_000, CompletionTokens: 500_000}, 2},
+		{Record{Model: "tts-1", Characters: 100_000}, 1},
+		{Record{Model: "whisper-1", AudioSeconds: 120}, 0.012},
+	}
+	for _, test := range tests {
+		cost, ok := prices.Cost(test.record)
+		if !ok || !almostEqual(cost, test.cost) {
+			t.Errorf("Expected %v for %s, got %v", test.cost, test.record.Model, cost)
+		}
+	}
+	if _, ok := prices.Cost(Record{Model: "mystery"}); ok {
+		t.Error("Expected unknown models to have no cost")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	prices := Prices{"cheap": {Input: 1}, "dear": {Input: 10}}
+	day := time.Date(2025, 9, 2, 12, 0, 0, 0, time.Local)
+	records := []Record{
+		{Time: day.AddDate(0, 0, 1), Persona: "coach", Model: "cheap", PromptTokens: 1_000_000},
+		{Time: day, Persona: "freud", Model: "dear", PromptTokens: 1_000_000},
+		{Time: day, Persona: "freud", Model: "mystery"},
+		{Time: day, Model: "cheap", PromptTokens: 1_000_000},
+	}
+
+	byPersona, err := Summarize(records, "persona", prices)
+	if err != nil {
+		t.Fatalf("Failed to summarize: %v", err)
+	}
+	if len(byPersona) != 3 || byPersona[0].Key != "freud" || byPersona[0].Calls != 2 || byPersona[0].Unpriced != 1 {
+		t.Errorf("Expected freud first by cost, got %+v", byPersona)
+	}
+	if byPersona[1].Key != "-" {
+		t.Errorf("Expected calls outside of a persona under -, got %q", byPersona[1].Key)
+	}
+
+	byDay, _ := Summarize(records, "day", prices)
+	if len(byDay) != 2 || byDay[0].Key != "2025-09-02" || byDay[0].Calls != 3 {
+		t.Errorf("Expected days in chronological order, got %+v", byDay)
+	}
+
+	if total := Total(records, prices); total.Calls != 4 || !almostEqual(total.Cost, 12) {
+		t.Errorf("Expected 4 calls costing 12, got %+v", total)
+	}
+	if _, err := Summarize(records, "voice", prices); err == nil {
+		t.Error("Expected an error for an unknown grouping")
+	}
+}
+
+func TestMeter_Budget(t *testing.T) {
+	now := time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC)
+	ledger := NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	// Last month does not count
+	ledger.Append(Record{Time: now.AddDate(0, -1, 0), Model: "chat", PromptTokens: 5_000_000})
+	ledger.Append(Record{Time: now.AddDate(0, 0, -1), Model: "chat", PromptTokens: 1_000_000})
+
+	newMeter := func(action string) *Meter {
+		m := NewMeter(ledger, Prices{"chat": {Input: 1}}, Budget{Monthly: 2, Action: action})
+		m.now = func() time.Time { return now }
+		return m
+	}
+
+	meter := newMeter(ActionBlock)
+	if err := meter.Allow(); err != nil {
+		t.Fatalf("Expected calls under the budget to be allowed, got %v", err)
+	}
+	if spent, _ := meter.Spent(); !almostEqual(spent, 1) {
+		t.Errorf("Expected 1 spent this month, got %v", spent)
+	}
+
+	meter.Record(openai.Call{Kind: openai.CallChat, Persona: "coach", Model: "chat", PromptTokens: 1_500_000})
+	if !meter.OverBudget() {
+		t.Error("Expected the recorded call to exceed the budget")
+	}
+	if err := meter.Allow(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected the budget to block the calls, got %v", err)
+	}
+
+	records, _ := ledger.Read(MonthStart(now))
+	if len(records) != 2 || records[1].Persona != "coach" || !records[1].Time.Equal(now) {
+		t.Errorf("Expected the call in the ledger, got %+v", records)
+	}
+
+	if err := newMeter(ActionWarn).Allow(); err != nil {
+		t.Errorf("Expected the warn action to allow the calls, got %v", err)
+	}
+	if err := newMeter(ActionBlock).Allow(); err == nil {
+		t.Error("Expected a new meter to read the spent budget from the ledger")
+	}
+}
+
+func TestMeter_SharedLedger(t *testing.T) {
+	now := time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC)
+	ledger := NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	newMeter := func() *Meter {
+		m := NewMeter(ledger, Prices{"chat": {Input: 1}}, Budget{Monthly: 2, Action: ActionBlock})
+		m.now = func() time.Time { return now }
+		return m
+	}
+	mine, other := newMeter(), newMeter()
+
+	if err := mine.Allow(); err != nil {
+		t.Fatalf("Expected an empty ledger to allow the calls, got %v", err)
+	}
+	other.Record(openai.Call{Kind: openai.CallChat, Model: "chat", PromptTokens: 1_500_000})
+	if spent, _ := mine.Spent(); !almostEqual(spent, 1.5) {
+		t.Errorf("Expected the calls of another instance to count, got %v", spent)
+	}
+	mine.Record(openai.Call{Kind: openai.CallChat, Model: "chat", PromptTokens: 1_000_000})
+	if err := other.Allow(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected the other instance to be blocked, got %v", err)
+	}
+	if spent, _ := mine.Spent(); !almostEqual(spent, 2.5) {
+		t.Errorf("Expected each call counted once, got %v", spent)
+	}
+
+	// A new month starts from the records of that month
+	now = now.AddDate(0, 1, 0)
+	if spent, _ := mine.Spent(); spent != 0 {
+		t.Errorf("Expected nothing spent in the new month, got %v", spent)
+	}
+}
@@ -26,6 +26,7 @@ type PersonaWatcher struct {
 type InstanceManager struct {
 	lockFilePath string
 	instanceID   string
+	personaName  string
 	manager      *storage.Manager
 }
 
@@ -118,14 +119,14 @@ func (pw *PersonaWatcher) handleFileChange(filename string) {
 	basename := filepath.Base(filename)
 
 	switch basename {
-	case "persona.json":
+	case "persona.yaml":
 		if pw.onUpdate != nil {
 			if p, err := pw.manager.GetPersona(pw.personaName); err == nil {
 				pw.onUpdate(p)
 			}
 		}
 
-	case "history.json":
+	case "history.yaml":
 		if pw.onHistoryUpdate != nil {
 			if p, err := pw.manager.GetPersona(pw.personaName); err == nil {
 				pw.onHistoryUpdate(p.History)
@@ -134,18 +135,68 @@ func (pw *PersonaWatcher) handleFileChange(filename string) {
 	}
 }
 
-// NewInstanceManager creates a new instance manager
-func NewInstanceManager(manager *storage.Manager) *InstanceManager {
+// NewInstanceManager creates a new instance manager for an instance chatting
+// with personaName
+func NewInstanceManager(manager *storage.Manager, personaName string) *InstanceManager {
 	instanceID := fmt.Sprintf("persona_%d_%d", os.Getpid(), time.Now().Unix())
-	lockFilePath := filepath.Join(manager.BasePath, ".instances.json")
 
 	return &InstanceManager{
-		lockFilePath: lockFilePath,
+		lockFilePath: instancesPath(manager),
 		instanceID:   instanceID,
+		personaName:  personaName,
 		manager:      manager,
 	}
 }
 
+func instancesPath(manager *storage.Manager) string {
+	return filepath.Join(manager.BasePath, ".instances.json")
+}
+
+// CountActiveInstances returns how many running instances are registered for
+// a persona
+func CountActiveInstances(manager *storage.Manager, name string) (int, error) {
+	im := &InstanceManager{lockFilePath: instancesPath(manager), manager: manager}
+	instances, err := im.loadInstances()
+	if err != nil {
+		return 0, err
+	}
+
+	active := 0
+	for _, info := range instances {
+		if info.Persona == name && time.Since(info.LastSeen) <= 5*time.Minute {
+			active++
+		}
+	}
+	return active, nil
+}
+
+// RenamePersona updates the instances registered for a renamed persona and
+// returns how many of them are still active
+func RenamePersona(manager *storage.Manager, oldName, newName string) (int, error) {
+	im := &InstanceManager{lockFilePath: instancesPath(manager), manager: manager}
+	instances, err := im.loadInstances()
+	if err != nil {
+		return 0, err
+	}
+
+	renamed, active := 0, 0
+	for id, info := range instances {
+		if info.Persona != oldName {
+			continue
+		}
+		info.Persona = newName
+		instances[id] = info
+		renamed++
+		if time.Since(info.LastSeen) <= 5*time.Minute {
+			active++
+		}
+	}
+	if renamed == 0 {
+		return 0, nil
+	}
+	return active, im.saveInstances(instances)
+}
+
 // RegisterInstance registers this instance
 func (im *InstanceManager) RegisterInstance() error {
 	instances, err := im.loadInstances()
@@ -155,6 +206,7 @@ func (im *InstanceManager) RegisterInstance() error {
 
 	instances[im.instanceID] = InstanceInfo{
 		PID:       os.Getpid(),
+		Persona:   im.personaName,
 		StartTime: time.Now(),
 		LastSeen:  time.Now(),
 	}
@@ -215,6 +267,7 @@ func (im *InstanceManager) GetActiveInstances() (map[string]InstanceInfo, error)
 // InstanceInfo holds information about a running instance
 type InstanceInfo struct {
 	PID       int       `json:"pid"`
+	Persona   string    `json:"persona,omitempty"`
 	StartTime time.Time `json:"start_time"`
 	LastSeen  time.Time `json:"last_seen"`
 }